@@ -0,0 +1,266 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultActivityWatchURL is where aw-server listens by default
+	defaultActivityWatchURL = "http://localhost:5600"
+	// defaultActivityWatchInterval is how often the focused window is
+	// heartbeated while it doesn't change
+	defaultActivityWatchInterval = 10 * time.Second
+	// maxQueuedHeartbeats keeps about a day of heartbeats while the server is down
+	maxQueuedHeartbeats = 8640
+	// activityWatchTimeout is how long one request to the server may take
+	activityWatchTimeout = 5 * time.Second
+)
+
+// ActivityWatchConfig configures sending window heartbeats to ActivityWatch
+// in settings.json
+type ActivityWatchConfig struct {
+	Enabled         bool   `json:"enabled"`
+	URL             string `json:"url,omitempty"`             // aw-server, "" = http://localhost:5600
+	IntervalSeconds int    `json:"intervalSeconds,omitempty"` // 0 = every 10 seconds
+	Hostname        string `json:"hostname,omitempty"`        // Part of the bucket ID, "" = this machine's name
+}
+
+// url returns the server URL without a trailing slash, falling back to the default
+func (c ActivityWatchConfig) url() string {
+	if c.URL == "" {
+		return defaultActivityWatchURL
+	}
+	return strings.TrimSuffix(c.URL, "/")
+}
+
+// interval returns the heartbeat interval, falling back to the default
+func (c ActivityWatchConfig) interval() time.Duration {
+	if c.IntervalSeconds <= 0 {
+		return defaultActivityWatchInterval
+	}
+	return time.Duration(c.IntervalSeconds) * time.Second
+}
+
+// hostname returns the configured host name, falling back to the machine's
+func (c ActivityWatchConfig) hostname() string {
+	if c.Hostname != "" {
+		return c.Hostname
+	}
+	if name, err := os.Hostname(); err == nil {
+		return name
+	}
+	return "unknown"
+}
+
+// awBucket is the body creating a bucket
+type awBucket struct {
+	Client   string `json:"client"`
+	Type     string `json:"type"`
+	Hostname string `json:"hostname"`
+}
+
+// awHeartbeat is one heartbeat in the format of aw-watcher-window
+type awHeartbeat struct {
+	Timestamp time.Time    `json:"timestamp"`
+	Duration  float64      `json:"duration"`
+	Data      awWindowData `json:"data"`
+}
+
+// awWindowData is what aw-watcher-window reports about the focused window
+type awWindowData struct {
+	App   string `json:"app"`
+	Title string `json:"title"`
+}
+
+// ActivityWatch sends the focused window to an aw-server as heartbeats, the
+// way aw-watcher-window does, so ActivityWatch dashboards show sybr's data.
+// Heartbeats are queued while the server can't be reached and sent once it
+// is back
+type ActivityWatch struct {
+	bus      *EventBus
+	config   ActivityWatchConfig
+	client   *http.Client
+	bucket   string
+	interval time.Duration
+	now      func() time.Time
+
+	// Only the run goroutine touches these
+	window  *WindowInfo
+	queue   []awHeartbeat
+	created bool  // The bucket exists on the server
+	offline error // Why the last attempt failed, nil while the server answers
+
+	mu   sync.Mutex
+	sub  *Subscription
+	done chan struct{}
+}
+
+// NewActivityWatch creates a sender for window changes on the bus; window is
+// the focused window when it starts, may be nil
+func NewActivityWatch(bus *EventBus, config ActivityWatchConfig, window *WindowInfo) *ActivityWatch {
+	return &ActivityWatch{
+		bus:      bus,
+		config:   config,
+		client:   &http.Client{Timeout: activityWatchTimeout},
+		bucket:   "aw-watcher-window_" + config.hostname(),
+		interval: config.interval(),
+		now:      time.Now,
+		window:   window,
+	}
+}
+
+// Start subscribes to window changes and heartbeats the focused window
+func (aw *ActivityWatch) Start() {
+	aw.mu.Lock()
+	defer aw.mu.Unlock()
+	if aw.sub != nil {
+		return
+	}
+	aw.sub = aw.bus.Subscribe(0, EventWindowChanged)
+	aw.done = make(chan struct{})
+	fmt.Printf("📡 Sending window heartbeats to ActivityWatch at %s (bucket %s)\n", aw.config.url(), aw.bucket)
+	go aw.run(aw.sub, aw.done)
+}
+
+// Stop unsubscribes and waits for the current request to finish. Heartbeats
+// still queued are lost
+func (aw *ActivityWatch) Stop() {
+	aw.mu.Lock()
+	sub, done := aw.sub, aw.done
+	aw.sub = nil
+	aw.mu.Unlock()
+	if sub == nil {
+		return
+	}
+	sub.Unsubscribe()
+	<-done
+}
+
+func (aw *ActivityWatch) run(sub *Subscription, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(aw.interval)
+	defer ticker.Stop()
+
+	aw.heartbeat(aw.now())
+	for {
+		select {
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if changed, ok := event.Payload.(WindowChangedEvent); ok {
+				aw.window = &changed.Window
+				aw.heartbeat(event.Time)
+			}
+		case <-ticker.C:
+			// Heartbeats merge into one event as long as the window stays the
+			// same; this also retries queued ones
+			aw.heartbeat(aw.now())
+		}
+	}
+}
+
+// heartbeat queues a heartbeat for the focused window and sends the queue
+func (aw *ActivityWatch) heartbeat(at time.Time) {
+	if aw.window != nil {
+		if len(aw.queue) >= maxQueuedHeartbeats {
+			aw.queue = aw.queue[1:]
+		}
+		aw.queue = append(aw.queue, awHeartbeat{
+			Timestamp: at.UTC(),
+			Data:      awWindowData{App: aw.window.Exe, Title: aw.window.Title},
+		})
+	}
+	aw.flush()
+}
+
+// flush creates the bucket if needed and sends the queued heartbeats in order,
+// stopping at the first that fails
+func (aw *ActivityWatch) flush() {
+	queued := len(aw.queue)
+	for len(aw.queue) > 0 {
+		if !aw.created {
+			if err := aw.createBucket(); err != nil {
+				aw.setOffline(err)
+				return
+			}
+			aw.created = true
+		}
+		if err := aw.send(aw.queue[0]); err != nil {
+			aw.setOffline(err)
+			return
+		}
+		aw.queue = aw.queue[1:]
+	}
+	if aw.offline != nil {
+		fmt.Printf("📡 ActivityWatch is reachable again, sent %d queued heartbeats\n", queued)
+		aw.offline = nil
+	}
+}
+
+// setOffline logs the first failure of an outage
+func (aw *ActivityWatch) setOffline(err error) {
+	if aw.offline == nil {
+		fmt.Printf("⚠️  ActivityWatch unreachable, queueing heartbeats: %v\n", err)
+	}
+	aw.offline = err
+}
+
+// createBucket creates the bucket; it existing already is fine
+func (aw *ActivityWatch) createBucket() error {
+	body := awBucket{Client: "sybr", Type: "currentwindow", Hostname: aw.config.hostname()}
+	status, err := aw.post("/api/0/buckets/"+url.PathEscape(aw.bucket), body)
+	if err != nil {
+		return err
+	}
+	if status != http.StatusOK && status != http.StatusNotModified {
+		return fmt.Errorf("creating bucket %s: server answered %d", aw.bucket, status)
+	}
+	return nil
+}
+
+// send sends one heartbeat. Heartbeats within pulsetime of the last one with
+// the same data extend it, so the pulse covers the interval plus some slack
+func (aw *ActivityWatch) send(heartbeat awHeartbeat) error {
+	pulse := aw.interval.Seconds() + 1
+	path := fmt.Sprintf("/api/0/buckets/%s/heartbeat?pulsetime=%g", url.PathEscape(aw.bucket), pulse)
+	status, err := aw.post(path, heartbeat)
+	switch {
+	case err != nil:
+		return err
+	case status == http.StatusNotFound:
+		// The bucket was deleted, create it again
+		aw.created = false
+		return fmt.Errorf("bucket %s doesn't exist", aw.bucket)
+	case status >= 500:
+		return fmt.Errorf("heartbeat: server answered %d", status)
+	case status >= 400:
+		// Retrying won't help, drop it rather than blocking the queue
+		fmt.Printf("⚠️  ActivityWatch refused a heartbeat (%d)\n", status)
+	}
+	return nil
+}
+
+// post sends v as JSON to path on the server and returns the status code
+func (aw *ActivityWatch) post(path string, v interface{}) (int, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := aw.client.Post(aw.config.url()+path, "application/json", bytes.NewReader(data))
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
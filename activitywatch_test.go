@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeAWServer stands in for aw-server, recording buckets and heartbeats
+type fakeAWServer struct {
+	mu         sync.Mutex
+	buckets    map[string]awBucket
+	heartbeats []awHeartbeat
+	pulsetime  string
+	down       atomic.Bool // Answer 503 to everything
+}
+
+func (f *fakeAWServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if f.down.Load() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	bucket := strings.TrimPrefix(r.URL.Path, "/api/0/buckets/")
+	if id, ok := strings.CutSuffix(bucket, "/heartbeat"); ok {
+		if _, exists := f.buckets[id]; !exists {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		var heartbeat awHeartbeat
+		if err := json.NewDecoder(r.Body).Decode(&heartbeat); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.heartbeats = append(f.heartbeats, heartbeat)
+		f.pulsetime = r.URL.Query().Get("pulsetime")
+		return
+	}
+	if _, exists := f.buckets[bucket]; exists {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	var created awBucket
+	json.NewDecoder(r.Body).Decode(&created)
+	f.buckets[bucket] = created
+}
+
+// titles returns the titles of the heartbeats received so far
+func (f *fakeAWServer) titles() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	titles := make([]string, len(f.heartbeats))
+	for i, heartbeat := range f.heartbeats {
+		titles[i] = heartbeat.Data.Title
+	}
+	return titles
+}
+
+// waitForTitles waits until the server got heartbeats with exactly want,
+// ignoring repeats of the same title
+func (f *fakeAWServer) waitForTitles(t *testing.T, want ...string) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var got []string
+		for _, title := range f.titles() {
+			if len(got) == 0 || got[len(got)-1] != title {
+				got = append(got, title)
+			}
+		}
+		if strings.Join(got, "|") == strings.Join(want, "|") {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("heartbeat titles = %q, want %q", got, want)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// startTestActivityWatch runs a sender against server heartbeating every interval
+func startTestActivityWatch(t *testing.T, bus *EventBus, server *httptest.Server, window *WindowInfo, interval time.Duration) {
+	t.Helper()
+	aw := NewActivityWatch(bus, ActivityWatchConfig{Enabled: true, URL: server.URL + "/", Hostname: "desk"}, window)
+	aw.interval = interval
+	aw.Start()
+	t.Cleanup(aw.Stop)
+}
+
+// TestActivityWatchHeartbeats tests that the bucket is created and that the
+// focused window and every change are sent as aw-watcher-window heartbeats
+func TestActivityWatchHeartbeats(t *testing.T) {
+	fake := &fakeAWServer{buckets: map[string]awBucket{}}
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	bus := NewEventBus()
+
+	startTestActivityWatch(t, bus, server, &WindowInfo{Exe: "code", Title: "main.go"}, 20*time.Millisecond)
+	fake.waitForTitles(t, "main.go")
+	bus.Publish(EventWindowChanged, WindowChangedEvent{Window: WindowInfo{Exe: "firefox", Title: "Docs"}})
+	fake.waitForTitles(t, "main.go", "Docs")
+
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	bucket, ok := fake.buckets["aw-watcher-window_desk"]
+	if !ok || bucket.Type != "currentwindow" || bucket.Hostname != "desk" {
+		t.Errorf("buckets = %+v, want aw-watcher-window_desk of type currentwindow", fake.buckets)
+	}
+	last := fake.heartbeats[len(fake.heartbeats)-1]
+	if last.Data.App != "firefox" || last.Timestamp.IsZero() {
+		t.Errorf("last heartbeat = %+v, want firefox with a timestamp", last)
+	}
+	if fake.pulsetime != "1.02" {
+		t.Errorf("pulsetime = %q, want the interval plus a second", fake.pulsetime)
+	}
+}
+
+// TestActivityWatchQueuesWhileOffline tests that heartbeats made while the
+// server is down are sent in order once it is back
+func TestActivityWatchQueuesWhileOffline(t *testing.T) {
+	fake := &fakeAWServer{buckets: map[string]awBucket{}}
+	fake.down.Store(true)
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	bus := NewEventBus()
+
+	// A long interval so nothing is retried until the server is back
+	startTestActivityWatch(t, bus, server, nil, time.Hour)
+	for _, title := range []string{"one", "two", "three"} {
+		bus.Publish(EventWindowChanged, WindowChangedEvent{Window: WindowInfo{Exe: "code", Title: title}})
+	}
+	time.Sleep(50 * time.Millisecond)
+	if titles := fake.titles(); len(titles) != 0 {
+		t.Fatalf("server got %q while down", titles)
+	}
+
+	fake.down.Store(false)
+	bus.Publish(EventWindowChanged, WindowChangedEvent{Window: WindowInfo{Exe: "code", Title: "four"}})
+	fake.waitForTitles(t, "one", "two", "three", "four")
+}
@@ -0,0 +1,415 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// errAdminAuthRequired is wrapped by every action refused for a missing or
+// expired admin token
+var errAdminAuthRequired = errors.New("admin password required")
+
+const (
+	// adminTokenTTL is how long a token from Authenticate stays valid
+	adminTokenTTL = 5 * time.Minute
+	// adminFreeAttempts is how many wrong passwords are allowed before backing off
+	adminFreeAttempts = 3
+	// adminBaseBackoff is the wait after the first attempt over the limit; it doubles
+	adminBaseBackoff = 30 * time.Second
+	// adminMaxBackoff caps the wait between attempts
+	adminMaxBackoff = 15 * time.Minute
+	// adminMinPasswordLength is the shortest password accepted
+	adminMinPasswordLength = 6
+)
+
+// Argon2id parameters (RFC 9106 second recommendation, with less memory)
+const (
+	argonTime    = 3
+	argonMemory  = 64 * 1024
+	argonThreads = 2
+	argonKeyLen  = 32
+	argonSaltLen = 16
+)
+
+// AdminConfig is the admin password as stored in settings.json. Both values
+// are Argon2id hashes in the PHC string format; empty means no password
+type AdminConfig struct {
+	PasswordHash string `json:"passwordHash,omitempty"`
+	RecoveryHash string `json:"recoveryHash,omitempty"`
+}
+
+// AuthToken is handed out by Authenticate and passed to gated bindings
+type AuthToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// AdminStatus describes the admin protection
+type AdminStatus struct {
+	Enabled      bool      `json:"enabled"`
+	BlockedUntil time.Time `json:"blockedUntil"` // Wrong attempts must wait until then
+}
+
+// adminSettings is the part of SettingsManager AdminAuth needs
+type adminSettings interface {
+	Get() Settings
+	Update(fn func(*Settings) error) error
+}
+
+// AdminAuth protects blocklist and settings changes with an optional password.
+// Wrong passwords are rate limited by authLimiter
+type AdminAuth struct {
+	mu       sync.Mutex
+	clock    Clock
+	settings adminSettings
+	limiter  authLimiter
+	tokens   map[string]time.Time
+	broken   error // Settings couldn't be loaded, refuse everything gated
+}
+
+// NewAdminAuth creates the admin protection; store may be nil
+func NewAdminAuth(clock Clock, settings adminSettings, store *StateStore) *AdminAuth {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &AdminAuth{
+		clock:    clock,
+		settings: settings,
+		limiter:  authLimiter{clock: clock, store: store},
+		tokens:   map[string]time.Time{},
+	}
+}
+
+// refuseAll makes every gated action fail with err; used when the settings
+// that may hold a password couldn't be loaded, so a corrupt file doesn't
+// turn the protection off
+func (aa *AdminAuth) refuseAll(err error) {
+	aa.mu.Lock()
+	defer aa.mu.Unlock()
+	aa.broken = err
+}
+
+// unavailable returns why the protection refuses everything, nil normally
+func (aa *AdminAuth) unavailable() error {
+	if aa == nil {
+		return nil
+	}
+	aa.mu.Lock()
+	defer aa.mu.Unlock()
+	return aa.broken
+}
+
+// Enabled reports whether an admin password is set
+func (aa *AdminAuth) Enabled() bool {
+	if aa.unavailable() != nil {
+		return true
+	}
+	if aa == nil || aa.settings == nil {
+		return false
+	}
+	return aa.settings.Get().Admin.PasswordHash != ""
+}
+
+// Status reports whether a password is set and whether attempts are blocked
+func (aa *AdminAuth) Status() AdminStatus {
+	status := AdminStatus{Enabled: aa.Enabled()}
+	if aa != nil {
+		status.BlockedUntil = aa.limiter.blockedUntil()
+	}
+	return status
+}
+
+// Authorize checks a token for a gated action; anything passes without a password
+func (aa *AdminAuth) Authorize(token, action string) error {
+	if err := aa.unavailable(); err != nil {
+		fmt.Printf("🔑 Refused %s: settings couldn't be loaded\n", action)
+		return fmt.Errorf("%w for %s: settings couldn't be loaded: %v", errAdminAuthRequired, action, err)
+	}
+	if !aa.Enabled() {
+		return nil
+	}
+
+	aa.mu.Lock()
+	defer aa.mu.Unlock()
+	now := aa.clock.Now()
+	for t, expiry := range aa.tokens {
+		if !expiry.After(now) {
+			delete(aa.tokens, t)
+		}
+	}
+	if token != "" {
+		if _, ok := aa.tokens[token]; ok {
+			return nil
+		}
+	}
+	fmt.Printf("🔑 Refused %s: admin password required\n", action)
+	return fmt.Errorf("%w for %s", errAdminAuthRequired, action)
+}
+
+// Authenticate checks the password and returns a short-lived token
+func (aa *AdminAuth) Authenticate(password string) (AuthToken, error) {
+	if err := aa.unavailable(); err != nil {
+		return AuthToken{}, fmt.Errorf("settings couldn't be loaded, fix settings.json first: %w", err)
+	}
+	if !aa.Enabled() {
+		return AuthToken{}, fmt.Errorf("no admin password is set")
+	}
+	if err := aa.limiter.check(); err != nil {
+		return AuthToken{}, err
+	}
+	if !verifyArgon2(aa.settings.Get().Admin.PasswordHash, password) {
+		fmt.Println("🔑 Wrong admin password")
+		return AuthToken{}, aa.limiter.fail("wrong password")
+	}
+	aa.limiter.reset()
+	return aa.issueToken()
+}
+
+// SetPassword sets or changes the admin password and returns a new recovery
+// code, which is only shown this once. Changing an existing password needs a token
+func (aa *AdminAuth) SetPassword(token, password string) (string, error) {
+	if err := aa.Authorize(token, "changing the admin password"); err != nil {
+		return "", err
+	}
+	return aa.storePassword(password)
+}
+
+// ClearPassword removes the admin password
+func (aa *AdminAuth) ClearPassword(token string) error {
+	if err := aa.Authorize(token, "removing the admin password"); err != nil {
+		return err
+	}
+	aa.mu.Lock()
+	aa.tokens = map[string]time.Time{}
+	aa.mu.Unlock()
+	return aa.settings.Update(func(s *Settings) error {
+		s.Admin = AdminConfig{}
+		return nil
+	})
+}
+
+// ResetPassword sets a new password with the recovery code and returns the
+// next recovery code; each code works once
+func (aa *AdminAuth) ResetPassword(recoveryCode, password string) (string, error) {
+	if err := aa.unavailable(); err != nil {
+		return "", fmt.Errorf("settings couldn't be loaded, fix settings.json first: %w", err)
+	}
+	if !aa.Enabled() {
+		return "", fmt.Errorf("no admin password is set")
+	}
+	if err := aa.limiter.check(); err != nil {
+		return "", err
+	}
+	code := normalizeRecoveryCode(recoveryCode)
+	if !verifyArgon2(aa.settings.Get().Admin.RecoveryHash, code) {
+		fmt.Println("🔑 Wrong recovery code")
+		return "", aa.limiter.fail("wrong recovery code")
+	}
+	aa.limiter.reset()
+	return aa.storePassword(password)
+}
+
+// storePassword hashes and saves a password with a fresh recovery code
+func (aa *AdminAuth) storePassword(password string) (string, error) {
+	if len(password) < adminMinPasswordLength {
+		return "", fmt.Errorf("the password must be at least %d characters", adminMinPasswordLength)
+	}
+	code, err := newRecoveryCode()
+	if err != nil {
+		return "", err
+	}
+	passwordHash, err := hashArgon2(password)
+	if err != nil {
+		return "", err
+	}
+	recoveryHash, err := hashArgon2(normalizeRecoveryCode(code))
+	if err != nil {
+		return "", err
+	}
+
+	err = aa.settings.Update(func(s *Settings) error {
+		s.Admin = AdminConfig{PasswordHash: passwordHash, RecoveryHash: recoveryHash}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	// Tokens issued for the old password are no longer valid
+	aa.mu.Lock()
+	aa.tokens = map[string]time.Time{}
+	aa.mu.Unlock()
+	fmt.Println("🔑 Admin password set")
+	return code, nil
+}
+
+func (aa *AdminAuth) issueToken() (AuthToken, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return AuthToken{}, fmt.Errorf("failed to generate token: %w", err)
+	}
+	token := AuthToken{
+		Token:     hex.EncodeToString(b),
+		ExpiresAt: aa.clock.Now().Add(adminTokenTTL),
+	}
+	aa.mu.Lock()
+	aa.tokens[token.Token] = token.ExpiresAt
+	aa.mu.Unlock()
+	return token, nil
+}
+
+// authLimiter backs off after repeated wrong passwords or codes. Failures are
+// counted in the state store so restarting sybr doesn't reset the back-off
+type authLimiter struct {
+	clock Clock
+	store *StateStore
+}
+
+// blockedUntil returns when the running back-off ends, zero if there is none
+func (l authLimiter) blockedUntil() time.Time {
+	if l.store == nil {
+		return time.Time{}
+	}
+	if until := l.store.Get().AuthBlockedUntil; until.After(l.clock.Now()) {
+		return until
+	}
+	return time.Time{}
+}
+
+// check refuses attempts while the back-off from earlier failures runs
+func (l authLimiter) check() error {
+	if until := l.blockedUntil(); !until.IsZero() {
+		wait := until.Sub(l.clock.Now()).Round(time.Second)
+		return fmt.Errorf("too many wrong attempts, try again in %s", wait)
+	}
+	return nil
+}
+
+// fail counts a failed attempt and starts the back-off once the free attempts
+// are used up. It returns the error to show to the user
+func (l authLimiter) fail(message string) error {
+	if l.store == nil {
+		return errors.New(message)
+	}
+	var blockedUntil time.Time
+	err := l.store.Update(func(state *EnforcementState) error {
+		state.AuthFailures++
+		if over := state.AuthFailures - adminFreeAttempts; over > 0 {
+			backoff := adminBaseBackoff
+			for i := 1; i < over && backoff < adminMaxBackoff; i++ {
+				backoff *= 2
+			}
+			if backoff > adminMaxBackoff {
+				backoff = adminMaxBackoff
+			}
+			state.AuthBlockedUntil = l.clock.Now().Add(backoff)
+			blockedUntil = state.AuthBlockedUntil
+		}
+		return nil
+	})
+	if err != nil {
+		fmt.Printf("⚠️  Failed to record failed attempt: %v\n", err)
+	}
+	if !blockedUntil.IsZero() {
+		return fmt.Errorf("%s, try again in %s", message, blockedUntil.Sub(l.clock.Now()).Round(time.Second))
+	}
+	return errors.New(message)
+}
+
+// reset clears the failed attempts after a success
+func (l authLimiter) reset() {
+	if l.store == nil {
+		return
+	}
+	if state := l.store.Get(); state.AuthFailures == 0 && state.AuthBlockedUntil.IsZero() {
+		return
+	}
+	if err := l.store.Update(func(state *EnforcementState) error {
+		state.AuthFailures = 0
+		state.AuthBlockedUntil = time.Time{}
+		return nil
+	}); err != nil {
+		fmt.Printf("⚠️  Failed to reset failed attempts: %v\n", err)
+	}
+}
+
+// hashArgon2 hashes a secret with a random salt into a PHC string
+func hashArgon2(secret string) (string, error) {
+	salt := make([]byte, argonSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(secret), salt, argonTime, argonMemory, argonThreads, argonKeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, argonMemory, argonTime, argonThreads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// verifyArgon2 checks a secret against a PHC string from hashArgon2, using the
+// parameters stored in the string so they can be raised later
+func verifyArgon2(encoded, secret string) bool {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return false
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return false
+	}
+	var memory, iterations uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+		return false
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false
+	}
+	want, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(want) == 0 {
+		return false
+	}
+	got := argon2.IDKey([]byte(secret), salt, iterations, memory, threads, uint32(len(want)))
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// recoveryAlphabet avoids characters that are easy to confuse (0/O, 1/I/L)
+const recoveryAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// newRecoveryCode returns a code like ABCD-EFGH-JKMN-PQRS
+func newRecoveryCode() (string, error) {
+	var code strings.Builder
+	max := big.NewInt(int64(len(recoveryAlphabet)))
+	for i := 0; i < 16; i++ {
+		if i > 0 && i%4 == 0 {
+			code.WriteByte('-')
+		}
+		index, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		code.WriteByte(recoveryAlphabet[index.Int64()])
+	}
+	return code.String(), nil
+}
+
+// normalizeRecoveryCode makes codes comparable regardless of dashes and case
+func normalizeRecoveryCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, code)
+}
@@ -0,0 +1,184 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestAdminAuth returns an AdminAuth backed by settings and state files in dir
+func newTestAdminAuth(t *testing.T, dir string, clock Clock) *AdminAuth {
+	t.Helper()
+	sm, err := newSettingsManager(filepath.Join(dir, "settings.json"))
+	if err != nil {
+		t.Fatalf("newSettingsManager failed: %v", err)
+	}
+	store, err := newStateStore(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatalf("newStateStore failed: %v", err)
+	}
+	return NewAdminAuth(clock, sm, store)
+}
+
+// TestArgon2RoundTrip tests that hashes verify only the secret they were made from
+func TestArgon2RoundTrip(t *testing.T) {
+	hash, err := hashArgon2("correct horse")
+	if err != nil {
+		t.Fatalf("hashArgon2 failed: %v", err)
+	}
+	if !strings.HasPrefix(hash, "$argon2id$") {
+		t.Errorf("hash = %q, want a PHC argon2id string", hash)
+	}
+	if !verifyArgon2(hash, "correct horse") {
+		t.Error("verifyArgon2 rejected the right secret")
+	}
+	if verifyArgon2(hash, "battery staple") {
+		t.Error("verifyArgon2 accepted a wrong secret")
+	}
+	if verifyArgon2("not a hash", "correct horse") {
+		t.Error("verifyArgon2 accepted a malformed hash")
+	}
+}
+
+// TestAdminAuthorize tests that gated actions need a valid, unexpired token
+// once a password is set
+func TestAdminAuthorize(t *testing.T) {
+	clock := newFakeClock()
+	admin := newTestAdminAuth(t, t.TempDir(), clock)
+
+	if err := admin.Authorize("", "adding apps"); err != nil {
+		t.Fatalf("Authorize without a password = %v, want nil", err)
+	}
+	if _, err := admin.SetPassword("", "hunter22"); err != nil {
+		t.Fatalf("SetPassword failed: %v", err)
+	}
+	if err := admin.Authorize("", "adding apps"); !errors.Is(err, errAdminAuthRequired) {
+		t.Fatalf("Authorize without a token = %v, want errAdminAuthRequired", err)
+	}
+	if _, err := admin.SetPassword("", "other-password"); !errors.Is(err, errAdminAuthRequired) {
+		t.Fatalf("changing the password without a token = %v, want errAdminAuthRequired", err)
+	}
+
+	token, err := admin.Authenticate("hunter22")
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if err := admin.Authorize(token.Token, "adding apps"); err != nil {
+		t.Fatalf("Authorize with a token = %v, want nil", err)
+	}
+	if err := admin.Authorize("made-up", "adding apps"); !errors.Is(err, errAdminAuthRequired) {
+		t.Fatalf("Authorize with an unknown token = %v, want errAdminAuthRequired", err)
+	}
+
+	clock.Advance(adminTokenTTL + time.Second)
+	if err := admin.Authorize(token.Token, "adding apps"); !errors.Is(err, errAdminAuthRequired) {
+		t.Fatalf("Authorize with an expired token = %v, want errAdminAuthRequired", err)
+	}
+}
+
+// TestAdminBackoff tests that wrong passwords back off after the free attempts
+// and that the back-off survives a restart
+func TestAdminBackoff(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock()
+	admin := newTestAdminAuth(t, dir, clock)
+	if _, err := admin.SetPassword("", "hunter22"); err != nil {
+		t.Fatalf("SetPassword failed: %v", err)
+	}
+
+	for i := 0; i < adminFreeAttempts; i++ {
+		if _, err := admin.Authenticate("wrong"); err == nil {
+			t.Fatalf("attempt %d with a wrong password succeeded", i+1)
+		}
+	}
+	if status := admin.Status(); !status.BlockedUntil.IsZero() {
+		t.Fatalf("blocked after %d attempts, want the first ones free", adminFreeAttempts)
+	}
+	admin.Authenticate("wrong")
+	if status := admin.Status(); !status.BlockedUntil.Equal(clock.Now().Add(adminBaseBackoff)) {
+		t.Fatalf("BlockedUntil = %v, want now + %s", status.BlockedUntil, adminBaseBackoff)
+	}
+
+	// Restarting keeps the back-off, even for the right password
+	admin = newTestAdminAuth(t, dir, clock)
+	if _, err := admin.Authenticate("hunter22"); err == nil {
+		t.Fatal("Authenticate during the back-off succeeded")
+	}
+
+	clock.Advance(adminBaseBackoff)
+	admin.Authenticate("wrong")
+	if status := admin.Status(); !status.BlockedUntil.Equal(clock.Now().Add(2 * adminBaseBackoff)) {
+		t.Fatalf("BlockedUntil = %v, want the back-off doubled", status.BlockedUntil)
+	}
+
+	clock.Advance(2 * adminBaseBackoff)
+	if _, err := admin.Authenticate("hunter22"); err != nil {
+		t.Fatalf("Authenticate after the back-off failed: %v", err)
+	}
+	admin.Authenticate("wrong")
+	if status := admin.Status(); !status.BlockedUntil.IsZero() {
+		t.Fatal("a success didn't reset the failed attempts")
+	}
+}
+
+// TestAdminRecovery tests that the recovery code resets the password once
+func TestAdminRecovery(t *testing.T) {
+	admin := newTestAdminAuth(t, t.TempDir(), newFakeClock())
+	code, err := admin.SetPassword("", "hunter22")
+	if err != nil {
+		t.Fatalf("SetPassword failed: %v", err)
+	}
+
+	next, err := admin.ResetPassword(strings.ToLower(code), "new-password")
+	if err != nil {
+		t.Fatalf("ResetPassword failed: %v", err)
+	}
+	if next == code {
+		t.Fatal("ResetPassword returned the same recovery code")
+	}
+	if _, err := admin.ResetPassword(code, "another-one"); err == nil {
+		t.Fatal("the old recovery code worked twice")
+	}
+	if _, err := admin.Authenticate("hunter22"); err == nil {
+		t.Fatal("the old password still works")
+	}
+	if _, err := admin.Authenticate("new-password"); err != nil {
+		t.Fatalf("Authenticate with the new password failed: %v", err)
+	}
+}
+
+// TestAuthRefusesWithBrokenSettings tests that a settings file that can't be
+// parsed turns the admin and partner protection into refusals, not off
+func TestAuthRefusesWithBrokenSettings(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	if err := os.WriteFile(path, []byte(`{"admin": {"passwordHash": `), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	sm, loadErr := newSettingsManager(path)
+	if loadErr == nil {
+		t.Fatal("newSettingsManager accepted a truncated file")
+	}
+	admin := NewAdminAuth(newFakeClock(), sm, nil)
+	partner := NewPartnerAuth(newFakeClock(), sm, nil)
+	admin.refuseAll(loadErr)
+	partner.refuseAll(loadErr)
+
+	if err := admin.Authorize("", "removing apps"); !errors.Is(err, errAdminAuthRequired) {
+		t.Errorf("Authorize = %v, want errAdminAuthRequired", err)
+	}
+	if _, err := admin.Authenticate("anything at all"); err == nil {
+		t.Error("Authenticate succeeded with broken settings")
+	}
+	if _, err := admin.SetPassword("", "a new password"); err == nil {
+		t.Error("SetPassword succeeded with broken settings")
+	}
+	if err := partner.Require("123456", "lifting the lock"); !errors.Is(err, errPartnerCodeRequired) {
+		t.Errorf("Require = %v, want errPartnerCodeRequired", err)
+	}
+	if !admin.Status().Enabled || !partner.Status().Enabled {
+		t.Error("status should report the protection as on")
+	}
+}
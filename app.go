@@ -3,6 +3,10 @@ package main
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
 	"time"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -10,8 +14,20 @@ import (
 
 // App struct
 type App struct {
-	ctx     context.Context
-	watcher *WindowWatcher
+	ctx            context.Context
+	watcher        *WindowWatcher
+	bus            *EventBus
+	bridge         *WailsBridge
+	session        *SessionEngine
+	lock           *CommitmentLock
+	pending        *PendingQueue
+	emergency      *EmergencyUnlocker
+	admin          *AdminAuth
+	partner        *PartnerAuth
+	tabs           *BrowserTabs // Active tabs reported by the native messaging host
+	tamperLog      string       // Kill attempts recorded by the watchdog
+	distractionLog string       // Blocked websites refused by the HTTP proxy
+	quitting       atomic.Bool  // Quit was allowed, the watchdog may let sybr go
 }
 
 // NewApp creates a new App application struct
@@ -19,6 +35,7 @@ func NewApp() *App {
 	return &App{
 		ctx:     nil,
 		watcher: nil, // Will be set in main.go
+		bus:     nil, // Will be set in main.go
 	}
 }
 
@@ -27,10 +44,13 @@ func NewApp() *App {
 func (a *App) OnStartup(ctx context.Context) {
 	a.ctx = ctx
 
-	// Set the Wails context on the watcher - this is critical for events to work
+	// Forward bus events to the frontend - this is critical for events to work
+	if a.bus != nil {
+		a.bridge = StartWailsBridge(ctx, a.bus)
+		fmt.Println("✓ Wails event bridge started in OnStartup")
+	}
+
 	if a.watcher != nil {
-		a.watcher.SetContext(ctx)
-		fmt.Println("✓ Watcher context set in OnStartup")
 
 		// Start monitoring in a goroutine to avoid blocking
 		go func() {
@@ -71,34 +91,41 @@ func (a *App) GetCurrentWindow() (*WindowInfo, error) {
 	return info, nil
 }
 
-// OnWindowChanged is called when the active window changes
-// This is used by the frontend to listen for window changes
+// OnWindowChanged calls fn for every change of the active window until the
+// app shuts down. The frontend listens to the 'window-changed' event instead
 func (a *App) OnWindowChanged(fn func(*WindowInfo)) {
 	if a.watcher == nil {
 		return
 	}
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	events := a.watcher.Subscribe(ctx)
 	go func() {
-		for {
-			info, err := a.watcher.GetActiveWindow()
-			if err != nil {
-				continue
-			}
-			fn(info)
+		for event := range events {
+			info := event.Window
+			fn(&info)
 		}
 	}()
-	// This will be handled by the watcher emitting events
-	// The frontend will use runtime.EventsOn to listen
 }
 
 // StopMonitoring stops the window monitoring
-func (a *App) StopMonitoring() {
+func (a *App) StopMonitoring() error {
+	if err := a.lock.Check("stopping monitoring"); err != nil {
+		return err
+	}
 	if a.watcher != nil {
 		a.watcher.StopMonitoring()
 	}
+	return nil
 }
 
 // EnableAutoStart enables auto-start on Windows boot
-func (a *App) EnableAutoStart() error {
+func (a *App) EnableAutoStart(token string) error {
+	if err := a.admin.Authorize(token, "enabling auto-start"); err != nil {
+		return err
+	}
 	exePath, err := getExecutablePath()
 	if err != nil {
 		return err
@@ -107,7 +134,13 @@ func (a *App) EnableAutoStart() error {
 }
 
 // DisableAutoStart disables auto-start on Windows boot
-func (a *App) DisableAutoStart() error {
+func (a *App) DisableAutoStart(token string) error {
+	if err := a.admin.Authorize(token, "disabling auto-start"); err != nil {
+		return err
+	}
+	if err := a.lock.Check("disabling auto-start"); err != nil {
+		return err
+	}
 	return DisableAutoStart()
 }
 
@@ -130,6 +163,16 @@ func (a *App) HideWindow() {
 	}
 }
 
+// beforeClose hides the main window instead of closing it, so closing the
+// window can't end sybr without the checks of Quit
+func (a *App) beforeClose(ctx context.Context) (prevent bool) {
+	if a.quitting.Load() {
+		return false
+	}
+	a.HideWindow()
+	return true
+}
+
 // ShowSystemWarning displays a native Windows MessageBox that stays on top of all windows
 // This is an "annoying" modal that appears above everything else
 func (a *App) ShowSystemWarning(title, message string) error {
@@ -144,7 +187,7 @@ func (a *App) ShowSystemWarning(title, message string) error {
 }
 
 // AddToBlocklist adds an app to the blocklist
-func (a *App) AddToBlocklist(executableName string, displayName string) error {
+func (a *App) AddToBlocklist(executableName string, displayName string, token string) error {
 	// Add panic recovery
 	defer func() {
 		if r := recover(); r != nil {
@@ -155,6 +198,10 @@ func (a *App) AddToBlocklist(executableName string, displayName string) error {
 
 	fmt.Printf("📝 AddToBlocklist called: executableName=%s, displayName=%s\n", executableName, displayName)
 
+	if err := a.admin.Authorize(token, "adding to the blocklist"); err != nil {
+		return err
+	}
+
 	bm, err := GetBlocklistManager()
 	if err != nil {
 		fmt.Printf("❌ Failed to get blocklist manager: %v\n", err)
@@ -172,13 +219,147 @@ func (a *App) AddToBlocklist(executableName string, displayName string) error {
 	return nil
 }
 
-// RemoveFromBlocklist removes an app from the blocklist
-func (a *App) RemoveFromBlocklist(executableName string) error {
+// AddDomainToBlocklist blocks a website in browsers with the sybr extension
+func (a *App) AddDomainToBlocklist(domain string, displayName string, token string) error {
+	if err := a.admin.Authorize(token, "adding to the blocklist"); err != nil {
+		return err
+	}
+	bm, err := GetBlocklistManager()
+	if err != nil {
+		return fmt.Errorf("failed to get blocklist manager: %w", err)
+	}
+	return bm.AddDomain(domain, displayName)
+}
+
+// GetBrowserTabs returns the active tab of every browser with the extension
+func (a *App) GetBrowserTabs() []BrowserTab {
+	if a.tabs == nil {
+		return []BrowserTab{}
+	}
+	return a.tabs.Tabs()
+}
+
+// GetRunningApps lists the running apps for the blocklist picker, one process
+// per executable
+func (a *App) GetRunningApps() ([]ProcessInfo, error) {
+	processes, err := listProcesses()
+	if err != nil {
+		return nil, err
+	}
+	self := os.Getpid()
+	seen := map[string]bool{}
+	apps := []ProcessInfo{}
+	for _, p := range processes {
+		// Sandboxed and interpreted apps share bwrap, AppRun or python, tell
+		// them apart by app ID
+		key := p.Name
+		if p.AppID != "" {
+			key = p.AppID
+		}
+		if p.PID == self || key == "" || seen[key] {
+			continue
+		}
+		if p.Path == "" {
+			p.Path = processPath(p.PID)
+		}
+		// Kernel threads and processes we can't inspect can't be pinned
+		if p.Path == "" {
+			continue
+		}
+		seen[key] = true
+		apps = append(apps, p)
+	}
+	sort.Slice(apps, func(i, j int) bool { return apps[i].Name < apps[j].Name })
+	return apps, nil
+}
+
+// AddRunningApp blocks the executable of a running process and pins the hash
+// of its binary, so renamed copies of it are blocked too. Sandboxed and
+// interpreted apps are blocked by app ID instead, their binary is a shared
+// wrapper or runtime
+func (a *App) AddRunningApp(pid int, displayName string, token string) error {
+	if err := a.admin.Authorize(token, "adding to the blocklist"); err != nil {
+		return err
+	}
 	bm, err := GetBlocklistManager()
 	if err != nil {
 		return fmt.Errorf("failed to get blocklist manager: %w", err)
 	}
-	return bm.RemoveApp(executableName)
+	path := processPath(pid)
+	if path == "" {
+		return fmt.Errorf("process %d not found or not accessible", pid)
+	}
+	if appID := resolveAppID(pid, path); appID != "" {
+		if bm.GetBlockedAppAnyProfile(appID) != nil {
+			return nil
+		}
+		return bm.AddApp(appID, displayName)
+	}
+	sum, err := hashExecutable(path)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+
+	exe := normalizeExecutableName(filepath.Base(path))
+	if bm.GetBlockedAppAnyProfile(exe) == nil {
+		if err := bm.AddApp(exe, displayName); err != nil {
+			return err
+		}
+	}
+	return bm.PinHash(exe, sum)
+}
+
+// PinBlocklistHash pins a binary to an app's entry; target is the path of
+// the binary or its SHA-256
+func (a *App) PinBlocklistHash(executableName, target, token string) error {
+	if err := a.admin.Authorize(token, "changing the blocklist"); err != nil {
+		return err
+	}
+	bm, err := GetBlocklistManager()
+	if err != nil {
+		return fmt.Errorf("failed to get blocklist manager: %w", err)
+	}
+	sum, err := normalizeHash(target)
+	if err != nil {
+		if sum, err = hashExecutable(target); err != nil {
+			return fmt.Errorf("'%s' is neither a SHA-256 hash nor a readable file: %w", target, err)
+		}
+	}
+	return bm.PinHash(executableName, sum)
+}
+
+// RemoveFromBlocklist removes an app from the blocklist, after the unlock
+// delay if one is configured. partnerCode is needed with an accountability partner
+func (a *App) RemoveFromBlocklist(executableName string, token string, partnerCode string) error {
+	_, err := a.requestRemoval(executableName, token, partnerCode)
+	return err
+}
+
+// requestRemoval removes an app or queues its removal; it returns the queued
+// change, nil if the app was removed right away
+func (a *App) requestRemoval(executableName string, token string, partnerCode string) (*PendingChange, error) {
+	if err := a.admin.Authorize(token, "removing from the blocklist"); err != nil {
+		return nil, err
+	}
+	if err := a.partner.Require(partnerCode, "removing from the blocklist"); err != nil {
+		return nil, err
+	}
+	bm, err := GetBlocklistManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocklist manager: %w", err)
+	}
+	if a.pending == nil {
+		return nil, bm.RemoveApp(executableName)
+	}
+	exe := normalizeExecutableName(executableName)
+	if bm.GetBlockedAppAnyProfile(exe) == nil {
+		return nil, fmt.Errorf("app '%s' not found in blocklist", exe)
+	}
+	// Refuse now rather than queueing a removal that would go through once the lock ends
+	if err := a.lock.Check("removing apps from the blocklist"); err != nil {
+		return nil, err
+	}
+	return a.pending.Request(PendingChange{Kind: PendingRemoveApp, ExecutableName: exe})
 }
 
 // GetBlocklist returns the list of blocked apps
@@ -193,3 +374,464 @@ func (a *App) GetBlocklist() ([]BlockedApp, error) {
 	fmt.Printf("✅ GetBlocklist returning %d apps\n", len(apps))
 	return apps, nil
 }
+
+// StartFocusSession starts a focus session; minutes <= 0 uses the configured focus length
+func (a *App) StartFocusSession(minutes int) error {
+	if a.session == nil {
+		return fmt.Errorf("session engine not available")
+	}
+	return a.session.Start(time.Duration(minutes) * time.Minute)
+}
+
+// StopFocusSession ends the running focus session
+func (a *App) StopFocusSession() error {
+	if a.session == nil {
+		return fmt.Errorf("session engine not available")
+	}
+	if err := a.lock.Check("stopping the focus session"); err != nil {
+		return err
+	}
+	return a.session.Stop()
+}
+
+// SkipSessionPhase ends the current focus or break early
+func (a *App) SkipSessionPhase() error {
+	if a.session == nil {
+		return fmt.Errorf("session engine not available")
+	}
+	// Skipping a break only brings the next focus phase forward
+	if a.session.Status().State == SessionFocusing {
+		if err := a.lock.Check("skipping the focus phase"); err != nil {
+			return err
+		}
+	}
+	return a.session.Skip()
+}
+
+// GetSessionStatus returns the current session state and remaining time
+func (a *App) GetSessionStatus() (SessionStatus, error) {
+	if a.session == nil {
+		return SessionStatus{State: SessionIdle}, nil
+	}
+	return a.session.Status(), nil
+}
+
+// GetSessionConfig returns the focus session configuration
+func (a *App) GetSessionConfig() (SessionConfig, error) {
+	if a.session == nil {
+		return DefaultSessionConfig(), nil
+	}
+	return a.session.Config(), nil
+}
+
+// SetSessionConfig validates, applies and saves the focus session configuration
+func (a *App) SetSessionConfig(config SessionConfig, token string) error {
+	if a.session == nil {
+		return fmt.Errorf("session engine not available")
+	}
+	if err := a.admin.Authorize(token, "changing the session settings"); err != nil {
+		return err
+	}
+	if err := a.lock.Check("changing the session settings"); err != nil {
+		return err
+	}
+	if err := a.session.SetConfig(config); err != nil {
+		return err
+	}
+	sm, err := GetSettingsManager()
+	if err != nil {
+		return fmt.Errorf("failed to get settings manager: %w", err)
+	}
+	return sm.Update(func(s *Settings) error {
+		s.Session = config
+		return nil
+	})
+}
+
+// SetBlocklistProfiles sets the profiles an app is blocked in (none = always blocked)
+// Narrowing them needs the partner's code and waits for the unlock delay like a removal
+func (a *App) SetBlocklistProfiles(executableName string, profiles []string, token string, partnerCode string) error {
+	if err := a.admin.Authorize(token, "changing blocklist profiles"); err != nil {
+		return err
+	}
+	bm, err := GetBlocklistManager()
+	if err != nil {
+		return fmt.Errorf("failed to get blocklist manager: %w", err)
+	}
+	exe := normalizeExecutableName(executableName)
+	profiles = normalizeProfiles(profiles)
+	app := bm.GetBlockedAppAnyProfile(exe)
+	if app == nil || !profilesNarrowed(app.Profiles, profiles) {
+		return bm.SetAppProfiles(exe, profiles)
+	}
+	if err := a.partner.Require(partnerCode, "narrowing the profiles of a blocked app"); err != nil {
+		return err
+	}
+	if a.pending == nil {
+		return bm.SetAppProfiles(exe, profiles)
+	}
+	if err := a.lock.Check("narrowing the profiles of a blocked app"); err != nil {
+		return err
+	}
+	_, err = a.pending.Request(PendingChange{Kind: PendingNarrowProfiles, ExecutableName: exe, Profiles: profiles})
+	return err
+}
+
+// SetBlocklistScope sets whether an app's entry also blocks the processes it
+// starts ("process", "tree" or "launched"). Narrowing it needs the partner's
+// code and waits for the unlock delay
+func (a *App) SetBlocklistScope(executableName, scope, token, partnerCode string) error {
+	_, err := a.requestScope(executableName, scope, token, partnerCode)
+	return err
+}
+
+// requestScope changes an app's scope or queues narrowing it; it returns the
+// queued change, nil if the scope was changed right away
+func (a *App) requestScope(executableName, scope, token, partnerCode string) (*PendingChange, error) {
+	if err := a.admin.Authorize(token, "changing the scope of a blocked app"); err != nil {
+		return nil, err
+	}
+	bm, err := GetBlocklistManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocklist manager: %w", err)
+	}
+	exe := normalizeExecutableName(executableName)
+	if scope, err = normalizeScope(scope); err != nil {
+		return nil, err
+	}
+	app := bm.GetBlockedAppAnyProfile(exe)
+	if app == nil || !scopeNarrowed(app.Scope, scope) {
+		return nil, bm.SetAppScope(exe, scope)
+	}
+	if err := a.partner.Require(partnerCode, "narrowing the scope of a blocked app"); err != nil {
+		return nil, err
+	}
+	if a.pending == nil {
+		return nil, bm.SetAppScope(exe, scope)
+	}
+	if err := a.lock.Check("narrowing the scope of a blocked app"); err != nil {
+		return nil, err
+	}
+	return a.pending.Request(PendingChange{Kind: PendingNarrowScope, ExecutableName: exe, Scope: scope})
+}
+
+// SetBlocklistPlatforms limits the operating systems an app is blocked on
+// (none = every OS), so one blocklist can be shared between machines.
+// Limiting them needs the partner's code and waits for the unlock delay like a removal
+func (a *App) SetBlocklistPlatforms(executableName string, platforms []string, token string, partnerCode string) error {
+	_, err := a.requestPlatforms(executableName, platforms, token, partnerCode)
+	return err
+}
+
+// requestPlatforms changes an app's platforms or queues limiting them; it
+// returns the queued change, nil if the platforms were changed right away
+func (a *App) requestPlatforms(executableName string, platforms []string, token string, partnerCode string) (*PendingChange, error) {
+	if err := a.admin.Authorize(token, "changing the platforms of a blocked app"); err != nil {
+		return nil, err
+	}
+	bm, err := GetBlocklistManager()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocklist manager: %w", err)
+	}
+	exe := normalizeExecutableName(executableName)
+	if platforms, err = normalizePlatforms(platforms); err != nil {
+		return nil, err
+	}
+	app := bm.GetBlockedAppAnyProfile(exe)
+	if app == nil || !profilesNarrowed(app.Platforms, platforms) {
+		return nil, bm.SetAppPlatforms(exe, platforms)
+	}
+	if err := a.partner.Require(partnerCode, "limiting the platforms of a blocked app"); err != nil {
+		return nil, err
+	}
+	if a.pending == nil {
+		return nil, bm.SetAppPlatforms(exe, platforms)
+	}
+	if err := a.lock.Check("limiting the platforms of a blocked app"); err != nil {
+		return nil, err
+	}
+	return a.pending.Request(PendingChange{Kind: PendingNarrowPlatform, ExecutableName: exe, Platforms: platforms})
+}
+
+// GetPendingChanges returns the removals and downgrades waiting for the unlock delay
+func (a *App) GetPendingChanges() ([]PendingChange, error) {
+	if a.pending == nil {
+		return []PendingChange{}, nil
+	}
+	return a.pending.List(), nil
+}
+
+// CancelPendingChange drops a queued removal or downgrade
+func (a *App) CancelPendingChange(id string) error {
+	if a.pending == nil {
+		return fmt.Errorf("no pending change '%s'", id)
+	}
+	return a.pending.Cancel(id)
+}
+
+// SnoozeApp suppresses warnings for a blocked app for the given number of minutes
+// 0 minutes ends the snooze. Snoozing needs the partner's code
+func (a *App) SnoozeApp(executableName string, minutes int, token string, partnerCode string) error {
+	if minutes < 0 {
+		return fmt.Errorf("snooze length can't be negative")
+	}
+	if minutes > 0 {
+		if err := a.admin.Authorize(token, "snoozing warnings"); err != nil {
+			return err
+		}
+		if err := a.partner.Require(partnerCode, "snoozing warnings"); err != nil {
+			return err
+		}
+		if err := a.lock.Check("snoozing warnings"); err != nil {
+			return err
+		}
+	}
+	store, err := GetStateStore()
+	if err != nil {
+		return fmt.Errorf("failed to get state store: %w", err)
+	}
+	exe := normalizeExecutableName(executableName)
+	return store.Snooze(exe, time.Now().Add(time.Duration(minutes)*time.Minute))
+}
+
+// GetSnoozes returns the snoozed apps and when their snooze ends
+func (a *App) GetSnoozes() (map[string]time.Time, error) {
+	store, err := GetStateStore()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get state store: %w", err)
+	}
+	now := time.Now()
+	snoozes := map[string]time.Time{}
+	for exe, until := range store.Get().Snoozes {
+		if until.After(now) {
+			snoozes[exe] = until
+		}
+	}
+	return snoozes, nil
+}
+
+// LockFor turns on the commitment lock for the given number of minutes
+// A running lock can be extended but not shortened
+func (a *App) LockFor(minutes int) error {
+	if a.lock == nil {
+		return fmt.Errorf("commitment lock not available")
+	}
+	return a.lock.LockFor(time.Duration(minutes) * time.Minute)
+}
+
+// GetLockStatus returns whether the commitment lock is active and until when
+func (a *App) GetLockStatus() (LockStatus, error) {
+	return a.lock.Status(), nil
+}
+
+// StartEmergencyUnlock returns a passage that has to be typed back exactly to
+// lift the commitment lock
+func (a *App) StartEmergencyUnlock() (EmergencyChallenge, error) {
+	if a.emergency == nil {
+		return EmergencyChallenge{}, fmt.Errorf("emergency unlock not available")
+	}
+	if !a.lock.Status().Locked {
+		return EmergencyChallenge{}, fmt.Errorf("the commitment lock is not active")
+	}
+	if a.partner.Enabled() {
+		// Otherwise typing the passage would go around the partner
+		return EmergencyChallenge{}, fmt.Errorf("the lock can only be lifted with a code from your accountability partner")
+	}
+	return a.emergency.Start()
+}
+
+// SubmitEmergencyUnlock checks the typed passage and lifts the lock if it matches
+func (a *App) SubmitEmergencyUnlock(id string, typed string) error {
+	if a.emergency == nil {
+		return fmt.Errorf("emergency unlock not available")
+	}
+	if a.partner.Enabled() {
+		// A partner may have been set up since the passage was shown
+		return fmt.Errorf("the lock can only be lifted with a code from your accountability partner")
+	}
+	took, length, err := a.emergency.Verify(id, typed)
+	if err != nil {
+		return err
+	}
+
+	status, err := a.liftLock()
+	if err != nil {
+		return err
+	}
+
+	entry := EmergencyUnlockEntry{
+		Time:          time.Now(),
+		PassageLength: length,
+		TookSeconds:   int(took / time.Second),
+		LockedUntil:   status.Until,
+		ForSession:    status.ForSession,
+	}
+	fmt.Printf("🆘 Emergency unlock completed after %s\n", took.Round(time.Second))
+	if err := a.emergency.Log(entry); err != nil {
+		fmt.Printf("⚠️  Failed to log emergency unlock: %v\n", err)
+	}
+	if a.bus != nil {
+		a.bus.Publish(EventEmergencyUnlock, entry)
+	}
+	return nil
+}
+
+// GetEmergencyUnlocks returns the log of emergency unlocks
+func (a *App) GetEmergencyUnlocks() ([]EmergencyUnlockEntry, error) {
+	if a.emergency == nil {
+		return []EmergencyUnlockEntry{}, nil
+	}
+	return a.emergency.Entries()
+}
+
+// Quit exits sybr; it is refused during a commitment lock and needs the admin
+// password when one is set
+func (a *App) Quit(token string) error {
+	if err := a.admin.Authorize(token, "quitting"); err != nil {
+		return err
+	}
+	if err := a.lock.Check("quitting"); err != nil {
+		return err
+	}
+	a.quitting.Store(true)
+	quitApp(a)
+	return nil
+}
+
+// GetAdminStatus returns whether an admin password is set
+func (a *App) GetAdminStatus() (AdminStatus, error) {
+	return a.admin.Status(), nil
+}
+
+// AuthenticateAdmin checks the admin password and returns a token for the
+// gated bindings that is valid for a few minutes
+func (a *App) AuthenticateAdmin(password string) (AuthToken, error) {
+	if a.admin == nil {
+		return AuthToken{}, fmt.Errorf("admin protection not available")
+	}
+	return a.admin.Authenticate(password)
+}
+
+// SetAdminPassword sets or changes the admin password and returns the recovery
+// code, which is only shown once
+func (a *App) SetAdminPassword(token string, password string) (string, error) {
+	if a.admin == nil {
+		return "", fmt.Errorf("admin protection not available")
+	}
+	return a.admin.SetPassword(token, password)
+}
+
+// ClearAdminPassword removes the admin password
+func (a *App) ClearAdminPassword(token string) error {
+	if a.admin == nil {
+		return fmt.Errorf("admin protection not available")
+	}
+	return a.admin.ClearPassword(token)
+}
+
+// ResetAdminPassword sets a new admin password with the recovery code and
+// returns the next recovery code
+func (a *App) ResetAdminPassword(recoveryCode string, password string) (string, error) {
+	if a.admin == nil {
+		return "", fmt.Errorf("admin protection not available")
+	}
+	return a.admin.ResetPassword(recoveryCode, password)
+}
+
+// liftLock lifts the commitment lock and stops the session it came from, if any
+func (a *App) liftLock() (LockStatus, error) {
+	status := a.lock.Status()
+	if err := a.lock.Lift(); err != nil {
+		return status, fmt.Errorf("failed to lift the lock: %w", err)
+	}
+	if status.ForSession && a.session != nil {
+		if err := a.session.Stop(); err != nil {
+			fmt.Printf("⚠️  Failed to stop the locked session: %v\n", err)
+		}
+	}
+	return status, nil
+}
+
+// LiftLockWithPartnerCode lifts the commitment lock with a current code from
+// the accountability partner's authenticator app
+func (a *App) LiftLockWithPartnerCode(code string) error {
+	if !a.partner.Enabled() {
+		return fmt.Errorf("no accountability partner is set up")
+	}
+	if !a.lock.Status().Locked {
+		return fmt.Errorf("the commitment lock is not active")
+	}
+	if err := a.partner.Require(code, "lifting the lock"); err != nil {
+		return err
+	}
+	_, err := a.liftLock()
+	return err
+}
+
+// GetPartnerStatus returns whether an accountability partner is set up
+func (a *App) GetPartnerStatus() (PartnerStatus, error) {
+	return a.partner.Status(), nil
+}
+
+// SetupPartnerCode generates a TOTP secret for the accountability partner to
+// scan; it is only returned this once. It needs an admin token when a password
+// is set, and replacing a partner needs their code
+func (a *App) SetupPartnerCode(token string, code string) (PartnerSetup, error) {
+	if a.partner == nil {
+		return PartnerSetup{}, fmt.Errorf("partner codes not available")
+	}
+	if err := a.admin.Authorize(token, "setting up an accountability partner"); err != nil {
+		return PartnerSetup{}, err
+	}
+	return a.partner.Setup(code)
+}
+
+// ConfirmPartnerCode finishes the setup with a code from the partner's app
+func (a *App) ConfirmPartnerCode(code string) error {
+	if a.partner == nil {
+		return fmt.Errorf("partner codes not available")
+	}
+	return a.partner.Confirm(code)
+}
+
+// RemovePartnerCode removes the accountability partner with a current code
+func (a *App) RemovePartnerCode(code string) error {
+	if a.partner == nil {
+		return fmt.Errorf("partner codes not available")
+	}
+	return a.partner.Remove(code)
+}
+
+// watchdogStatus is what this process answers to the watchdog's pings
+func (a *App) watchdogStatus() WatchdogPing {
+	ping := WatchdogPing{PID: os.Getpid()}
+	if a.quitting.Load() {
+		// The quit passed the lock and admin checks, let the watchdog go
+		return ping
+	}
+	lock := a.lock.Status()
+	if lock.Locked {
+		ping.Protected, ping.Locked = true, true
+		ping.Reason = "a commitment lock"
+	} else if a.session != nil && a.session.Status().State != SessionIdle {
+		ping.Protected = true
+		ping.Reason = "a focus session"
+	}
+	return ping
+}
+
+// GetTamperEvents returns the kill attempts recorded by the watchdog, oldest first
+func (a *App) GetTamperEvents() ([]TamperEvent, error) {
+	if a.tamperLog == "" {
+		return []TamperEvent{}, nil
+	}
+	return readTamperEvents(a.tamperLog)
+}
+
+// GetDistractionAttempts returns the blocked websites the HTTP proxy refused, oldest first
+func (a *App) GetDistractionAttempts() ([]DistractionAttempt, error) {
+	if a.distractionLog == "" {
+		return []DistractionAttempt{}, nil
+	}
+	return readDistractionAttempts(a.distractionLog)
+}
@@ -0,0 +1,206 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// useTestBlocklist makes GetBlocklistManager return a blocklist in a
+// temporary directory holding discord, for the rest of the test
+func useTestBlocklist(t *testing.T) *BlocklistManager {
+	t.Helper()
+	blocklistOnce.Do(func() {})
+	previous := globalBlocklist
+	bm := &BlocklistManager{filePath: filepath.Join(t.TempDir(), "blocking_list.json")}
+	if err := bm.AddApp("discord", "Discord"); err != nil {
+		t.Fatalf("AddApp failed: %v", err)
+	}
+	globalBlocklist = bm
+	t.Cleanup(func() { globalBlocklist = previous })
+	return bm
+}
+
+// TestAppRefusesQueueingWhileLocked tests that weakening changes are refused
+// during a commitment lock instead of being queued for when it ends
+func TestAppRefusesQueueingWhileLocked(t *testing.T) {
+	bm := useTestBlocklist(t)
+	clock := newFakeClock()
+	store, err := newStateStore(filepath.Join(t.TempDir(), "state.json"))
+	if err != nil {
+		t.Fatalf("newStateStore failed: %v", err)
+	}
+	app := &App{
+		lock:    NewCommitmentLock(clock, store, nil),
+		pending: NewPendingQueue(clock, store, bm, nil, 24*time.Hour),
+	}
+	if err := app.lock.LockFor(time.Hour); err != nil {
+		t.Fatalf("LockFor failed: %v", err)
+	}
+
+	requests := map[string]func() error{
+		"removal": func() error { _, err := app.requestRemoval("discord", "", ""); return err },
+		"profiles": func() error {
+			return app.SetBlocklistProfiles("discord", []string{"focus"}, "", "")
+		},
+		"scope": func() error { _, err := app.requestScope("discord", ScopeLaunched, "", ""); return err },
+		"platforms": func() error {
+			_, err := app.requestPlatforms("discord", []string{"darwin"}, "", "")
+			return err
+		},
+	}
+	for name, request := range requests {
+		if err := request(); !errors.Is(err, errCommitmentLocked) {
+			t.Errorf("%s during the lock = %v, want errCommitmentLocked", name, err)
+		}
+	}
+	if pending := app.pending.List(); len(pending) != 0 {
+		t.Errorf("pending changes = %+v, want none", pending)
+	}
+}
+
+// TestAppNarrowingNeedsPartnerCode tests that every change that narrows or
+// pauses a block is refused without the accountability partner's code
+func TestAppNarrowingNeedsPartnerCode(t *testing.T) {
+	bm := useTestBlocklist(t)
+	clock := newFakeClock()
+	partner := newTestPartnerAuth(t, t.TempDir(), clock)
+	setup, err := partner.Setup("")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := partner.Confirm(codeAt(t, setup.Secret, clock.Now())); err != nil {
+		t.Fatalf("Confirm failed: %v", err)
+	}
+	app := &App{partner: partner}
+
+	requests := map[string]func(code string) error{
+		"removal": func(code string) error { _, err := app.requestRemoval("discord", "", code); return err },
+		"profiles": func(code string) error {
+			return app.SetBlocklistProfiles("discord", []string{"nonexistent"}, "", code)
+		},
+		"scope": func(code string) error { _, err := app.requestScope("discord", ScopeLaunched, "", code); return err },
+		"platforms": func(code string) error {
+			_, err := app.requestPlatforms("discord", []string{"darwin"}, "", code)
+			return err
+		},
+		"snooze": func(code string) error { return app.SnoozeApp("discord", 30, "", code) },
+	}
+	for name, request := range requests {
+		if err := request(""); !errors.Is(err, errPartnerCodeRequired) {
+			t.Errorf("%s without a code = %v, want errPartnerCodeRequired", name, err)
+		}
+		clock.Advance(totpPeriod)
+		if err := request("000000"); err == nil && codeAt(t, setup.Secret, clock.Now()) != "000000" {
+			t.Errorf("%s with a wrong code succeeded", name)
+		}
+	}
+	blocked := bm.GetBlockedAppAnyProfile("discord")
+	if blocked == nil || len(blocked.Profiles) != 0 || blocked.Scope != ScopeProcess || len(blocked.Platforms) != 0 {
+		t.Fatalf("entry after refused changes = %+v, want it unchanged", blocked)
+	}
+
+	// Widening never needs the code, narrowing goes through with a current one
+	if _, err := app.requestScope("discord", ScopeTree, "", ""); err != nil {
+		t.Errorf("widening the scope without a code failed: %v", err)
+	}
+	clock.Advance(time.Hour) // Past the back-off from the wrong codes
+	if _, err := app.requestPlatforms("discord", []string{"linux"}, "", codeAt(t, setup.Secret, clock.Now())); err != nil {
+		t.Errorf("limiting the platforms with a current code failed: %v", err)
+	}
+}
+
+// TestAppPartnerSetupNeedsAdmin tests that with an admin password no client
+// can enroll its own partner without a token
+func TestAppPartnerSetupNeedsAdmin(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock()
+	admin := newTestAdminAuth(t, dir, clock)
+	if _, err := admin.SetPassword("", "correct horse battery"); err != nil {
+		t.Fatalf("SetPassword failed: %v", err)
+	}
+	app := &App{admin: admin, partner: newTestPartnerAuth(t, dir, clock)}
+
+	if _, err := app.SetupPartnerCode("", ""); !errors.Is(err, errAdminAuthRequired) {
+		t.Fatalf("SetupPartnerCode without a token = %v, want errAdminAuthRequired", err)
+	}
+	if app.partner.Status().Pending {
+		t.Fatal("a refused setup was left pending")
+	}
+	token, err := admin.Authenticate("correct horse battery")
+	if err != nil {
+		t.Fatalf("Authenticate failed: %v", err)
+	}
+	if _, err := app.SetupPartnerCode(token.Token, ""); err != nil {
+		t.Errorf("SetupPartnerCode with a token failed: %v", err)
+	}
+}
+
+// TestAppEmergencyUnlockRechecksPartner tests that a passage shown before a
+// partner was set up can't lift the lock afterwards
+func TestAppEmergencyUnlockRechecksPartner(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock()
+	store, err := newStateStore(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatalf("newStateStore failed: %v", err)
+	}
+	app := &App{
+		lock:      NewCommitmentLock(clock, store, nil),
+		emergency: NewEmergencyUnlocker(clock, 5, ""),
+		partner:   newTestPartnerAuth(t, dir, clock),
+	}
+	if err := app.lock.LockFor(time.Hour); err != nil {
+		t.Fatalf("LockFor failed: %v", err)
+	}
+	challenge, err := app.StartEmergencyUnlock()
+	if err != nil {
+		t.Fatalf("StartEmergencyUnlock failed: %v", err)
+	}
+
+	setup, err := app.partner.Setup("")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := app.partner.Confirm(codeAt(t, setup.Secret, clock.Now())); err != nil {
+		t.Fatalf("Confirm failed: %v", err)
+	}
+	clock.Advance(5 * time.Minute)
+	if err := app.SubmitEmergencyUnlock(challenge.ID, challenge.Passage); err == nil {
+		t.Fatal("the typed passage lifted the lock with a partner set up")
+	}
+	if !app.lock.Status().Locked {
+		t.Error("lock was lifted")
+	}
+}
+
+// TestAppWatchdogStatusAfterQuit tests that a session is protected until a
+// quit passed its checks
+func TestAppWatchdogStatusAfterQuit(t *testing.T) {
+	app := &App{session: NewSessionEngine(nil, newFakeClock(), nil, testSessionConfig())}
+	if err := app.session.Start(0); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if !app.watchdogStatus().Protected {
+		t.Fatal("a running session isn't protected")
+	}
+	app.quitting.Store(true)
+	if app.watchdogStatus().Protected {
+		t.Error("still protected after an allowed quit")
+	}
+}
+
+// TestAppCloseHidesWindow tests that closing the window doesn't end sybr
+// until a quit passed its checks
+func TestAppCloseHidesWindow(t *testing.T) {
+	app := &App{}
+	if !app.beforeClose(context.Background()) {
+		t.Fatal("closing the window was allowed without a quit")
+	}
+	app.quitting.Store(true)
+	if app.beforeClose(context.Background()) {
+		t.Error("closing the window was refused after an allowed quit")
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// resolveAppID returns the identity of a sandboxed, bundled or interpreted
+// app: the Flatpak app ID, the Snap or AppImage name, or the script, jar or
+// Electron app an interpreter runs. Their processes otherwise show up as
+// bwrap, AppRun, python or another generic binary. "" for other apps
+func resolveAppID(pid int, path string) string {
+	if pid <= 0 {
+		return ""
+	}
+	// Flatpak puts its metadata at the root of the sandbox
+	if info, err := os.ReadFile(fmt.Sprintf("/proc/%d/root/.flatpak-info", pid)); err == nil {
+		if id := parseFlatpakInfo(string(info)); id != "" {
+			return id
+		}
+	}
+	// Snap runs every app in its own systemd scope
+	if cgroup, err := os.ReadFile(fmt.Sprintf("/proc/%d/cgroup", pid)); err == nil {
+		if name := snapNameFromCgroup(string(cgroup)); name != "" {
+			return name
+		}
+	}
+	// AppImages run from a FUSE mount and export their own path; the
+	// environment is only worth reading for those
+	if strings.Contains(path, "/.mount_") || strings.EqualFold(filepath.Base(path), "AppRun") {
+		if environ, err := os.ReadFile(fmt.Sprintf("/proc/%d/environ", pid)); err == nil {
+			if image := environValue(environ, "APPIMAGE"); image != "" {
+				return appImageID(image)
+			}
+		}
+	}
+	// Interpreters name the app in their command line
+	if interpreterKind(path) != "" {
+		return interpreterAppID(path, processCommandLine(pid))
+	}
+	return ""
+}
+
+// parseFlatpakInfo returns the app ID from the [Application] section of a
+// .flatpak-info file
+func parseFlatpakInfo(info string) string {
+	section := ""
+	scanner := bufio.NewScanner(strings.NewReader(info))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section = line[1 : len(line)-1]
+			continue
+		}
+		if section != "Application" {
+			continue
+		}
+		if key, value, ok := strings.Cut(line, "="); ok && strings.TrimSpace(key) == "name" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
+
+// snapNameFromCgroup returns the snap name from a scope like
+// snap.firefox.firefox-1234.scope in /proc/<pid>/cgroup
+func snapNameFromCgroup(cgroup string) string {
+	for _, line := range strings.Split(cgroup, "\n") {
+		// Lines are hierarchy-ID:controllers:path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		for _, segment := range strings.Split(parts[2], "/") {
+			if !strings.HasPrefix(segment, "snap.") {
+				continue
+			}
+			if fields := strings.Split(segment, "."); len(fields) >= 3 && fields[1] != "" {
+				return fields[1]
+			}
+		}
+	}
+	return ""
+}
+
+// environValue looks up a variable in the NUL separated contents of
+// /proc/<pid>/environ
+func environValue(environ []byte, key string) string {
+	prefix := []byte(key + "=")
+	for _, entry := range bytes.Split(environ, []byte{0}) {
+		if bytes.HasPrefix(entry, prefix) {
+			return string(entry[len(prefix):])
+		}
+	}
+	return ""
+}
+
+// appImageID turns an AppImage path like ~/Apps/Obsidian-1.4.16-x86_64.AppImage
+// into a name that survives updates, "obsidian"
+func appImageID(path string) string {
+	name := filepath.Base(path)
+	if ext := filepath.Ext(name); strings.EqualFold(ext, ".appimage") {
+		name = strings.TrimSuffix(name, ext)
+	}
+	return strings.ToLower(stripVersionSuffix(name))
+}
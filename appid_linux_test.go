@@ -0,0 +1,63 @@
+package main
+
+import "testing"
+
+// TestParseFlatpakInfo tests reading the app ID from the [Application] section
+func TestParseFlatpakInfo(t *testing.T) {
+	info := `[Runtime]
+name=org.freedesktop.Platform
+
+[Application]
+name=com.discordapp.Discord
+runtime=runtime/org.freedesktop.Platform/x86_64/23.08
+`
+	if got := parseFlatpakInfo(info); got != "com.discordapp.Discord" {
+		t.Errorf("parseFlatpakInfo = %q, want com.discordapp.Discord", got)
+	}
+	if got := parseFlatpakInfo("[Runtime]\nname=org.freedesktop.Platform\n"); got != "" {
+		t.Errorf("parseFlatpakInfo without an app = %q, want empty", got)
+	}
+}
+
+// TestSnapNameFromCgroup tests finding the snap scope in cgroup v1 and v2 paths
+func TestSnapNameFromCgroup(t *testing.T) {
+	tests := map[string]string{
+		"0::/user.slice/user-1000.slice/user@1000.service/app.slice/snap.firefox.firefox-4242.scope\n": "firefox",
+		"12:pids:/user.slice/user-1000.slice/snap.spotify.spotify.3f2a.scope\n1:name=systemd:/\n":      "spotify",
+		"0::/user.slice/user-1000.slice/user@1000.service/app.slice/app-gnome-firefox-4242.scope\n":    "",
+		"0::/system.slice/snapd.service\n": "",
+	}
+	for cgroup, want := range tests {
+		if got := snapNameFromCgroup(cgroup); got != want {
+			t.Errorf("snapNameFromCgroup(%q) = %q, want %q", cgroup, got, want)
+		}
+	}
+}
+
+// TestAppImageID tests turning AppImage file names into stable names
+func TestAppImageID(t *testing.T) {
+	tests := map[string]string{
+		"/home/me/Apps/Obsidian-1.4.16.AppImage":         "obsidian",
+		"/home/me/Apps/Obsidian-1.4.16-x86_64.AppImage":  "obsidian",
+		"/opt/balenaEtcher-v1.18.11-x64.AppImage":        "balenaetcher",
+		"/home/me/kdenlive_24.02.1-x86_64.appimage":      "kdenlive",
+		"/home/me/Apps/Joplin.AppImage":                  "joplin",
+		"/home/me/Apps/visual-studio-code-1.80.AppImage": "visual-studio-code",
+	}
+	for path, want := range tests {
+		if got := appImageID(path); got != want {
+			t.Errorf("appImageID(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+// TestEnvironValue tests looking up variables in /proc/<pid>/environ contents
+func TestEnvironValue(t *testing.T) {
+	environ := []byte("HOME=/home/me\x00APPIMAGE=/home/me/Joplin.AppImage\x00APPDIR=/tmp/.mount_Joplin\x00")
+	if got := environValue(environ, "APPIMAGE"); got != "/home/me/Joplin.AppImage" {
+		t.Errorf("environValue = %q", got)
+	}
+	if got := environValue(environ, "APP"); got != "" {
+		t.Errorf("environValue of a prefix = %q, want empty", got)
+	}
+}
@@ -0,0 +1,11 @@
+package main
+
+// resolveAppID returns the identity of an interpreted app: the script, jar or
+// Electron app an interpreter runs. path may also be just the executable
+// name; other processes are identified by their executable alone
+func resolveAppID(pid int, path string) string {
+	if interpreterKind(path) == "" {
+		return ""
+	}
+	return interpreterAppID(path, processCommandLine(pid))
+}
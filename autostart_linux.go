@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	// XDG autostart entry name
+	autostartDesktopFile = "sybr.desktop"
+	appName              = "WindowMonitor"
+)
+
+// autostartPath returns the path of the XDG autostart desktop entry
+func autostartPath() (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to get config directory: %w", err)
+	}
+	return filepath.Join(configDir, "autostart", autostartDesktopFile), nil
+}
+
+// EnableAutoStart writes an XDG autostart entry for the application
+func EnableAutoStart(exePath string) error {
+	// Convert to absolute path
+	absPath, err := filepath.Abs(exePath)
+	if err != nil {
+		return fmt.Errorf("failed to get absolute path: %w", err)
+	}
+
+	// Verify the executable exists
+	if _, err := os.Stat(absPath); os.IsNotExist(err) {
+		return fmt.Errorf("executable not found: %s", absPath)
+	}
+
+	path, err := autostartPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create autostart directory: %w", err)
+	}
+
+	// Quote the path as required by the desktop entry spec
+	quoted := `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "`", "\\`", "$", `\$`).Replace(absPath) + `"`
+	entry := fmt.Sprintf(`[Desktop Entry]
+Type=Application
+Name=%s
+Exec=%s
+X-GNOME-Autostart-enabled=true
+`, appName, quoted)
+
+	if err := os.WriteFile(path, []byte(entry), 0644); err != nil {
+		return fmt.Errorf("failed to write autostart entry: %w", err)
+	}
+	return nil
+}
+
+// DisableAutoStart removes the XDG autostart entry
+func DisableAutoStart() error {
+	path, err := autostartPath()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove autostart entry: %w", err)
+	}
+	return nil
+}
+
+// IsAutoStartEnabled checks if the XDG autostart entry exists
+func IsAutoStartEnabled() (bool, error) {
+	path, err := autostartPath()
+	if err != nil {
+		return false, err
+	}
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to check autostart entry: %w", err)
+	}
+	return true, nil
+}
@@ -3,8 +3,9 @@ package main
 import (
 	"encoding/json"
 	"fmt"
+	"net"
 	"os"
-	"path/filepath"
+	"runtime"
 	"strings"
 	"sync"
 )
@@ -12,15 +13,120 @@ import (
 // BlockedApp represents a blocked application
 // Note: Field names must be capitalized for JSON export in Go
 type BlockedApp struct {
-	ExecutableName string `json:"executableName"` // e.g., "chrome.exe"
-	DisplayName    string `json:"displayName"`    // e.g., "Google Chrome"
+	ExecutableName string   `json:"executableName"`      // Canonical name, e.g. "chrome" for chrome.exe
+	DisplayName    string   `json:"displayName"`         // e.g., "Google Chrome"
+	Profiles       []string `json:"profiles,omitempty"`  // Empty = blocked in every profile
+	Platforms      []string `json:"platforms,omitempty"` // e.g. ["windows"]; empty = on every OS
+	Scope          string   `json:"scope,omitempty"`     // ScopeProcess (default), ScopeTree or ScopeLaunched
+	Hashes         []string `json:"hashes,omitempty"`    // SHA-256 of pinned binaries, matched under any name
+	Kind           string   `json:"kind,omitempty"`      // KindApp (default) or KindDomain
+}
+
+// Blocklist entry kinds
+const (
+	KindApp    = ""       // ExecutableName is an executable or app ID
+	KindDomain = "domain" // ExecutableName is a website's domain, e.g. youtube.com
+)
+
+// Blocklist entry scopes
+const (
+	ScopeProcess  = ""         // Only the executable itself
+	ScopeTree     = "tree"     // The executable and everything it starts
+	ScopeLaunched = "launched" // Only what the executable starts, e.g. games from a launcher
+)
+
+// identifies reports whether the entry names the process, by executable name,
+// app ID for sandboxed and interpreted apps or the hash of its binary so that renamed copies
+// are caught too
+func (app BlockedApp) identifies(p ProcessInfo) bool {
+	if app.Kind == KindDomain {
+		return false
+	}
+	if app.ExecutableName == normalizeExecutableName(p.Name) {
+		return true
+	}
+	if p.AppID != "" && app.ExecutableName == normalizeExecutableName(p.AppID) {
+		return true
+	}
+	if len(app.Hashes) == 0 {
+		return false
+	}
+	path := p.Path
+	if path == "" {
+		path = processPath(p.PID)
+	}
+	if path == "" {
+		return false
+	}
+	sum, err := processIdentities.hash(p.PID, path)
+	if err != nil {
+		return false
+	}
+	return containsString(app.Hashes, sum)
+}
+
+// matches reports whether the entry applies to a process, given its
+// ancestors (parent first)
+func (app BlockedApp) matches(p ProcessInfo, ancestors []ProcessInfo) bool {
+	if app.Scope != ScopeLaunched && app.identifies(p) {
+		return true
+	}
+	if app.Scope == ScopeProcess {
+		return false
+	}
+	for _, ancestor := range ancestors {
+		if app.identifies(ancestor) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesDomain reports whether a domain entry covers domain or one of its
+// subdomains: youtube.com covers m.youtube.com but not notyoutube.com
+func (app BlockedApp) matchesDomain(domain string) bool {
+	if app.Kind != KindDomain || domain == "" {
+		return false
+	}
+	return domain == app.ExecutableName || strings.HasSuffix(domain, "."+app.ExecutableName)
+}
+
+// currentPlatform is the OS entries' platforms are checked against
+var currentPlatform = runtime.GOOS
+
+// knownPlatforms are the values accepted in BlockedApp.Platforms
+var knownPlatforms = map[string]bool{"windows": true, "linux": true, "darwin": true}
+
+// enforced reports whether the entry applies on this OS while profile is active
+func (app BlockedApp) enforced(profile string) bool {
+	return app.onPlatform(currentPlatform) && app.inProfile(profile)
+}
+
+// onPlatform reports whether the entry applies on the given OS
+func (app BlockedApp) onPlatform(platform string) bool {
+	return len(app.Platforms) == 0 || containsString(app.Platforms, platform)
+}
+
+// inProfile reports whether the entry is enforced while profile is active
+func (app BlockedApp) inProfile(profile string) bool {
+	if len(app.Profiles) == 0 {
+		return true
+	}
+	for _, p := range app.Profiles {
+		if p == profile {
+			return true
+		}
+	}
+	return false
 }
 
 // BlocklistManager manages the blocklist storage
 type BlocklistManager struct {
-	filePath string
-	mu       sync.RWMutex
-	apps     []BlockedApp
+	filePath      string
+	mu            sync.RWMutex
+	apps          []BlockedApp
+	activeProfile string                    // "" = default profile, only entries without profiles apply
+	guard         func(action string) error // Commitment lock check, nil = unlocked
 }
 
 var (
@@ -30,19 +136,10 @@ var (
 
 // getBlocklistPath returns the path to the blocklist JSON file
 func getBlocklistPath() (string, error) {
-	// In dev mode, use current working directory
-	// In production, use executable directory
-	wd, err := os.Getwd()
+	blocklistPath, err := getDataFilePath("blocking_list.json")
 	if err != nil {
-		// Fallback to executable directory
-		exePath, exeErr := os.Executable()
-		if exeErr != nil {
-			return "", fmt.Errorf("failed to get working directory or executable path: %w", err)
-		}
-		wd = filepath.Dir(exePath)
+		return "", err
 	}
-
-	blocklistPath := filepath.Join(wd, "blocking_list.json")
 	fmt.Printf("📁 Blocklist file path: %s\n", blocklistPath)
 	return blocklistPath, nil
 }
@@ -93,6 +190,7 @@ func (bm *BlocklistManager) load() error {
 	if err := json.Unmarshal(data, &apps); err != nil {
 		return err
 	}
+	apps = canonicalizeApps(apps)
 
 	bm.mu.Lock()
 	bm.apps = apps
@@ -121,16 +219,101 @@ func (bm *BlocklistManager) save() error {
 	return nil
 }
 
+// normalizeExecutableName returns the canonical form of an executable name:
+// lowercase and without the Windows .exe suffix, so "Discord.exe" on Windows
+// and "discord" on Linux are the same app
+func normalizeExecutableName(executableName string) string {
+	executableName = strings.ToLower(strings.TrimSpace(executableName))
+	return strings.TrimSuffix(executableName, ".exe")
+}
+
+// normalizeDomain turns a domain or URL into the form domain entries are kept
+// in: "https://www.YouTube.com/watch" and "*.youtube.com" are youtube.com
+func normalizeDomain(domain string) (string, error) {
+	domain = strings.ToLower(strings.TrimSpace(domain))
+	if i := strings.Index(domain, "://"); i >= 0 {
+		domain = domain[i+3:]
+	}
+	if i := strings.IndexAny(domain, "/?#"); i >= 0 {
+		domain = domain[:i]
+	}
+	if i := strings.LastIndex(domain, "@"); i >= 0 {
+		domain = domain[i+1:]
+	}
+	if host, _, err := net.SplitHostPort(domain); err == nil {
+		domain = host
+	}
+	// Subdomains are always blocked, so a wildcard means the same as the domain
+	domain = strings.TrimPrefix(domain, "*.")
+	domain = strings.TrimPrefix(strings.TrimSuffix(domain, "."), "www.")
+	if domain == "" || strings.Trim(domain, "abcdefghijklmnopqrstuvwxyz0123456789.-") != "" ||
+		strings.HasPrefix(domain, ".") || strings.Contains(domain, "..") {
+		return "", fmt.Errorf("'%s' is not a valid domain", domain)
+	}
+	return domain, nil
+}
+
+// canonicalizeApps brings entries written by older versions, which kept the
+// .exe suffix, into canonical form and merges entries that become the same app.
+// The merged entry blocks everything either one did
+func canonicalizeApps(apps []BlockedApp) []BlockedApp {
+	canonical := make([]BlockedApp, 0, len(apps))
+	index := map[string]int{}
+	for _, app := range apps {
+		app.ExecutableName = normalizeExecutableName(app.ExecutableName)
+		i, seen := index[app.ExecutableName]
+		if !seen {
+			index[app.ExecutableName] = len(canonical)
+			canonical = append(canonical, app)
+			continue
+		}
+		merged := &canonical[i]
+		merged.Profiles = unionLimits(merged.Profiles, app.Profiles)
+		merged.Platforms = unionLimits(merged.Platforms, app.Platforms)
+		if merged.Scope != app.Scope {
+			// ScopeTree covers both the executable and what it starts
+			merged.Scope = ScopeTree
+		}
+		for _, sum := range app.Hashes {
+			if !containsString(merged.Hashes, sum) {
+				merged.Hashes = append(merged.Hashes, sum)
+			}
+		}
+	}
+	return canonical
+}
+
+// unionLimits merges two profile or platform lists of entries for the same
+// app. An empty list means no limit, so it stays empty if either one is
+func unionLimits(a, b []string) []string {
+	if len(a) == 0 || len(b) == 0 {
+		return nil
+	}
+	for _, item := range b {
+		if !containsString(a, item) {
+			a = append(a, item)
+		}
+	}
+	return a
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 // AddApp adds an app to the blocklist
 func (bm *BlocklistManager) AddApp(executableName, displayName string) error {
 	bm.mu.Lock()
 	defer bm.mu.Unlock()
 
-	// Normalize executable name (lowercase, ensure .exe)
-	executableName = strings.ToLower(strings.TrimSpace(executableName))
-	if !strings.HasSuffix(executableName, ".exe") {
-		executableName += ".exe"
-	}
+	// Normalize executable name (lowercase, no .exe suffix)
+	executableName = normalizeExecutableName(executableName)
 
 	fmt.Printf("📝 BlocklistManager.AddApp: executableName=%s, displayName=%s\n", executableName, displayName)
 
@@ -162,16 +345,64 @@ func (bm *BlocklistManager) AddApp(executableName, displayName string) error {
 	return nil
 }
 
+// AddDomain adds a website to the blocklist; it applies to browsers that
+// report their active tab through the native messaging host
+func (bm *BlocklistManager) AddDomain(domain, displayName string) error {
+	domain, err := normalizeDomain(domain)
+	if err != nil {
+		return err
+	}
+
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	for _, app := range bm.apps {
+		if app.ExecutableName == domain {
+			return fmt.Errorf("'%s' is already in the blocklist", domain)
+		}
+	}
+	if displayName == "" {
+		displayName = domain
+	}
+	bm.apps = append(bm.apps, BlockedApp{
+		ExecutableName: domain,
+		DisplayName:    displayName,
+		Kind:           KindDomain,
+	})
+	fmt.Printf("🌐 Added domain %s to the blocklist\n", domain)
+	return bm.save()
+}
+
+// SetGuard sets the check that refuses weakening changes (CommitmentLock.Check)
+func (bm *BlocklistManager) SetGuard(guard func(action string) error) {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	bm.guard = guard
+}
+
+// checkGuard runs the guard; it must be called without holding bm.mu because
+// the lock looks at the session engine, which calls back into SetActiveProfile
+func (bm *BlocklistManager) checkGuard(action string) error {
+	bm.mu.RLock()
+	guard := bm.guard
+	bm.mu.RUnlock()
+	if guard == nil {
+		return nil
+	}
+	return guard(action)
+}
+
 // RemoveApp removes an app from the blocklist
 func (bm *BlocklistManager) RemoveApp(executableName string) error {
+	if err := bm.checkGuard("removing apps from the blocklist"); err != nil {
+		return err
+	}
+
 	bm.mu.Lock()
 	defer bm.mu.Unlock()
 
 	// Normalize executable name
-	executableName = strings.ToLower(strings.TrimSpace(executableName))
-	if !strings.HasSuffix(executableName, ".exe") {
-		executableName += ".exe"
-	}
+	executableName = normalizeExecutableName(executableName)
 
 	// Find and remove
 	newApps := []BlockedApp{}
@@ -230,12 +461,12 @@ func (bm *BlocklistManager) IsBlocked(executableName string) bool {
 	defer bm.mu.RUnlock()
 
 	// Normalize for comparison
-	executableName = strings.ToLower(strings.TrimSpace(executableName))
+	executableName = normalizeExecutableName(executableName)
 
 	fmt.Printf("🔍 IsBlocked: checking '%s' against %d apps\n", executableName, len(bm.apps))
 	for i, app := range bm.apps {
 		fmt.Printf("   [%d] Comparing with: '%s'\n", i, app.ExecutableName)
-		if app.ExecutableName == executableName {
+		if app.ExecutableName == executableName && app.enforced(bm.activeProfile) {
 			fmt.Printf("   ✅ MATCH FOUND!\n")
 			return true
 		}
@@ -250,8 +481,63 @@ func (bm *BlocklistManager) GetBlockedApp(executableName string) *BlockedApp {
 	defer bm.mu.RUnlock()
 
 	// Normalize for comparison
-	executableName = strings.ToLower(strings.TrimSpace(executableName))
+	executableName = normalizeExecutableName(executableName)
 
+	for _, app := range bm.apps {
+		if app.ExecutableName == executableName && app.enforced(bm.activeProfile) {
+			return &app
+		}
+	}
+	return nil
+}
+
+// MatchProcess returns the entry blocking a process, looking at the entries
+// for its ancestors too (parent first), nil if it isn't blocked. An entry for
+// the executable itself wins over a launcher's
+func (bm *BlocklistManager) MatchProcess(p ProcessInfo, ancestors []ProcessInfo) *BlockedApp {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+
+	var launcher *BlockedApp
+	for _, app := range bm.apps {
+		if !app.enforced(bm.activeProfile) || !app.matches(p, ancestors) {
+			continue
+		}
+		if app.identifies(p) {
+			return &app
+		}
+		if launcher == nil {
+			launcher = &app
+		}
+	}
+	return launcher
+}
+
+// MatchDomain returns the entry blocking a website's domain, nil if it isn't
+// blocked. The most specific entry wins, so a subdomain's own display name
+// is used
+func (bm *BlocklistManager) MatchDomain(domain string) *BlockedApp {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+
+	var match *BlockedApp
+	for _, app := range bm.apps {
+		if !app.enforced(bm.activeProfile) || !app.matchesDomain(domain) {
+			continue
+		}
+		if match == nil || len(app.ExecutableName) > len(match.ExecutableName) {
+			match = &app
+		}
+	}
+	return match
+}
+
+// GetBlockedAppAnyProfile returns the entry for an executable regardless of the active profile
+func (bm *BlocklistManager) GetBlockedAppAnyProfile(executableName string) *BlockedApp {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+
+	executableName = normalizeExecutableName(executableName)
 	for _, app := range bm.apps {
 		if app.ExecutableName == executableName {
 			return &app
@@ -259,3 +545,241 @@ func (bm *BlocklistManager) GetBlockedApp(executableName string) *BlockedApp {
 	}
 	return nil
 }
+
+// SetActiveProfile switches which profile-specific entries are enforced
+func (bm *BlocklistManager) SetActiveProfile(profile string) error {
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	profile = strings.ToLower(strings.TrimSpace(profile))
+	if bm.activeProfile != profile {
+		fmt.Printf("🎚️  Active blocklist profile: '%s' -> '%s'\n", bm.activeProfile, profile)
+	}
+	bm.activeProfile = profile
+	return nil
+}
+
+// ActiveProfile returns the currently enforced profile ("" is the default profile)
+func (bm *BlocklistManager) ActiveProfile() string {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+	return bm.activeProfile
+}
+
+// SetAppProfiles sets the profiles an app is blocked in; no profiles means always
+func (bm *BlocklistManager) SetAppProfiles(executableName string, profiles []string) error {
+	executableName = normalizeExecutableName(executableName)
+	normalized := normalizeProfiles(profiles)
+
+	// Narrowing the profiles an app is blocked in is a downgrade
+	bm.mu.RLock()
+	downgrade := false
+	for _, app := range bm.apps {
+		if app.ExecutableName == executableName {
+			downgrade = profilesNarrowed(app.Profiles, normalized)
+		}
+	}
+	bm.mu.RUnlock()
+	if downgrade {
+		if err := bm.checkGuard("narrowing the profiles of a blocked app"); err != nil {
+			return err
+		}
+	}
+
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	for i, app := range bm.apps {
+		if app.ExecutableName == executableName {
+			bm.apps[i].Profiles = normalized
+			return bm.save()
+		}
+	}
+	return fmt.Errorf("app '%s' not found in blocklist", executableName)
+}
+
+// SetAppScope sets whether an entry covers the processes an app starts
+func (bm *BlocklistManager) SetAppScope(executableName, scope string) error {
+	executableName = normalizeExecutableName(executableName)
+	scope, err := normalizeScope(scope)
+	if err != nil {
+		return err
+	}
+
+	bm.mu.RLock()
+	downgrade := false
+	for _, app := range bm.apps {
+		if app.ExecutableName == executableName {
+			if app.Kind == KindDomain {
+				bm.mu.RUnlock()
+				return fmt.Errorf("'%s' is a website, scopes only apply to apps", executableName)
+			}
+			downgrade = scopeNarrowed(app.Scope, scope)
+		}
+	}
+	bm.mu.RUnlock()
+	if downgrade {
+		if err := bm.checkGuard("narrowing the scope of a blocked app"); err != nil {
+			return err
+		}
+	}
+
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	for i, app := range bm.apps {
+		if app.ExecutableName == executableName {
+			bm.apps[i].Scope = scope
+			return bm.save()
+		}
+	}
+	return fmt.Errorf("app '%s' not found in blocklist", executableName)
+}
+
+// PinHash adds a binary's SHA-256 to an entry so the program is blocked under
+// any name or path
+func (bm *BlocklistManager) PinHash(executableName, sum string) error {
+	executableName = normalizeExecutableName(executableName)
+	sum, err := normalizeHash(sum)
+	if err != nil {
+		return err
+	}
+
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	for i, app := range bm.apps {
+		if app.ExecutableName != executableName {
+			continue
+		}
+		if app.Kind == KindDomain {
+			return fmt.Errorf("'%s' is a website, only apps can be pinned", executableName)
+		}
+		if containsString(app.Hashes, sum) {
+			return nil
+		}
+		bm.apps[i].Hashes = append(bm.apps[i].Hashes, sum)
+		fmt.Printf("📌 Pinned %s to %s\n", sum[:12], executableName)
+		return bm.save()
+	}
+	return fmt.Errorf("app '%s' not found in blocklist", executableName)
+}
+
+// SetAppPlatforms limits the operating systems an entry applies on; no
+// platforms means every OS
+func (bm *BlocklistManager) SetAppPlatforms(executableName string, platforms []string) error {
+	executableName = normalizeExecutableName(executableName)
+	normalized, err := normalizePlatforms(platforms)
+	if err != nil {
+		return err
+	}
+
+	// Limiting the platforms works like narrowing the profiles
+	bm.mu.RLock()
+	downgrade := false
+	for _, app := range bm.apps {
+		if app.ExecutableName == executableName {
+			downgrade = profilesNarrowed(app.Platforms, normalized)
+		}
+	}
+	bm.mu.RUnlock()
+	if downgrade {
+		if err := bm.checkGuard("limiting the platforms of a blocked app"); err != nil {
+			return err
+		}
+	}
+
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+
+	for i, app := range bm.apps {
+		if app.ExecutableName == executableName {
+			bm.apps[i].Platforms = normalized
+			return bm.save()
+		}
+	}
+	return fmt.Errorf("app '%s' not found in blocklist", executableName)
+}
+
+// normalizePlatforms validates platform names from user input
+func normalizePlatforms(platforms []string) ([]string, error) {
+	normalized := []string{}
+	for _, p := range platforms {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p == "" || containsString(normalized, p) {
+			continue
+		}
+		if !knownPlatforms[p] {
+			return nil, fmt.Errorf("unknown platform '%s' (use windows, linux or darwin)", p)
+		}
+		normalized = append(normalized, p)
+	}
+	return normalized, nil
+}
+
+// normalizeScope validates a scope from user input ("process" is the default)
+func normalizeScope(scope string) (string, error) {
+	switch scope = strings.ToLower(strings.TrimSpace(scope)); scope {
+	case ScopeProcess, "process":
+		return ScopeProcess, nil
+	case ScopeTree, ScopeLaunched:
+		return scope, nil
+	}
+	return "", fmt.Errorf("unknown scope '%s' (use process, tree or launched)", scope)
+}
+
+// scopeNarrowed reports whether changing an entry's scope from old to updated
+// stops it from blocking something. Only widening to ScopeTree never does
+func scopeNarrowed(old, updated string) bool {
+	return old != updated && updated != ScopeTree
+}
+
+// normalizeProfiles lowercases profile names and drops empty ones
+func normalizeProfiles(profiles []string) []string {
+	normalized := []string{}
+	for _, p := range profiles {
+		if p = strings.ToLower(strings.TrimSpace(p)); p != "" {
+			normalized = append(normalized, p)
+		}
+	}
+	return normalized
+}
+
+// profilesNarrowed reports whether an app blocked in old is blocked in fewer
+// situations with updated. No profiles means blocked in every profile
+func profilesNarrowed(old, updated []string) bool {
+	if len(updated) == 0 {
+		return false
+	}
+	if len(old) == 0 {
+		return true
+	}
+	kept := map[string]bool{}
+	for _, p := range updated {
+		kept[p] = true
+	}
+	for _, p := range old {
+		if !kept[p] {
+			return true
+		}
+	}
+	return false
+}
+
+// GetProfiles returns all profile names used by blocklist entries
+func (bm *BlocklistManager) GetProfiles() []string {
+	bm.mu.RLock()
+	defer bm.mu.RUnlock()
+
+	seen := map[string]bool{}
+	profiles := []string{}
+	for _, app := range bm.apps {
+		for _, p := range app.Profiles {
+			if !seen[p] {
+				seen[p] = true
+				profiles = append(profiles, p)
+			}
+		}
+	}
+	return profiles
+}
@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestNormalizeExecutableName tests that Windows and Linux names of an app
+// share one canonical form
+func TestNormalizeExecutableName(t *testing.T) {
+	tests := map[string]string{
+		"Discord.exe":  "discord",
+		" discord ":    "discord",
+		"firefox":      "firefox",
+		"code-oss":     "code-oss",
+		"archive.exes": "archive.exes",
+	}
+	for input, want := range tests {
+		if got := normalizeExecutableName(input); got != want {
+			t.Errorf("normalizeExecutableName(%q) = %q, want %q", input, got, want)
+		}
+	}
+}
+
+// TestBlocklistMatchesAcrossPlatforms tests that an entry added with either
+// name matches the process on Windows and on Linux
+func TestBlocklistMatchesAcrossPlatforms(t *testing.T) {
+	bm := &BlocklistManager{filePath: filepath.Join(t.TempDir(), "blocking_list.json")}
+	if err := bm.AddApp("Discord.exe", "Discord"); err != nil {
+		t.Fatalf("AddApp failed: %v", err)
+	}
+	if err := bm.AddApp("discord", "Discord"); err == nil {
+		t.Error("adding the Linux name of the same app should fail as a duplicate")
+	}
+	if err := bm.AddApp("firefox", "Firefox"); err != nil {
+		t.Fatalf("AddApp failed: %v", err)
+	}
+
+	for _, exe := range []string{"discord.exe", "discord", "firefox", "firefox.exe"} {
+		if !bm.IsBlocked(exe) {
+			t.Errorf("%s should be blocked", exe)
+		}
+	}
+	if err := bm.RemoveApp("firefox.exe"); err != nil {
+		t.Errorf("removing by the Windows name failed: %v", err)
+	}
+	if bm.IsBlocked("firefox") {
+		t.Error("firefox should be unblocked")
+	}
+}
+
+// TestBlocklistLoadsLegacyNames tests that entries saved with the .exe suffix
+// are read in canonical form, merging duplicates
+func TestBlocklistLoadsLegacyNames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "blocking_list.json")
+	legacy := `[
+  {"executableName": "steam.exe", "displayName": "Steam", "hashes": ["aa"]},
+  {"executableName": "steam", "displayName": "Steam (Linux)", "hashes": ["aa", "bb"]}
+]`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	bm := &BlocklistManager{filePath: path}
+	apps := bm.GetApps()
+	if len(apps) != 1 || apps[0].ExecutableName != "steam" || apps[0].DisplayName != "Steam" {
+		t.Fatalf("apps = %+v, want one canonical steam entry", apps)
+	}
+	if len(apps[0].Hashes) != 2 {
+		t.Errorf("hashes = %v, want both merged", apps[0].Hashes)
+	}
+}
+
+// TestBlocklistMergesLimits tests that merging duplicates keeps what either
+// entry blocked instead of the first entry's limits
+func TestBlocklistMergesLimits(t *testing.T) {
+	saved := currentPlatform
+	defer func() { currentPlatform = saved }()
+
+	path := filepath.Join(t.TempDir(), "blocking_list.json")
+	legacy := `[
+  {"executableName": "discord.exe", "displayName": "Discord", "platforms": ["windows"], "profiles": ["work"]},
+  {"executableName": "Discord", "displayName": "Discord", "platforms": ["linux"], "scope": "launched"},
+  {"executableName": "steam.exe", "displayName": "Steam", "profiles": ["work"]},
+  {"executableName": "steam", "displayName": "Steam", "profiles": ["evening"]}
+]`
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	bm := &BlocklistManager{filePath: path}
+	apps := bm.GetApps()
+	if len(apps) != 2 {
+		t.Fatalf("apps = %+v, want one discord and one steam entry", apps)
+	}
+	discord := apps[0]
+	if len(discord.Platforms) != 2 || len(discord.Profiles) != 0 || discord.Scope != ScopeTree {
+		t.Errorf("discord = %+v, want windows and linux in every profile with the tree scope", discord)
+	}
+	for _, platform := range []string{"windows", "linux"} {
+		currentPlatform = platform
+		if !bm.IsBlocked("discord") {
+			t.Errorf("discord should be blocked on %s", platform)
+		}
+	}
+	if steam := apps[1]; len(steam.Profiles) != 2 || len(steam.Platforms) != 0 {
+		t.Errorf("steam = %+v, want the work and evening profiles on every platform", steam)
+	}
+}
+
+// TestBlocklistPlatforms tests that an entry limited to some platforms is
+// only enforced there, and that limiting it goes through the guard
+func TestBlocklistPlatforms(t *testing.T) {
+	saved := currentPlatform
+	defer func() { currentPlatform = saved }()
+
+	bm := &BlocklistManager{filePath: filepath.Join(t.TempDir(), "blocking_list.json")}
+	if err := bm.AddApp("explorer.exe", "Explorer"); err != nil {
+		t.Fatalf("AddApp failed: %v", err)
+	}
+	if err := bm.SetAppPlatforms("explorer", []string{"Windows"}); err != nil {
+		t.Fatalf("SetAppPlatforms failed: %v", err)
+	}
+	if err := bm.SetAppPlatforms("explorer", []string{"beos"}); err == nil {
+		t.Error("SetAppPlatforms accepted an unknown platform")
+	}
+
+	currentPlatform = "windows"
+	if !bm.IsBlocked("explorer.exe") {
+		t.Error("explorer should be blocked on Windows")
+	}
+	currentPlatform = "linux"
+	if bm.IsBlocked("explorer") {
+		t.Error("explorer should not be blocked on Linux")
+	}
+
+	bm.SetGuard(func(action string) error { return errCommitmentLocked })
+	if err := bm.SetAppPlatforms("explorer", nil); err != nil {
+		t.Errorf("widening to every platform under lock failed: %v", err)
+	}
+	if err := bm.SetAppPlatforms("explorer", []string{"linux"}); !errors.Is(err, errCommitmentLocked) {
+		t.Errorf("limiting platforms = %v, want errCommitmentLocked", err)
+	}
+}
+
+// TestBlocklistMatchesAppID tests that sandboxed apps are matched by their
+// app ID rather than the wrapper executable
+func TestBlocklistMatchesAppID(t *testing.T) {
+	bm := &BlocklistManager{filePath: filepath.Join(t.TempDir(), "blocking_list.json")}
+	if err := bm.AddApp("com.discordapp.Discord", "Discord"); err != nil {
+		t.Fatalf("AddApp failed: %v", err)
+	}
+
+	if app := bm.MatchProcess(ProcessInfo{Name: "bwrap", AppID: "com.discordapp.Discord"}, nil); app == nil {
+		t.Error("the Flatpak should be matched by its app ID")
+	}
+	if app := bm.MatchProcess(ProcessInfo{Name: "bwrap", AppID: "org.mozilla.firefox"}, nil); app != nil {
+		t.Errorf("another Flatpak matched %+v", app)
+	}
+}
+
+// TestNormalizeDomain tests accepting domains and URLs in any case
+func TestNormalizeDomain(t *testing.T) {
+	tests := map[string]string{
+		"youtube.com":                       "youtube.com",
+		"https://www.YouTube.com/watch?v=1": "youtube.com",
+		"m.youtube.com.":                    "m.youtube.com",
+		"user@example.org:8443/path":        "example.org",
+		" news.ycombinator.com ":            "news.ycombinator.com",
+		"*.reddit.com":                      "reddit.com",
+	}
+	for input, want := range tests {
+		got, err := normalizeDomain(input)
+		if err != nil || got != want {
+			t.Errorf("normalizeDomain(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+	for _, bad := range []string{"", "https://", "exa mple.com", "a..b", ".com"} {
+		if got, err := normalizeDomain(bad); err == nil {
+			t.Errorf("normalizeDomain(%q) = %q, want an error", bad, got)
+		}
+	}
+}
+
+// TestBlocklistMatchesDomain tests that domain entries cover subdomains, that
+// the most specific entry wins and that they never match processes
+func TestBlocklistMatchesDomain(t *testing.T) {
+	bm := &BlocklistManager{filePath: filepath.Join(t.TempDir(), "blocking_list.json")}
+	if err := bm.AddDomain("https://www.youtube.com/", "YouTube"); err != nil {
+		t.Fatalf("AddDomain failed: %v", err)
+	}
+	if err := bm.AddDomain("music.youtube.com", "YouTube Music"); err != nil {
+		t.Fatalf("AddDomain failed: %v", err)
+	}
+	if err := bm.AddDomain("youtube.com", ""); err == nil {
+		t.Error("adding a domain twice should fail")
+	}
+
+	if app := bm.MatchDomain("m.youtube.com"); app == nil || app.DisplayName != "YouTube" {
+		t.Errorf("m.youtube.com matched %+v, want YouTube", app)
+	}
+	if app := bm.MatchDomain("music.youtube.com"); app == nil || app.DisplayName != "YouTube Music" {
+		t.Errorf("music.youtube.com matched %+v, want YouTube Music", app)
+	}
+	if app := bm.MatchDomain("notyoutube.com"); app != nil {
+		t.Errorf("notyoutube.com matched %+v", app)
+	}
+	if app := bm.MatchProcess(ProcessInfo{Name: "youtube.com"}, nil); app != nil {
+		t.Errorf("a process matched the domain entry %+v", app)
+	}
+	if err := bm.SetAppScope("youtube.com", ScopeTree); err == nil {
+		t.Error("a domain entry shouldn't take a scope")
+	}
+}
@@ -0,0 +1,59 @@
+// Reports the active tab to sybr through its native messaging host, which
+// `sybr browser install` registers with the browser
+const HOST = 'sybr.native_host'
+
+let port = null
+let lastReport = ''
+
+// connect starts the host; the open port also keeps this worker alive
+function connect() {
+  if (port) {
+    return port
+  }
+  port = chrome.runtime.connectNative(HOST)
+  port.onMessage.addListener((reply) => {
+    if (reply.type === 'error') {
+      console.warn('sybr:', reply.error)
+    }
+    chrome.action?.setBadgeText({ text: reply.blocked ? '!' : '' })
+  })
+  port.onDisconnect.addListener(() => {
+    console.warn('sybr host disconnected:', chrome.runtime.lastError?.message)
+    port = null
+    lastReport = ''
+  })
+  return port
+}
+
+// report sends the active tab of the focused window, once per change
+async function report() {
+  const [tab] = await chrome.tabs.query({ active: true, lastFocusedWindow: true })
+  const message = { type: 'tab', url: tab?.url || '', title: tab?.title || '' }
+  const key = message.url + '\n' + message.title
+  if (key === lastReport) {
+    return
+  }
+  lastReport = key
+  try {
+    connect().postMessage(message)
+  } catch (err) {
+    console.warn('sybr: failed to report the tab', err)
+    port = null
+    lastReport = ''
+  }
+}
+
+chrome.tabs.onActivated.addListener(report)
+chrome.tabs.onUpdated.addListener((tabId, changeInfo, tab) => {
+  if (tab.active && (changeInfo.url || changeInfo.title)) {
+    report()
+  }
+})
+chrome.windows.onFocusChanged.addListener((windowId) => {
+  if (windowId !== chrome.windows.WINDOW_ID_NONE) {
+    report()
+  }
+})
+chrome.runtime.onStartup.addListener(report)
+chrome.runtime.onInstalled.addListener(report)
+report()
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BrowserTab is the active tab a browser extension reported through the
+// native messaging host
+type BrowserTab struct {
+	Browser string    `json:"browser"`       // Canonical executable name of the browser, e.g. firefox
+	PID     int       `json:"pid,omitempty"` // Browser process that started the host
+	URL     string    `json:"url"`
+	Domain  string    `json:"domain,omitempty"` // "" for pages that aren't websites, e.g. about:blank
+	Title   string    `json:"title,omitempty"`
+	Time    time.Time `json:"time"`
+}
+
+// BrowserTabs keeps the last reported active tab of every browser, which the
+// watcher adds to the window info of that browser's windows
+type BrowserTabs struct {
+	mu   sync.Mutex
+	tabs map[string]BrowserTab
+}
+
+// NewBrowserTabs creates an empty tab tracker
+func NewBrowserTabs() *BrowserTabs {
+	return &BrowserTabs{tabs: map[string]BrowserTab{}}
+}
+
+// Report records the active tab of a browser and returns it with its domain
+func (bt *BrowserTabs) Report(tab BrowserTab) BrowserTab {
+	tab.Browser = normalizeExecutableName(tab.Browser)
+	tab.Domain = domainOf(tab.URL)
+	if tab.Time.IsZero() {
+		tab.Time = time.Now()
+	}
+
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	if previous, ok := bt.tabs[tab.Browser]; !ok || previous.Domain != tab.Domain {
+		fmt.Printf("🌐 Active tab in %s: %s\n", tab.Browser, tab.Domain)
+	}
+	bt.tabs[tab.Browser] = tab
+	return tab
+}
+
+// Tabs returns the last reported tab of every browser
+func (bt *BrowserTabs) Tabs() []BrowserTab {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	tabs := make([]BrowserTab, 0, len(bt.tabs))
+	for _, tab := range bt.tabs {
+		tabs = append(tabs, tab)
+	}
+	sort.Slice(tabs, func(i, j int) bool { return tabs[i].Browser < tabs[j].Browser })
+	return tabs
+}
+
+// annotate adds the URL and domain of the active tab to the window info of a
+// browser that reported one. A report from another instance of the browser,
+// e.g. before it was restarted, is ignored
+func (bt *BrowserTabs) annotate(info *WindowInfo) {
+	bt.mu.Lock()
+	defer bt.mu.Unlock()
+	tab, ok := bt.tabs[normalizeExecutableName(info.Exe)]
+	if !ok || (tab.PID != 0 && info.PID != 0 && tab.PID != info.PID) {
+		return
+	}
+	info.URL = tab.URL
+	info.Domain = tab.Domain
+}
+
+// websiteBlocker returns the blocklist entry blocking a host name, nil if it
+// isn't blocked, for the DNS and HTTP proxies. Snoozed entries aren't
+// blocked, and with sessionsOnly nothing is outside a session's focus phase;
+// store and session may be nil
+func websiteBlocker(bm *BlocklistManager, store *StateStore, session sessionSource, sessionsOnly bool) func(host string) *BlockedApp {
+	return func(host string) *BlockedApp {
+		if sessionsOnly && (session == nil || session.Status().State != SessionFocusing) {
+			return nil
+		}
+		app := bm.MatchDomain(host)
+		if app == nil || (store != nil && !store.SnoozedUntil(app.ExecutableName, time.Now()).IsZero()) {
+			return nil
+		}
+		return app
+	}
+}
+
+// domainOf returns the domain of a web page's URL, "" for other pages like
+// about:blank or the browser's own settings
+func domainOf(rawURL string) string {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+		return ""
+	}
+	domain, err := normalizeDomain(parsed.Hostname())
+	if err != nil {
+		return ""
+	}
+	return domain
+}
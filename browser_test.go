@@ -0,0 +1,47 @@
+package main
+
+import "testing"
+
+// TestDomainOf tests extracting the domain of web pages only
+func TestDomainOf(t *testing.T) {
+	tests := map[string]string{
+		"https://www.youtube.com/watch?v=1": "youtube.com",
+		"http://News.ycombinator.com:8080/": "news.ycombinator.com",
+		"about:blank":                       "",
+		"chrome://settings/":                "",
+		"file:///home/me/notes.html":        "",
+		"":                                  "",
+	}
+	for url, want := range tests {
+		if got := domainOf(url); got != want {
+			t.Errorf("domainOf(%q) = %q, want %q", url, got, want)
+		}
+	}
+}
+
+// TestBrowserTabsAnnotate tests that a reported tab is added to the windows
+// of the same browser instance only
+func TestBrowserTabsAnnotate(t *testing.T) {
+	tabs := NewBrowserTabs()
+	tab := tabs.Report(BrowserTab{Browser: "Firefox", PID: 100, URL: "https://www.reddit.com/r/golang"})
+	if tab.Browser != "firefox" || tab.Domain != "reddit.com" {
+		t.Fatalf("reported tab = %+v", tab)
+	}
+
+	window := WindowInfo{Exe: "firefox", PID: 100}
+	tabs.annotate(&window)
+	if window.Domain != "reddit.com" || window.URL != "https://www.reddit.com/r/golang" {
+		t.Errorf("annotated window = %+v", window)
+	}
+
+	restarted := WindowInfo{Exe: "firefox", PID: 200}
+	tabs.annotate(&restarted)
+	if restarted.Domain != "" {
+		t.Errorf("a restarted browser got the old tab: %+v", restarted)
+	}
+	other := WindowInfo{Exe: "code", PID: 100}
+	tabs.annotate(&other)
+	if other.Domain != "" {
+		t.Errorf("another app got the tab: %+v", other)
+	}
+}
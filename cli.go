@@ -0,0 +1,811 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// cliInput is where interactive commands read from
+var cliInput io.Reader = os.Stdin
+
+// cliCommands are the subcommands that make the binary act as a client
+var cliCommands = map[string]bool{
+	"status":  true,
+	"block":   true,
+	"history": true,
+	"session": true,
+	"lock":    true,
+	"pending": true,
+	"unlock":  true,
+	"quit":    true,
+	"partner": true,
+	"browser": true,
+	"dns":     true,
+	"proxy":   true,
+	"help":    true,
+}
+
+// isCLICommand reports whether arg selects client mode instead of starting the GUI
+func isCLICommand(arg string) bool {
+	return cliCommands[arg]
+}
+
+// runCLI executes a subcommand against the running instance and returns the exit code
+func runCLI(args []string) int {
+	return runCLIWithOutput(args, os.Stdout, os.Stderr)
+}
+
+// runCLIWithOutput is runCLI with injectable output streams
+func runCLIWithOutput(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 || args[0] == "help" {
+		printCLIUsage(stdout)
+		return 0
+	}
+
+	// Installing the native messaging host only writes files
+	if args[0] == "browser" && len(args) > 1 && args[1] != "status" {
+		if err := runBrowserSetupCommand(args[1:], stdout); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	// Pointing the system resolver or proxy at sybr doesn't involve the instance
+	if args[0] == "proxy" && len(args) > 1 && args[1] != "attempts" {
+		if err := runProxySetupCommand(args[1:], stdout); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+	if args[0] == "dns" {
+		if err := runDNSSetupCommand(args[1:], stdout); err != nil {
+			fmt.Fprintf(stderr, "Error: %v\n", err)
+			return 1
+		}
+		return 0
+	}
+
+	client, err := DialIPC(defaultIPCAddress())
+	if err != nil {
+		fmt.Fprintf(stderr, "sybr is not running (%v)\n", err)
+		return 1
+	}
+	defer client.Close()
+
+	if err := dispatchCLI(client, args, stdout); err != nil {
+		fmt.Fprintf(stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// dispatchCLI maps a subcommand to its IPC method and prints the result
+func dispatchCLI(client *IPCClient, args []string, out io.Writer) error {
+	switch args[0] {
+	case "status":
+		var status StatusInfo
+		if err := client.Call("status", nil, &status); err != nil {
+			return err
+		}
+		printStatus(out, status)
+		return nil
+
+	case "block":
+		return runBlockCommand(client, args[1:], out)
+
+	case "history":
+		return runHistoryCommand(client, args[1:], out)
+
+	case "session":
+		return runSessionCommand(client, args[1:], out)
+
+	case "lock":
+		return runLockCommand(client, args[1:], out)
+
+	case "pending":
+		return runPendingCommand(client, args[1:], out)
+
+	case "unlock":
+		return runUnlockCommand(client, out)
+
+	case "partner":
+		return runPartnerCommand(client, args[1:], out)
+
+	case "browser":
+		var tabs []BrowserTab
+		if err := client.Call("browser.tabs", nil, &tabs); err != nil {
+			return err
+		}
+		if len(tabs) == 0 {
+			fmt.Fprintln(out, "No browser has reported a tab (is the extension installed?)")
+			return nil
+		}
+		for _, tab := range tabs {
+			fmt.Fprintf(out, "%-12s %s  %s\n", tab.Browser, tab.Time.Local().Format("15:04:05"), tab.URL)
+		}
+		return nil
+
+	case "proxy":
+		var attempts []DistractionAttempt
+		if err := client.Call("proxy.attempts", nil, &attempts); err != nil {
+			return err
+		}
+		if len(attempts) == 0 {
+			fmt.Fprintln(out, "The HTTP proxy hasn't blocked anything")
+			return nil
+		}
+		for _, attempt := range attempts {
+			fmt.Fprintf(out, "%s  %-7s %s (%s)\n", attempt.Time.Local().Format("2006-01-02 15:04:05"),
+				attempt.Method, attempt.Host, attempt.ExecutableName)
+		}
+		return nil
+
+	case "quit":
+		err := callWithAuth(client, out, "quit", func(auth cliAuth) interface{} {
+			return tokenParams{Token: auth.Token}
+		}, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "sybr is quitting")
+		return nil
+	}
+	return fmt.Errorf("unknown command '%s'", args[0])
+}
+
+// runBlockCommand handles `sybr block add|domain|remove|list|snooze|scope|pin|platforms`
+func runBlockCommand(client *IPCClient, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: sybr block add|domain|remove|list|snooze|scope|pin|platforms")
+	}
+
+	switch args[0] {
+	case "add":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: sybr block add <executable> [display name]")
+		}
+		params := blockParams{
+			ExecutableName: args[1],
+			DisplayName:    strings.Join(args[2:], " "),
+		}
+		err := callWithAuth(client, out, "block.add", func(auth cliAuth) interface{} {
+			params.Token = auth.Token
+			return params
+		}, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Blocked %s\n", args[1])
+		return nil
+
+	case "domain":
+		if len(args) < 2 {
+			return fmt.Errorf("usage: sybr block domain <domain> [display name]")
+		}
+		params := domainParams{
+			Domain:      args[1],
+			DisplayName: strings.Join(args[2:], " "),
+		}
+		err := callWithAuth(client, out, "block.domain", func(auth cliAuth) interface{} {
+			params.Token = auth.Token
+			return params
+		}, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Blocked %s in browsers with the sybr extension\n", args[1])
+		return nil
+
+	case "remove", "rm":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: sybr block remove <executable>")
+		}
+		var queued *PendingChange
+		err := callWithAuth(client, out, "block.remove", func(auth cliAuth) interface{} {
+			return blockParams{ExecutableName: args[1], Token: auth.Token, PartnerCode: auth.PartnerCode}
+		}, &queued)
+		if err != nil {
+			return err
+		}
+		if queued != nil {
+			fmt.Fprintf(out, "Removal of %s queued until %s (cancel with: sybr pending cancel %s)\n",
+				queued.ExecutableName, queued.EffectiveAt.Local().Format("Jan 2 15:04"), queued.ID)
+			return nil
+		}
+		fmt.Fprintf(out, "Unblocked %s\n", args[1])
+		return nil
+
+	case "snooze":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: sybr block snooze <executable> <duration|0>")
+		}
+		err := callWithAuth(client, out, "block.snooze", func(auth cliAuth) interface{} {
+			return snoozeParams{ExecutableName: args[1], Duration: args[2], Token: auth.Token, PartnerCode: auth.PartnerCode}
+		}, nil)
+		if err != nil {
+			return err
+		}
+		if args[2] == "0" {
+			fmt.Fprintf(out, "Snooze for %s ended\n", args[1])
+		} else {
+			fmt.Fprintf(out, "Snoozed %s for %s\n", args[1], args[2])
+		}
+		return nil
+
+	case "scope":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: sybr block scope <executable> process|tree|launched")
+		}
+		var queued *PendingChange
+		err := callWithAuth(client, out, "block.scope", func(auth cliAuth) interface{} {
+			return scopeParams{ExecutableName: args[1], Scope: args[2], Token: auth.Token, PartnerCode: auth.PartnerCode}
+		}, &queued)
+		if err != nil {
+			return err
+		}
+		if queued != nil {
+			fmt.Fprintf(out, "Narrowing %s to %s queued until %s (cancel with: sybr pending cancel %s)\n",
+				queued.ExecutableName, args[2], queued.EffectiveAt.Local().Format("Jan 2 15:04"), queued.ID)
+			return nil
+		}
+		fmt.Fprintf(out, "Scope of %s set to %s\n", args[1], args[2])
+		return nil
+
+	case "platforms":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: sybr block platforms <executable> <windows,linux|all>")
+		}
+		platforms := []string{}
+		if args[2] != "all" {
+			platforms = strings.Split(args[2], ",")
+		}
+		var queued *PendingChange
+		err := callWithAuth(client, out, "block.platforms", func(auth cliAuth) interface{} {
+			return platformsParams{ExecutableName: args[1], Platforms: platforms, Token: auth.Token, PartnerCode: auth.PartnerCode}
+		}, &queued)
+		if err != nil {
+			return err
+		}
+		if queued != nil {
+			fmt.Fprintf(out, "Limiting %s to %s queued until %s (cancel with: sybr pending cancel %s)\n",
+				queued.ExecutableName, args[2], queued.EffectiveAt.Local().Format("Jan 2 15:04"), queued.ID)
+			return nil
+		}
+		fmt.Fprintf(out, "%s is now blocked on %s\n", args[1], args[2])
+		return nil
+
+	case "pin":
+		if len(args) != 3 {
+			return fmt.Errorf("usage: sybr block pin <executable> <path|sha256>")
+		}
+		// The path is resolved by the running sybr, which may have another working directory
+		target := args[2]
+		if _, err := normalizeHash(target); err != nil {
+			if target, err = filepath.Abs(target); err != nil {
+				return err
+			}
+		}
+		err := callWithAuth(client, out, "block.pin", func(auth cliAuth) interface{} {
+			return pinParams{ExecutableName: args[1], Target: target, Token: auth.Token}
+		}, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Pinned %s to %s\n", args[2], args[1])
+		return nil
+
+	case "list", "ls":
+		var apps []BlockedApp
+		if err := client.Call("block.list", nil, &apps); err != nil {
+			return err
+		}
+		if len(apps) == 0 {
+			fmt.Fprintln(out, "No apps blocked")
+			return nil
+		}
+		for _, app := range apps {
+			name := app.DisplayName
+			if app.Kind == KindDomain {
+				name += " (website)"
+			}
+			if app.Scope != ScopeProcess {
+				name += " (" + app.Scope + ")"
+			}
+			if len(app.Platforms) > 0 {
+				name += " {" + strings.Join(app.Platforms, ",") + "}"
+			}
+			if len(app.Hashes) > 0 {
+				name += fmt.Sprintf(" [%d pinned]", len(app.Hashes))
+			}
+			fmt.Fprintf(out, "%-30s %s\n", app.ExecutableName, name)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown block command '%s'", args[0])
+}
+
+// runHistoryCommand handles `sybr history [--today] [--since 2h] [--json]`
+func runHistoryCommand(client *IPCClient, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("history", flag.ContinueOnError)
+	fs.SetOutput(out)
+	today := fs.Bool("today", false, "only show entries since midnight")
+	since := fs.Duration("since", 0, "only show entries newer than this (e.g. 2h)")
+	asJSON := fs.Bool("json", false, "print raw JSON")
+	projects := fs.Bool("projects", false, "show the time spent per project instead")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	params := historyParams{}
+	now := time.Now()
+	if *today {
+		params.Since = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	}
+	if *since > 0 {
+		params.Since = now.Add(-*since)
+	}
+
+	var entries []HistoryEntry
+	if err := client.Call("history", params, &entries); err != nil {
+		return err
+	}
+
+	if *projects {
+		return printProjectTotals(out, projectTotals(entries, now), *asJSON)
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(entries)
+	}
+	if len(entries) == 0 {
+		fmt.Fprintln(out, "No history recorded")
+		return nil
+	}
+	for _, entry := range entries {
+		exe := entry.Exe
+		if entry.AppID != "" {
+			exe = entry.AppID
+		}
+		if entry.Launcher != "" {
+			exe += " via " + entry.Launcher
+		}
+		if entry.Foreground.PID != 0 {
+			exe += " > " + entry.Foreground.name()
+		}
+		if entry.Domain != "" {
+			exe += " @ " + entry.Domain
+		}
+		if entry.Project != "" {
+			exe += " in " + entry.Project
+		}
+		fmt.Fprintf(out, "%s  [%s] %s\n", entry.Time.Local().Format("2006-01-02 15:04:05"), exe, entry.Title)
+	}
+	return nil
+}
+
+// printProjectTotals prints the time spent per project
+func printProjectTotals(out io.Writer, totals []ProjectTotal, asJSON bool) error {
+	if asJSON {
+		encoder := json.NewEncoder(out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(totals)
+	}
+	if len(totals) == 0 {
+		fmt.Fprintln(out, "No project time recorded (is an editor plugin sending heartbeats?)")
+		return nil
+	}
+	for _, total := range totals {
+		fmt.Fprintf(out, "%8s  %s\n", total.Duration.Round(time.Minute), total.Project)
+	}
+	return nil
+}
+
+// runSessionCommand handles `sybr session start [50m]|stop|skip|status`
+func runSessionCommand(client *IPCClient, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		args = []string{"status"}
+	}
+
+	var status SessionStatus
+	switch args[0] {
+	case "start":
+		params := sessionParams{}
+		if len(args) > 1 {
+			params.Duration = args[1]
+		}
+		if err := client.Call("session.start", params, &status); err != nil {
+			return err
+		}
+
+	case "stop":
+		if err := client.Call("session.stop", nil, nil); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Session stopped")
+		return nil
+
+	case "skip":
+		if err := client.Call("session.skip", nil, &status); err != nil {
+			return err
+		}
+
+	case "status":
+		if err := client.Call("session.status", nil, &status); err != nil {
+			return err
+		}
+
+	default:
+		return fmt.Errorf("unknown session command '%s'", args[0])
+	}
+	fmt.Fprintf(out, "Session:      %s\n", formatSessionStatus(status))
+	return nil
+}
+
+// runLockCommand handles `sybr lock <duration>|status`
+func runLockCommand(client *IPCClient, args []string, out io.Writer) error {
+	var status LockStatus
+	if len(args) > 0 && args[0] == "lift" {
+		err := callWithAuth(client, out, "lock.lift", func(auth cliAuth) interface{} {
+			return codeParams{Code: auth.PartnerCode}
+		}, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Lock lifted")
+		return nil
+	}
+	if len(args) == 0 || args[0] == "status" {
+		if err := client.Call("lock.status", nil, &status); err != nil {
+			return err
+		}
+	} else {
+		if err := client.Call("lock", lockParams{Duration: args[0]}, &status); err != nil {
+			return err
+		}
+	}
+	fmt.Fprintf(out, "Lock:         %s\n", formatLockStatus(status))
+	return nil
+}
+
+// runPartnerCommand handles `sybr partner status|setup|confirm <code>|remove`
+func runPartnerCommand(client *IPCClient, args []string, out io.Writer) error {
+	if len(args) == 0 || args[0] == "status" {
+		var status PartnerStatus
+		if err := client.Call("partner.status", nil, &status); err != nil {
+			return err
+		}
+		switch {
+		case status.Enabled:
+			fmt.Fprintln(out, "Partner:      set up")
+		case status.Pending:
+			fmt.Fprintln(out, "Partner:      waiting for the first code (sybr partner confirm <code>)")
+		default:
+			fmt.Fprintln(out, "Partner:      none")
+		}
+		return nil
+	}
+
+	switch args[0] {
+	case "setup":
+		var setup PartnerSetup
+		err := callWithAuth(client, out, "partner.setup", func(auth cliAuth) interface{} {
+			return codeParams{Code: auth.PartnerCode, Token: auth.Token}
+		}, &setup)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Have your accountability partner scan this with their authenticator app.")
+		fmt.Fprintln(out, "It is shown only once, so don't keep a copy yourself.")
+		fmt.Fprintln(out)
+		if qr, err := qrcode.New(setup.URI, qrcode.Medium); err == nil {
+			fmt.Fprintln(out, qr.ToSmallString(false))
+		}
+		fmt.Fprintln(out, setup.URI)
+		fmt.Fprintln(out)
+		fmt.Fprintln(out, "Then finish with: sybr partner confirm <code from their app>")
+		return nil
+
+	case "confirm":
+		if len(args) != 2 {
+			return fmt.Errorf("usage: sybr partner confirm <code>")
+		}
+		if err := client.Call("partner.confirm", codeParams{Code: args[1]}, nil); err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Accountability partner set up")
+		return nil
+
+	case "remove":
+		err := callWithAuth(client, out, "partner.remove", func(auth cliAuth) interface{} {
+			return codeParams{Code: auth.PartnerCode}
+		}, nil)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, "Accountability partner removed")
+		return nil
+	}
+	return fmt.Errorf("unknown partner command '%s'", args[0])
+}
+
+// runPendingCommand handles `sybr pending [list]|cancel <id>`
+func runPendingCommand(client *IPCClient, args []string, out io.Writer) error {
+	if len(args) > 0 && args[0] == "cancel" {
+		if len(args) != 2 {
+			return fmt.Errorf("usage: sybr pending cancel <id>")
+		}
+		if err := client.Call("pending.cancel", pendingParams{ID: args[1]}, nil); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Cancelled %s\n", args[1])
+		return nil
+	}
+	if len(args) > 0 && args[0] != "list" && args[0] != "ls" {
+		return fmt.Errorf("unknown pending command '%s'", args[0])
+	}
+
+	var pending []PendingChange
+	if err := client.Call("pending.list", nil, &pending); err != nil {
+		return err
+	}
+	if len(pending) == 0 {
+		fmt.Fprintln(out, "No pending changes")
+		return nil
+	}
+	for _, change := range pending {
+		fmt.Fprintf(out, "%s  %-16s %-30s %s\n", change.ID, change.Kind, change.ExecutableName,
+			change.EffectiveAt.Local().Format("Jan 2 15:04"))
+	}
+	return nil
+}
+
+// runUnlockCommand handles `sybr unlock`: it shows the emergency passage and
+// sends back what the user typed
+func runUnlockCommand(client *IPCClient, out io.Writer) error {
+	var challenge EmergencyChallenge
+	if err := client.Call("unlock.start", nil, &challenge); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Type the following passage exactly, then press Enter:")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, challenge.Passage)
+	fmt.Fprintln(out)
+
+	reader := bufio.NewReader(cliInput)
+	typed, err := reader.ReadString('\n')
+	if err != nil && typed == "" {
+		return fmt.Errorf("no passage entered")
+	}
+	typed = strings.TrimRight(typed, "\r\n")
+	if err := client.Call("unlock.submit", unlockParams{ID: challenge.ID, Typed: typed}, nil); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "Lock lifted. This emergency unlock was logged.")
+	return nil
+}
+
+// cliAuth is what the running instance may ask for before a gated action
+type cliAuth struct {
+	Token       string // From auth.login
+	PartnerCode string // From the accountability partner's authenticator app
+}
+
+// runBrowserSetupCommand handles `sybr browser install [extension id..]|uninstall`
+func runBrowserSetupCommand(args []string, out io.Writer) error {
+	switch args[0] {
+	case "install":
+		exePath, err := getExecutablePath()
+		if err != nil {
+			return err
+		}
+		browsers, err := installNativeHost(exePath, args[1:])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Native messaging host installed for %s\n", strings.Join(browsers, ", "))
+		fmt.Fprintln(out, "Load the extension from the browser-extension folder to report tabs")
+		return nil
+
+	case "uninstall":
+		browsers, err := uninstallNativeHost()
+		if err != nil {
+			return err
+		}
+		if len(browsers) == 0 {
+			fmt.Fprintln(out, "The native messaging host wasn't installed")
+			return nil
+		}
+		fmt.Fprintf(out, "Native messaging host removed from %s\n", strings.Join(browsers, ", "))
+		return nil
+	}
+	return fmt.Errorf("usage: sybr browser install [extension id..]|uninstall|status")
+}
+
+// runDNSSetupCommand handles `sybr dns setup [listen]|restore`
+func runDNSSetupCommand(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: sybr dns setup [listen address]|restore")
+	}
+	switch args[0] {
+	case "setup":
+		config := DefaultSettings().DNS
+		if sm, err := GetSettingsManager(); err == nil {
+			config = sm.Get().DNS
+		}
+		if len(args) > 1 {
+			config.Listen = args[1]
+		}
+		if err := setupSystemDNS(config.listen(), out); err != nil {
+			return err
+		}
+		if !config.Enabled {
+			fmt.Fprintln(out, "Set dns.enabled in settings.json and restart sybr to start the proxy")
+		}
+		return nil
+
+	case "restore":
+		return restoreSystemDNS(out)
+	}
+	return fmt.Errorf("usage: sybr dns setup [listen address]|restore")
+}
+
+// runProxySetupCommand handles `sybr proxy setup [listen]|restore`
+func runProxySetupCommand(args []string, out io.Writer) error {
+	switch args[0] {
+	case "setup":
+		config := DefaultSettings().HTTPProxy
+		if sm, err := GetSettingsManager(); err == nil {
+			config = sm.Get().HTTPProxy
+		}
+		if len(args) > 1 {
+			config.Listen = args[1]
+		}
+		if err := setupSystemProxy("http://"+config.listen()+pacPath, out); err != nil {
+			return err
+		}
+		if !config.Enabled {
+			fmt.Fprintln(out, "Set httpProxy.enabled in settings.json and restart sybr to start the proxy")
+		}
+		return nil
+
+	case "restore":
+		return restoreSystemProxy(out)
+	}
+	return fmt.Errorf("usage: sybr proxy setup [listen address]|restore|attempts")
+}
+
+// callWithAuth calls method and, while the instance asks for the admin
+// password or a partner code, prompts for it on cliInput and retries.
+// params builds the params for what was collected so far
+func callWithAuth(client *IPCClient, out io.Writer, method string, params func(auth cliAuth) interface{}, result interface{}) error {
+	var auth cliAuth
+	reader := bufio.NewReader(cliInput)
+	for {
+		err := client.Call(method, params(auth), result)
+		var ipcErr *IPCError
+		if !errors.As(err, &ipcErr) {
+			return err
+		}
+
+		switch {
+		case ipcErr.Code == ipcErrUnauthorized && auth.Token == "":
+			password, err := promptLine(reader, out, "Admin password: ")
+			if err != nil {
+				return err
+			}
+			var token AuthToken
+			if err := client.Call("auth.login", authParams{Password: password}, &token); err != nil {
+				return err
+			}
+			auth.Token = token.Token
+
+		case ipcErr.Code == ipcErrPartnerCode && auth.PartnerCode == "":
+			code, err := promptLine(reader, out, "Code from your accountability partner: ")
+			if err != nil {
+				return err
+			}
+			auth.PartnerCode = code
+
+		default:
+			return err
+		}
+	}
+}
+
+// promptLine prints prompt and reads one line from reader
+func promptLine(reader *bufio.Reader, out io.Writer, prompt string) (string, error) {
+	fmt.Fprint(out, prompt)
+	line, err := reader.ReadString('\n')
+	fmt.Fprintln(out)
+	line = strings.TrimRight(line, "\r\n")
+	if err != nil && line == "" {
+		return "", fmt.Errorf("nothing entered")
+	}
+	return line, nil
+}
+
+// formatLockStatus describes the commitment lock in one line
+func formatLockStatus(status LockStatus) string {
+	if !status.Locked {
+		return "off"
+	}
+	return "locked " + status.Description
+}
+
+// formatSessionStatus describes a session in one line, e.g. "focusing 1/4, 12:30 left"
+func formatSessionStatus(status SessionStatus) string {
+	if status.State == SessionIdle {
+		return "idle"
+	}
+	cycle := fmt.Sprintf("%d", status.Cycle)
+	if status.TotalCycles > 0 {
+		cycle = fmt.Sprintf("%d/%d", status.Cycle, status.TotalCycles)
+	}
+	return fmt.Sprintf("%s %s, %d:%02d left", status.State, cycle,
+		status.RemainingSeconds/60, status.RemainingSeconds%60)
+}
+
+// printStatus prints the "status" result in a human readable form
+func printStatus(out io.Writer, status StatusInfo) {
+	monitoring := "stopped"
+	if status.Monitoring {
+		monitoring = "running"
+	}
+	fmt.Fprintf(out, "sybr running (pid %d)\n", status.PID)
+	fmt.Fprintf(out, "Monitoring:   %s\n", monitoring)
+	fmt.Fprintf(out, "Blocked apps: %d\n", status.BlockedApps)
+	if status.CurrentWindow != nil {
+		fmt.Fprintf(out, "Active:       [%s] %s\n", status.CurrentWindow.Exe, status.CurrentWindow.Title)
+	}
+	if status.Session != nil {
+		fmt.Fprintf(out, "Session:      %s\n", formatSessionStatus(*status.Session))
+	}
+	fmt.Fprintf(out, "Lock:         %s\n", formatLockStatus(status.Lock))
+}
+
+// printCLIUsage prints the list of supported subcommands
+func printCLIUsage(out io.Writer) {
+	fmt.Fprintln(out, `Usage: sybr [command]
+
+Without a command the GUI is started. Commands talk to the running instance:
+
+  status                          Show monitoring state and the active window
+  block add <exe> [display name]  Add an app to the blocklist
+  block domain <domain> [name]    Block a website in browsers with the extension
+  block remove <exe>              Remove an app from the blocklist
+  block list                      List blocked apps
+  block snooze <exe> <30m|0>      Pause warnings for an app (0 ends the snooze)
+  block scope <exe> <scope>       Also block what an app launches (process, tree, launched)
+  block pin <exe> <path|sha256>   Block a binary under any name by its hash
+  block platforms <exe> <os,..>   Only block an app on some OSes (windows, linux, darwin, all)
+  history [--today] [--since 2h]  Show recorded window changes
+  history --projects [--today]    Show the time spent per editor project
+  session start [50m]             Start a focus session
+  session stop|skip|status        Stop, skip the current phase or show it
+  lock <2h>|status                Refuse weakening the blocklist for a while
+  lock lift                       Lift the lock with your partner's code
+  pending [cancel <id>]           List or cancel delayed removals
+  unlock                          Lift the lock by typing a passage (logged)
+  partner setup|confirm <code>    Let an accountability partner hold the unlock codes
+  partner status|remove           Show or remove the partner (remove needs a code)
+  browser install [extension id]  Install the native messaging host for browsers
+  browser uninstall|status        Remove it or show the tabs browsers reported
+  dns setup [listen]|restore      Point the system resolver at the DNS proxy or undo it
+  proxy setup [listen]|restore    Point the system proxy at the HTTP proxy's PAC file or undo it
+  proxy attempts                  List the websites the HTTP proxy refused
+  quit                            Quit the running instance
+  help                            Show this help`)
+}
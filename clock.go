@@ -0,0 +1,26 @@
+package main
+
+import "time"
+
+// Clock abstracts time so timer driven code (sessions, locks) can be tested
+// without sleeping
+type Clock interface {
+	Now() time.Time
+	AfterFunc(d time.Duration, f func()) Timer
+}
+
+// Timer is a stoppable pending callback created by Clock.AfterFunc
+type Timer interface {
+	Stop() bool
+}
+
+// realClock is the Clock backed by the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+func (realClock) AfterFunc(d time.Duration, f func()) Timer {
+	return time.AfterFunc(d, f)
+}
@@ -0,0 +1,376 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// DNSResponseNXDomain answers blocked names with "no such domain"
+	DNSResponseNXDomain = "nxdomain"
+	// DNSResponseSinkhole answers blocked names with 0.0.0.0 and ::
+	DNSResponseSinkhole = "sinkhole"
+
+	// dnsBlockedTTL keeps blocked answers from being cached long past a session
+	dnsBlockedTTL = 10
+	// dnsUpstreamTimeout is how long one upstream resolver gets to answer
+	dnsUpstreamTimeout = 3 * time.Second
+	// dnsClientTimeout closes idle TCP connections from clients
+	dnsClientTimeout = 10 * time.Second
+	// dnsLogInterval limits how often the same blocked name is logged
+	dnsLogInterval = time.Minute
+	// maxDNSMessageSize is the largest message DNS can carry
+	maxDNSMessageSize = 65535
+)
+
+// defaultDNSUpstream is forwarded to when neither the settings nor the system
+// name a resolver, e.g. because the system already points at the proxy
+var defaultDNSUpstream = []string{"1.1.1.1:53", "9.9.9.9:53"}
+
+// DNSConfig configures the local DNS proxy in settings.json
+type DNSConfig struct {
+	Enabled      bool     `json:"enabled"`
+	Listen       string   `json:"listen,omitempty"`       // "" = 127.0.0.1:5300 on Linux, 127.0.0.1:53 on Windows
+	Upstream     []string `json:"upstream,omitempty"`     // Resolvers to forward to, "" = the system's
+	Response     string   `json:"response,omitempty"`     // "nxdomain" (default) or "sinkhole"
+	SessionsOnly bool     `json:"sessionsOnly,omitempty"` // Only block while a focus session is in its focus phase
+}
+
+// listen returns the listen address, falling back to the default
+func (c DNSConfig) listen() string {
+	if c.Listen == "" {
+		return defaultDNSListen
+	}
+	return c.Listen
+}
+
+// response returns the configured response, falling back to NXDOMAIN
+func (c DNSConfig) response() string {
+	if c.Response == DNSResponseSinkhole {
+		return DNSResponseSinkhole
+	}
+	return DNSResponseNXDomain
+}
+
+// upstream returns the resolvers to forward to: the configured ones, else the
+// system's, else public ones. The proxy's own address is never included
+func (c DNSConfig) upstream() []string {
+	servers := c.Upstream
+	if len(servers) == 0 {
+		servers = systemResolvers()
+	}
+	var upstream []string
+	for _, server := range servers {
+		server = dnsServerAddress(server)
+		if server != c.listen() && !containsString(upstream, server) {
+			upstream = append(upstream, server)
+		}
+	}
+	if len(upstream) == 0 {
+		return defaultDNSUpstream
+	}
+	return upstream
+}
+
+// dnsServerAddress adds the DNS port to a resolver given as a bare IP
+func dnsServerAddress(server string) string {
+	server = strings.TrimSpace(server)
+	if _, _, err := net.SplitHostPort(server); err == nil {
+		return server
+	}
+	return net.JoinHostPort(strings.Trim(server, "[]"), "53")
+}
+
+// DNSProxy is a DNS forwarder that answers names of blocked websites itself,
+// so they are blocked in every browser and app without the extension.
+// Subdomains of a blocked domain are blocked too
+type DNSProxy struct {
+	config   DNSConfig
+	upstream []string
+	blocked  func(name string) *BlockedApp
+	now      func() time.Time
+
+	mu     sync.Mutex
+	udp    net.PacketConn
+	tcp    net.Listener
+	logged map[string]time.Time // Blocked name -> when it was last logged
+	wg     sync.WaitGroup
+}
+
+// NewDNSProxy creates a proxy blocking the blocklist's websites in the active
+// profile, skipping snoozed ones; store and session may be nil
+func NewDNSProxy(config DNSConfig, bm *BlocklistManager, store *StateStore, session sessionSource) *DNSProxy {
+	return &DNSProxy{
+		config:   config,
+		upstream: config.upstream(),
+		blocked:  websiteBlocker(bm, store, session, config.SessionsOnly),
+		now:      time.Now,
+		logged:   map[string]time.Time{},
+	}
+}
+
+// Start listens for queries over UDP and TCP
+func (p *DNSProxy) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.udp != nil {
+		return nil
+	}
+	udp, err := net.ListenPacket("udp", p.config.listen())
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", p.config.listen(), err)
+	}
+	// With port 0, e.g. in tests, TCP uses the port UDP got
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		udp.Close()
+		return fmt.Errorf("failed to listen on %s: %w", p.config.listen(), err)
+	}
+	p.udp, p.tcp = udp, tcp
+
+	p.wg.Add(2)
+	go p.serveUDP(udp)
+	go p.serveTCP(tcp)
+	fmt.Printf("🧭 DNS proxy listening on %s (forwarding to %s, blocked names get %s)\n",
+		udp.LocalAddr(), strings.Join(p.upstream, ", "), p.config.response())
+	return nil
+}
+
+// Stop closes the listeners and waits for the servers to finish
+func (p *DNSProxy) Stop() {
+	p.mu.Lock()
+	udp, tcp := p.udp, p.tcp
+	p.udp, p.tcp = nil, nil
+	p.mu.Unlock()
+	if udp == nil {
+		return
+	}
+	udp.Close()
+	tcp.Close()
+	p.wg.Wait()
+}
+
+// Addr returns the address the proxy listens on, "" when it isn't running
+func (p *DNSProxy) Addr() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.udp == nil {
+		return ""
+	}
+	return p.udp.LocalAddr().String()
+}
+
+func (p *DNSProxy) serveUDP(conn net.PacketConn) {
+	defer p.wg.Done()
+	buf := make([]byte, maxDNSMessageSize)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return
+		}
+		if err != nil {
+			continue
+		}
+		query := append([]byte(nil), buf[:n]...)
+		// Forwarding can take a while, so one slow name doesn't hold up others
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			if reply := p.answer(query, false); reply != nil {
+				conn.WriteTo(reply, addr)
+			}
+		}()
+	}
+}
+
+func (p *DNSProxy) serveTCP(listener net.Listener) {
+	defer p.wg.Done()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			}
+			continue
+		}
+		p.wg.Add(1)
+		go func() {
+			defer p.wg.Done()
+			defer conn.Close()
+			p.serveTCPConn(conn)
+		}()
+	}
+}
+
+// serveTCPConn answers length-prefixed queries until the client is done
+func (p *DNSProxy) serveTCPConn(conn net.Conn) {
+	for {
+		conn.SetDeadline(time.Now().Add(dnsClientTimeout))
+		query, err := readTCPMessage(conn)
+		if err != nil {
+			return
+		}
+		reply := p.answer(query, true)
+		if reply == nil || writeTCPMessage(conn, reply) != nil {
+			return
+		}
+	}
+}
+
+// answer returns the reply to a query: a blocked answer for blocked names,
+// otherwise what the upstream resolver said. Garbage gets no reply
+func (p *DNSProxy) answer(query []byte, tcp bool) []byte {
+	var parser dnsmessage.Parser
+	header, err := parser.Start(query)
+	if err != nil || header.Response {
+		return nil
+	}
+	question, err := parser.Question()
+	if err != nil {
+		return dnsReply(header, nil, dnsmessage.RCodeFormatError, nil)
+	}
+
+	name := strings.TrimSuffix(strings.ToLower(question.Name.String()), ".")
+	if app := p.blocked(name); app != nil {
+		p.logBlocked(name, app)
+		return p.blockedReply(header, question)
+	}
+
+	reply, err := p.forward(query, tcp)
+	if err != nil {
+		fmt.Printf("⚠️  DNS: failed to resolve %s: %v\n", name, err)
+		return dnsReply(header, &question, dnsmessage.RCodeServerFailure, nil)
+	}
+	return reply
+}
+
+// blockedReply answers a blocked name with NXDOMAIN, or in sinkhole mode with
+// an unroutable address for A and AAAA and no records for other types
+func (p *DNSProxy) blockedReply(header dnsmessage.Header, question dnsmessage.Question) []byte {
+	if p.config.response() == DNSResponseNXDomain {
+		return dnsReply(header, &question, dnsmessage.RCodeNameError, nil)
+	}
+	resource := dnsmessage.ResourceHeader{Name: question.Name, Class: question.Class, TTL: dnsBlockedTTL}
+	var answers []dnsmessage.Resource
+	switch question.Type {
+	case dnsmessage.TypeA:
+		resource.Type = dnsmessage.TypeA
+		answers = append(answers, dnsmessage.Resource{Header: resource, Body: &dnsmessage.AResource{}})
+	case dnsmessage.TypeAAAA:
+		resource.Type = dnsmessage.TypeAAAA
+		answers = append(answers, dnsmessage.Resource{Header: resource, Body: &dnsmessage.AAAAResource{}})
+	}
+	return dnsReply(header, &question, dnsmessage.RCodeSuccess, answers)
+}
+
+// logBlocked logs a blocked name, at most once a minute per name
+func (p *DNSProxy) logBlocked(name string, app *BlockedApp) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if last, ok := p.logged[name]; ok && now.Sub(last) < dnsLogInterval {
+		return
+	}
+	p.logged[name] = now
+	fmt.Printf("🚫 DNS: blocked %s (%s)\n", name, app.DisplayName)
+}
+
+// forward asks the upstream resolvers in turn until one answers
+func (p *DNSProxy) forward(query []byte, tcp bool) ([]byte, error) {
+	err := errors.New("no upstream resolver")
+	for _, server := range p.upstream {
+		var reply []byte
+		if reply, err = exchangeDNS(server, query, tcp); err == nil {
+			return reply, nil
+		}
+	}
+	return nil, err
+}
+
+// exchangeDNS sends query to server and returns its reply. A truncated UDP
+// reply is passed on as is so the client retries over TCP
+func exchangeDNS(server string, query []byte, tcp bool) ([]byte, error) {
+	network := "udp"
+	if tcp {
+		network = "tcp"
+	}
+	conn, err := net.DialTimeout(network, server, dnsUpstreamTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(dnsUpstreamTimeout))
+
+	if tcp {
+		if err := writeTCPMessage(conn, query); err != nil {
+			return nil, err
+		}
+		return readTCPMessage(conn)
+	}
+	if _, err := conn.Write(query); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, maxDNSMessageSize)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		// Skip stray replies to earlier queries
+		if n >= 2 && buf[0] == query[0] && buf[1] == query[1] {
+			return buf[:n], nil
+		}
+	}
+}
+
+// dnsReply builds a reply to the query with the given header and question
+func dnsReply(query dnsmessage.Header, question *dnsmessage.Question, rcode dnsmessage.RCode, answers []dnsmessage.Resource) []byte {
+	msg := dnsmessage.Message{
+		Header: dnsmessage.Header{
+			ID:                 query.ID,
+			Response:           true,
+			OpCode:             query.OpCode,
+			RecursionDesired:   query.RecursionDesired,
+			RecursionAvailable: true,
+			RCode:              rcode,
+		},
+		Answers: answers,
+	}
+	if question != nil {
+		msg.Questions = []dnsmessage.Question{*question}
+	}
+	reply, err := msg.Pack()
+	if err != nil {
+		return nil
+	}
+	return reply
+}
+
+// readTCPMessage reads one DNS message prefixed with its 16-bit length
+func readTCPMessage(r io.Reader) ([]byte, error) {
+	var length uint16
+	if err := binary.Read(r, binary.BigEndian, &length); err != nil {
+		return nil, err
+	}
+	msg := make([]byte, length)
+	if _, err := io.ReadFull(r, msg); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// writeTCPMessage writes one DNS message prefixed with its 16-bit length
+func writeTCPMessage(w io.Writer, msg []byte) error {
+	data := make([]byte, 2, 2+len(msg))
+	binary.BigEndian.PutUint16(data, uint16(len(msg)))
+	_, err := w.Write(append(data, msg...))
+	return err
+}
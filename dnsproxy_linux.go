@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// defaultDNSListen avoids port 53, which needs root; systemd-resolved can
+// forward to any port
+const defaultDNSListen = "127.0.0.1:5300"
+
+// resolvedDropIn points systemd-resolved at the proxy for all names
+const resolvedDropIn = "/etc/systemd/resolved.conf.d/sybr-dns.conf"
+
+// resolvConfPaths list the system's resolvers. systemd-resolved writes the
+// real upstream servers to the first, /etc/resolv.conf then names its stub
+var resolvConfPaths = []string{"/run/systemd/resolve/resolv.conf", "/etc/resolv.conf"}
+
+// systemResolvers returns the resolvers the system uses, leaving out local
+// ones like resolved's stub, which may forward to the proxy itself
+func systemResolvers() []string {
+	for _, path := range resolvConfPaths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		if servers := parseResolvConf(data); len(servers) > 0 {
+			return servers
+		}
+	}
+	return nil
+}
+
+// parseResolvConf returns the non-loopback nameservers of a resolv.conf
+func parseResolvConf(data []byte) []string {
+	var servers []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "nameserver" {
+			continue
+		}
+		// IPv6 link-local servers carry a zone, e.g. fe80::1%eth0
+		ip := net.ParseIP(strings.SplitN(fields[1], "%", 2)[0])
+		if ip == nil || ip.IsLoopback() {
+			continue
+		}
+		servers = append(servers, dnsServerAddress(fields[1]))
+	}
+	return servers
+}
+
+// resolvedConfig is the drop-in making systemd-resolved send every name to
+// the proxy at listen
+func resolvedConfig(listen string) string {
+	return fmt.Sprintf("# Written by `sybr dns setup`, remove with `sybr dns restore`\n[Resolve]\nDNS=%s\nDomains=~.\n", listen)
+}
+
+// setupSystemDNS points systemd-resolved at the proxy
+func setupSystemDNS(listen string, out io.Writer) error {
+	if _, err := os.Stat("/run/systemd/resolve"); err != nil {
+		host, port, _ := net.SplitHostPort(listen)
+		if port != "53" {
+			return fmt.Errorf("systemd-resolved isn't running: set dns.listen to %s:53 and point the nameserver in /etc/resolv.conf at %s", host, host)
+		}
+		return fmt.Errorf("systemd-resolved isn't running: point the nameserver in /etc/resolv.conf at %s", host)
+	}
+	if err := os.MkdirAll(filepath.Dir(resolvedDropIn), 0755); err != nil {
+		return dnsSetupError(err)
+	}
+	if err := os.WriteFile(resolvedDropIn, []byte(resolvedConfig(listen)), 0644); err != nil {
+		return dnsSetupError(err)
+	}
+	if err := restartResolved(); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "systemd-resolved now asks sybr at %s (%s)\n", listen, resolvedDropIn)
+	return nil
+}
+
+// restoreSystemDNS removes the drop-in written by setupSystemDNS
+func restoreSystemDNS(out io.Writer) error {
+	err := os.Remove(resolvedDropIn)
+	if errors.Is(err, fs.ErrNotExist) {
+		fmt.Fprintln(out, "The system resolver wasn't pointed at sybr")
+		return nil
+	}
+	if err != nil {
+		return dnsSetupError(err)
+	}
+	if err := restartResolved(); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "systemd-resolved uses its usual resolvers again")
+	return nil
+}
+
+// restartResolved makes systemd-resolved read its configuration again
+func restartResolved() error {
+	if output, err := exec.Command("systemctl", "restart", "systemd-resolved").CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to restart systemd-resolved: %v: %s", err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
+
+// dnsSetupError explains that changing the system resolver needs root
+func dnsSetupError(err error) error {
+	if errors.Is(err, fs.ErrPermission) {
+		return fmt.Errorf("changing the system resolver needs root, run it with sudo")
+	}
+	return err
+}
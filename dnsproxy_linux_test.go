@@ -0,0 +1,34 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// TestParseResolvConf tests that local nameservers like resolved's stub are
+// left out and the others get the DNS port
+func TestParseResolvConf(t *testing.T) {
+	data := []byte(`# Generated by NetworkManager
+search lan
+nameserver 127.0.0.53
+nameserver 192.168.1.1
+nameserver fe80::1%wlan0
+options edns0 trust-ad
+nameserver ::1
+`)
+	got := parseResolvConf(data)
+	want := []string{"192.168.1.1:53", "[fe80::1%wlan0]:53"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("parseResolvConf() = %v, want %v", got, want)
+	}
+}
+
+// TestResolvedConfig tests that the drop-in routes every name to the proxy
+func TestResolvedConfig(t *testing.T) {
+	config := resolvedConfig("127.0.0.1:5300")
+	for _, line := range []string{"[Resolve]", "DNS=127.0.0.1:5300", "Domains=~."} {
+		if !strings.Contains(config, line+"\n") {
+			t.Errorf("resolvedConfig() = %q, missing %q", config, line)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package main
+
+import (
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// upstreamAddress is what the fake upstream resolver answers every A query with
+var upstreamAddress = [4]byte{192, 0, 2, 1}
+
+// startFakeUpstream runs a resolver on a local port answering every query
+// over UDP and TCP, standing in for the real upstream
+func startFakeUpstream(t *testing.T) string {
+	t.Helper()
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	tcp, err := net.Listen("tcp", udp.LocalAddr().String())
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	t.Cleanup(func() {
+		udp.Close()
+		tcp.Close()
+	})
+
+	go func() {
+		buf := make([]byte, maxDNSMessageSize)
+		for {
+			n, addr, err := udp.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			udp.WriteTo(fakeUpstreamReply(buf[:n]), addr)
+		}
+	}()
+	go func() {
+		for {
+			conn, err := tcp.Accept()
+			if err != nil {
+				return
+			}
+			if query, err := readTCPMessage(conn); err == nil {
+				writeTCPMessage(conn, fakeUpstreamReply(query))
+			}
+			conn.Close()
+		}
+	}()
+	return udp.LocalAddr().String()
+}
+
+// fakeUpstreamReply answers a query with upstreamAddress
+func fakeUpstreamReply(query []byte) []byte {
+	var msg dnsmessage.Message
+	if err := msg.Unpack(query); err != nil || len(msg.Questions) == 0 {
+		return nil
+	}
+	question := msg.Questions[0]
+	resource := dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeA, Class: question.Class, TTL: 300}
+	return dnsReply(msg.Header, &question, dnsmessage.RCodeSuccess,
+		[]dnsmessage.Resource{{Header: resource, Body: &dnsmessage.AResource{A: upstreamAddress}}})
+}
+
+// fakeSession reports a session state the test sets
+type fakeSession struct {
+	mu    sync.Mutex
+	state SessionState
+}
+
+func (f *fakeSession) set(state SessionState) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.state = state
+}
+
+func (f *fakeSession) Status() SessionStatus {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return SessionStatus{State: f.state}
+}
+
+func (f *fakeSession) Config() SessionConfig { return DefaultSessionConfig() }
+
+// startTestDNSProxy runs a proxy on a local port forwarding to upstream and
+// blocking youtube.com
+func startTestDNSProxy(t *testing.T, config DNSConfig, session sessionSource) *DNSProxy {
+	t.Helper()
+	bm := &BlocklistManager{filePath: filepath.Join(t.TempDir(), "blocking_list.json")}
+	if err := bm.AddDomain("youtube.com", "YouTube"); err != nil {
+		t.Fatalf("AddDomain failed: %v", err)
+	}
+	config.Listen = "127.0.0.1:0"
+	proxy := NewDNSProxy(config, bm, nil, session)
+	if err := proxy.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	t.Cleanup(proxy.Stop)
+	return proxy
+}
+
+// queryDNS asks the resolver at server for name and returns the reply
+func queryDNS(t *testing.T, server, network, name string, qtype dnsmessage.Type) dnsmessage.Message {
+	t.Helper()
+	query := dnsmessage.Message{
+		Header:    dnsmessage.Header{ID: 4242, RecursionDesired: true},
+		Questions: []dnsmessage.Question{{Name: dnsmessage.MustNewName(name), Type: qtype, Class: dnsmessage.ClassINET}},
+	}
+	data, err := query.Pack()
+	if err != nil {
+		t.Fatalf("failed to pack query: %v", err)
+	}
+	conn, err := net.Dial(network, server)
+	if err != nil {
+		t.Fatalf("failed to dial %s: %v", server, err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	var reply []byte
+	if network == "tcp" {
+		if err := writeTCPMessage(conn, data); err != nil {
+			t.Fatalf("failed to send query: %v", err)
+		}
+		reply, err = readTCPMessage(conn)
+	} else {
+		if _, err := conn.Write(data); err != nil {
+			t.Fatalf("failed to send query: %v", err)
+		}
+		reply = make([]byte, maxDNSMessageSize)
+		var n int
+		n, err = conn.Read(reply)
+		reply = reply[:n]
+	}
+	if err != nil {
+		t.Fatalf("no reply for %s over %s: %v", name, network, err)
+	}
+
+	var msg dnsmessage.Message
+	if err := msg.Unpack(reply); err != nil {
+		t.Fatalf("malformed reply for %s: %v", name, err)
+	}
+	if msg.ID != query.ID {
+		t.Errorf("reply ID = %d, want %d", msg.ID, query.ID)
+	}
+	return msg
+}
+
+// answerA returns the address of the first A record in msg
+func answerA(msg dnsmessage.Message) ([4]byte, bool) {
+	for _, answer := range msg.Answers {
+		if a, ok := answer.Body.(*dnsmessage.AResource); ok {
+			return a.A, true
+		}
+	}
+	return [4]byte{}, false
+}
+
+// TestDNSProxyBlocksDomains tests that blocked domains and their subdomains
+// get NXDOMAIN over UDP and TCP while other names are forwarded upstream
+func TestDNSProxyBlocksDomains(t *testing.T) {
+	proxy := startTestDNSProxy(t, DNSConfig{Upstream: []string{startFakeUpstream(t)}}, nil)
+
+	for _, network := range []string{"udp", "tcp"} {
+		for _, name := range []string{"youtube.com.", "www.youtube.com.", "M.YouTube.com."} {
+			if reply := queryDNS(t, proxy.Addr(), network, name, dnsmessage.TypeA); reply.RCode != dnsmessage.RCodeNameError {
+				t.Errorf("%s over %s: rcode = %v, want NXDOMAIN", name, network, reply.RCode)
+			}
+		}
+		for _, name := range []string{"example.org.", "notyoutube.com."} {
+			reply := queryDNS(t, proxy.Addr(), network, name, dnsmessage.TypeA)
+			if a, ok := answerA(reply); reply.RCode != dnsmessage.RCodeSuccess || !ok || a != upstreamAddress {
+				t.Errorf("%s over %s: got %v %+v, want the upstream's answer", name, network, reply.RCode, reply.Answers)
+			}
+		}
+	}
+}
+
+// TestDNSProxySinkhole tests that sinkhole mode answers blocked names with
+// unroutable addresses and no records for other types
+func TestDNSProxySinkhole(t *testing.T) {
+	config := DNSConfig{Upstream: []string{startFakeUpstream(t)}, Response: DNSResponseSinkhole}
+	proxy := startTestDNSProxy(t, config, nil)
+
+	reply := queryDNS(t, proxy.Addr(), "udp", "www.youtube.com.", dnsmessage.TypeA)
+	if a, ok := answerA(reply); reply.RCode != dnsmessage.RCodeSuccess || !ok || a != [4]byte{} {
+		t.Errorf("A: got %v %+v, want 0.0.0.0", reply.RCode, reply.Answers)
+	}
+	if reply.Answers[0].Header.TTL != dnsBlockedTTL {
+		t.Errorf("TTL = %d, want %d", reply.Answers[0].Header.TTL, dnsBlockedTTL)
+	}
+
+	reply = queryDNS(t, proxy.Addr(), "udp", "youtube.com.", dnsmessage.TypeAAAA)
+	if len(reply.Answers) != 1 {
+		t.Fatalf("AAAA: got %+v, want one answer", reply.Answers)
+	}
+	if aaaa, ok := reply.Answers[0].Body.(*dnsmessage.AAAAResource); !ok || aaaa.AAAA != [16]byte{} {
+		t.Errorf("AAAA: got %+v, want ::", reply.Answers[0].Body)
+	}
+
+	reply = queryDNS(t, proxy.Addr(), "udp", "youtube.com.", dnsmessage.TypeMX)
+	if reply.RCode != dnsmessage.RCodeSuccess || len(reply.Answers) != 0 {
+		t.Errorf("MX: got %v %+v, want no records", reply.RCode, reply.Answers)
+	}
+}
+
+// TestDNSProxySessionsOnly tests that with sessionsOnly names are only
+// blocked during the focus phase of a session
+func TestDNSProxySessionsOnly(t *testing.T) {
+	session := &fakeSession{state: SessionIdle}
+	config := DNSConfig{Upstream: []string{startFakeUpstream(t)}, SessionsOnly: true}
+	proxy := startTestDNSProxy(t, config, session)
+
+	if reply := queryDNS(t, proxy.Addr(), "udp", "youtube.com.", dnsmessage.TypeA); reply.RCode != dnsmessage.RCodeSuccess {
+		t.Errorf("idle: rcode = %v, want the name forwarded", reply.RCode)
+	}
+	session.set(SessionFocusing)
+	if reply := queryDNS(t, proxy.Addr(), "udp", "youtube.com.", dnsmessage.TypeA); reply.RCode != dnsmessage.RCodeNameError {
+		t.Errorf("focusing: rcode = %v, want NXDOMAIN", reply.RCode)
+	}
+}
+
+// TestDNSProxyUpstreamDown tests that a name is answered with SERVFAIL when
+// no upstream resolver answers
+func TestDNSProxyUpstreamDown(t *testing.T) {
+	// Nothing listens on a port that was just freed
+	listener, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	down := listener.LocalAddr().String()
+	listener.Close()
+
+	proxy := startTestDNSProxy(t, DNSConfig{Upstream: []string{down}}, nil)
+	if reply := queryDNS(t, proxy.Addr(), "udp", "example.org.", dnsmessage.TypeA); reply.RCode != dnsmessage.RCodeServerFailure {
+		t.Errorf("rcode = %v, want SERVFAIL", reply.RCode)
+	}
+}
+
+// TestDNSProxyStop tests that the listeners are closed after Stop
+func TestDNSProxyStop(t *testing.T) {
+	proxy := startTestDNSProxy(t, DNSConfig{Upstream: []string{startFakeUpstream(t)}}, nil)
+	addr := proxy.Addr()
+	proxy.Stop()
+	if proxy.Addr() != "" {
+		t.Errorf("Addr() = %q after Stop, want \"\"", proxy.Addr())
+	}
+	if conn, err := net.DialTimeout("tcp", addr, time.Second); err == nil {
+		conn.Close()
+		t.Errorf("dialing %s after Stop succeeded, want connection refused", addr)
+	}
+}
+
+// TestDNSConfigUpstream tests that upstream resolvers get the DNS port and
+// that the proxy never forwards to itself
+func TestDNSConfigUpstream(t *testing.T) {
+	config := DNSConfig{Listen: "127.0.0.1:5300", Upstream: []string{"192.0.2.53", "[2001:db8::53]", "192.0.2.54:5353", "192.0.2.53:53"}}
+	want := []string{"192.0.2.53:53", "[2001:db8::53]:53", "192.0.2.54:5353"}
+	got := config.upstream()
+	if len(got) != len(want) {
+		t.Fatalf("upstream() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("upstream()[%d] = %q, want %q", i, got[i], want[i])
+		}
+	}
+
+	config.Upstream = []string{"127.0.0.1:5300"}
+	if got := config.upstream(); len(got) != len(defaultDNSUpstream) || got[0] != defaultDNSUpstream[0] {
+		t.Errorf("upstream() = %v, want the defaults when only the proxy itself is configured", got)
+	}
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os/exec"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// defaultDNSListen is the only port Windows asks resolvers on
+const defaultDNSListen = "127.0.0.1:53"
+
+// dnsAdapter is a network adapter that is up
+type dnsAdapter struct {
+	name    string   // Friendly name as netsh knows it, e.g. Wi-Fi
+	servers []string // Its DNS servers
+}
+
+// dnsAdapters returns the adapters that are up, leaving out loopback
+func dnsAdapters() ([]dnsAdapter, error) {
+	size := uint32(15000)
+	var buf []byte
+	for {
+		buf = make([]byte, size)
+		err := windows.GetAdaptersAddresses(windows.AF_UNSPEC, windows.GAA_FLAG_SKIP_ANYCAST|windows.GAA_FLAG_SKIP_MULTICAST, 0,
+			(*windows.IpAdapterAddresses)(unsafe.Pointer(&buf[0])), &size)
+		if err == nil {
+			break
+		}
+		if err != windows.ERROR_BUFFER_OVERFLOW {
+			return nil, fmt.Errorf("failed to list network adapters: %w", err)
+		}
+	}
+
+	var adapters []dnsAdapter
+	for aa := (*windows.IpAdapterAddresses)(unsafe.Pointer(&buf[0])); aa != nil; aa = aa.Next {
+		if aa.OperStatus != windows.IfOperStatusUp || aa.IfType == windows.IF_TYPE_SOFTWARE_LOOPBACK {
+			continue
+		}
+		adapter := dnsAdapter{name: windows.UTF16PtrToString(aa.FriendlyName)}
+		for server := aa.FirstDnsServerAddress; server != nil; server = server.Next {
+			if ip := server.Address.IP(); ip != nil {
+				adapter.servers = append(adapter.servers, net.JoinHostPort(ip.String(), "53"))
+			}
+		}
+		adapters = append(adapters, adapter)
+	}
+	return adapters, nil
+}
+
+// systemResolvers returns the DNS servers of the adapters that are up,
+// leaving out local ones, which may be the proxy itself
+func systemResolvers() []string {
+	adapters, err := dnsAdapters()
+	if err != nil {
+		return nil
+	}
+	var servers []string
+	for _, adapter := range adapters {
+		for _, server := range adapter.servers {
+			host, _, _ := net.SplitHostPort(server)
+			// Windows lists unconfigured IPv6 servers as site-local fec0:0:0:ffff::1
+			if ip := net.ParseIP(host); ip.IsLoopback() || strings.HasPrefix(host, "fec0:0:0:ffff::") {
+				continue
+			}
+			if !containsString(servers, server) {
+				servers = append(servers, server)
+			}
+		}
+	}
+	return servers
+}
+
+// setupSystemDNS points the IPv4 DNS server of every adapter at the proxy
+func setupSystemDNS(listen string, out io.Writer) error {
+	host, port, err := net.SplitHostPort(listen)
+	if err != nil {
+		return err
+	}
+	if port != "53" {
+		return fmt.Errorf("Windows only asks resolvers on port 53: set dns.listen to %s:53", host)
+	}
+	adapters, err := dnsAdapters()
+	if err != nil {
+		return err
+	}
+	previous := systemResolvers()
+	for _, adapter := range adapters {
+		if err := netsh("interface", "ipv4", "set", "dnsservers", "name="+adapter.name,
+			"source=static", "address="+host, "register=none", "validate=no"); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s now asks sybr at %s\n", adapter.name, host)
+	}
+	if len(previous) > 0 {
+		fmt.Fprintf(out, "Set dns.upstream in settings.json to %s to keep forwarding to the previous resolvers after a restart\n",
+			strings.Join(previous, ", "))
+	}
+	return nil
+}
+
+// restoreSystemDNS lets every adapter take its DNS servers from DHCP again
+func restoreSystemDNS(out io.Writer) error {
+	adapters, err := dnsAdapters()
+	if err != nil {
+		return err
+	}
+	for _, adapter := range adapters {
+		if err := netsh("interface", "ipv4", "set", "dnsservers", "name="+adapter.name, "source=dhcp"); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%s takes its DNS servers from DHCP again\n", adapter.name)
+	}
+	return nil
+}
+
+// netsh runs netsh, which needs an elevated prompt to change adapters
+func netsh(args ...string) error {
+	if output, err := exec.Command("netsh", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("netsh %s failed (run it as administrator): %s", strings.Join(args[:4], " "), strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultEmergencyPassageWords is the passage length used when the setting is 0
+const defaultEmergencyPassageWords = 60
+
+// emergencyMaxCharsPerSecond caps the typing speed; anything faster was pasted
+const emergencyMaxCharsPerSecond = 12
+
+// emergencyChallengeTTL is how long a passage can be typed before it expires
+const emergencyChallengeTTL = 30 * time.Minute
+
+// emergencyWords is the vocabulary passages are drawn from. Plain lowercase
+// words keep the passage typeable on any keyboard layout
+var emergencyWords = strings.Fields(`
+	able about above across after again against air all almost alone along
+	already also always among animal another answer around away back ball
+	became because become before began behind being below best better between
+	big bird black blue boat body book both bottom box boy bring brought build
+	built busy call came can car care carry cause center certain change check
+	children city class clear close cold color come common complete could
+	country course cover cross cut dark day deep did different distance does
+	dog done door down draw dry during each early earth east easy eat end
+	enough even ever every example eye face fact fall family far farm fast
+	father feel feet few field find fine fire first fish five fly follow food
+	foot force form found four free friend from front full game gave get girl
+	give glass good got great green ground group grow half hand happen hard
+	has have head hear heard heart heavy help here high hold home horse hot
+	hour house however hundred idea important inch interest island just keep
+	kind king knew know land large last late laugh lead learn leave left less
+	letter life light like line list listen little live long look made main
+	make man many map mark may mean measure men might mile mind minute miss
+	money moon more morning most mother mountain move much music must name
+	near need never new next night north note nothing notice now number object
+	ocean off often old once only open order other our out over own page paper
+	part pass past pattern people perhaps person picture piece place plain
+	plan plant play point poor possible power press problem produce pull put
+	question quick quiet rain ran reach read ready real record red remember
+	rest right river road rock room round rule run said same sat saw say
+	school science sea second see seem sentence set several shape ship short
+	should show side simple since sing size sleep slow small snow some song
+	soon sound south space special spell stand star start state stay step
+	still stood stop story street strong study such sun sure surface table
+	tail take talk teacher tell ten test than that their them then there these
+	thing think those though thought thousand three through time today together
+	told too took top toward town travel tree true try turn under unit until
+	upon usually valley very voice walk warm watch water wave way weather week
+	weight well went west what wheel when where which while white whole why
+	wide wild will wind winter with without woman wood word work world would
+	write wrote year yellow young
+`)
+
+// EmergencyChallenge is a passage the user has to type back to lift the lock
+type EmergencyChallenge struct {
+	ID        string    `json:"id"`
+	Passage   string    `json:"passage"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// EmergencyUnlockEntry is one line of the emergency unlock log
+type EmergencyUnlockEntry struct {
+	Time          time.Time `json:"time"`
+	PassageLength int       `json:"passageLength"` // Characters typed
+	TookSeconds   int       `json:"tookSeconds"`
+	LockedUntil   time.Time `json:"lockedUntil,omitempty"` // Timed lock that was lifted
+	ForSession    bool      `json:"forSession"`            // A locked session was stopped
+}
+
+// EmergencyUnlocker hands out typing challenges and lifts the commitment lock
+// when one is typed back exactly. Validation happens here and not in the
+// frontend, so the lock can't be lifted by calling a binding directly
+type EmergencyUnlocker struct {
+	mu        sync.Mutex
+	clock     Clock
+	words     int
+	logPath   string
+	challenge *EmergencyChallenge
+}
+
+// NewEmergencyUnlocker creates an unlocker generating passages of words words
+func NewEmergencyUnlocker(clock Clock, words int, logPath string) *EmergencyUnlocker {
+	if clock == nil {
+		clock = realClock{}
+	}
+	if words <= 0 {
+		words = defaultEmergencyPassageWords
+	}
+	return &EmergencyUnlocker{clock: clock, words: words, logPath: logPath}
+}
+
+// Start generates a new passage, replacing any earlier one
+func (eu *EmergencyUnlocker) Start() (EmergencyChallenge, error) {
+	passage, err := randomPassage(eu.words)
+	if err != nil {
+		return EmergencyChallenge{}, fmt.Errorf("failed to generate passage: %w", err)
+	}
+
+	eu.mu.Lock()
+	defer eu.mu.Unlock()
+	now := eu.clock.Now()
+	eu.challenge = &EmergencyChallenge{
+		ID:        newPendingID(),
+		Passage:   passage,
+		CreatedAt: now,
+		ExpiresAt: now.Add(emergencyChallengeTTL),
+	}
+	fmt.Printf("🆘 Emergency unlock requested (%d characters)\n", len(passage))
+	return *eu.challenge, nil
+}
+
+// Verify checks a typed passage. On success the challenge is consumed and
+// the time it took is returned
+func (eu *EmergencyUnlocker) Verify(id, typed string) (time.Duration, int, error) {
+	eu.mu.Lock()
+	defer eu.mu.Unlock()
+
+	challenge := eu.challenge
+	if challenge == nil || challenge.ID != id {
+		return 0, 0, fmt.Errorf("no such emergency unlock, start a new one")
+	}
+	now := eu.clock.Now()
+	if now.After(challenge.ExpiresAt) {
+		eu.challenge = nil
+		return 0, 0, fmt.Errorf("the passage expired, start a new one")
+	}
+	if typed != challenge.Passage {
+		return 0, 0, fmt.Errorf("the passage doesn't match (at character %d)", firstDifference(typed, challenge.Passage)+1)
+	}
+
+	took := now.Sub(challenge.CreatedAt)
+	minimum := time.Duration(len(challenge.Passage)/emergencyMaxCharsPerSecond) * time.Second
+	if took < minimum {
+		// Too fast to have been typed; make them start over
+		eu.challenge = nil
+		return 0, 0, fmt.Errorf("the passage was entered too fast to be typed, start a new one")
+	}
+
+	eu.challenge = nil
+	return took, len(challenge.Passage), nil
+}
+
+// Log appends an unlock to the emergency unlock log
+func (eu *EmergencyUnlocker) Log(entry EmergencyUnlockEntry) error {
+	if eu.logPath == "" {
+		return nil
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal unlock entry: %w", err)
+	}
+	file, err := os.OpenFile(eu.logPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open unlock log: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write unlock log: %w", err)
+	}
+	return nil
+}
+
+// Entries returns the logged emergency unlocks, oldest first
+func (eu *EmergencyUnlocker) Entries() ([]EmergencyUnlockEntry, error) {
+	entries := []EmergencyUnlockEntry{}
+	if eu.logPath == "" {
+		return entries, nil
+	}
+	data, err := os.ReadFile(eu.logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return entries, nil
+		}
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		var entry EmergencyUnlockEntry
+		if line == "" || json.Unmarshal([]byte(line), &entry) != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// randomPassage joins n random words using crypto/rand
+func randomPassage(n int) (string, error) {
+	words := make([]string, n)
+	max := big.NewInt(int64(len(emergencyWords)))
+	for i := range words {
+		index, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		words[i] = emergencyWords[index.Int64()]
+	}
+	return strings.Join(words, " "), nil
+}
+
+// firstDifference returns the index of the first differing byte
+func firstDifference(a, b string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return i
+		}
+	}
+	if len(a) < len(b) {
+		return len(a)
+	}
+	return len(b)
+}
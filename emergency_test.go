@@ -0,0 +1,74 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestEmergencyUnlockVerify tests that only the exact passage, typed at a
+// human speed and before it expires, is accepted
+func TestEmergencyUnlockVerify(t *testing.T) {
+	clock := newFakeClock()
+	unlocker := NewEmergencyUnlocker(clock, 20, "")
+
+	challenge, err := unlocker.Start()
+	if err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if words := len(strings.Fields(challenge.Passage)); words != 20 {
+		t.Fatalf("passage has %d words, want 20", words)
+	}
+
+	// Pasted immediately: refused and the challenge is burnt
+	if _, _, err := unlocker.Verify(challenge.ID, challenge.Passage); err == nil {
+		t.Fatal("instant submission should be refused")
+	}
+	if _, _, err := unlocker.Verify(challenge.ID, challenge.Passage); err == nil {
+		t.Fatal("challenge should be consumed after a too fast submission")
+	}
+
+	challenge, _ = unlocker.Start()
+	clock.Advance(2 * time.Minute)
+	if _, _, err := unlocker.Verify(challenge.ID, challenge.Passage+" "); err == nil {
+		t.Error("passage with a trailing space should not match")
+	}
+	if _, _, err := unlocker.Verify("other", challenge.Passage); err == nil {
+		t.Error("unknown challenge ID should be refused")
+	}
+	took, length, err := unlocker.Verify(challenge.ID, challenge.Passage)
+	if err != nil {
+		t.Fatalf("Verify failed: %v", err)
+	}
+	if took != 2*time.Minute || length != len(challenge.Passage) {
+		t.Errorf("Verify = %v, %d; want 2m, %d", took, length, len(challenge.Passage))
+	}
+	if _, _, err := unlocker.Verify(challenge.ID, challenge.Passage); err == nil {
+		t.Error("a challenge can only be used once")
+	}
+
+	challenge, _ = unlocker.Start()
+	clock.Advance(emergencyChallengeTTL + time.Second)
+	if _, _, err := unlocker.Verify(challenge.ID, challenge.Passage); err == nil {
+		t.Error("expired challenge should be refused")
+	}
+}
+
+// TestEmergencyUnlockLog tests that unlocks are appended to the log
+func TestEmergencyUnlockLog(t *testing.T) {
+	unlocker := NewEmergencyUnlocker(nil, 0, filepath.Join(t.TempDir(), "emergency_unlocks.jsonl"))
+	now := time.Now().UTC().Truncate(time.Second)
+	for i := 0; i < 2; i++ {
+		if err := unlocker.Log(EmergencyUnlockEntry{Time: now, PassageLength: 100 + i}); err != nil {
+			t.Fatalf("Log failed: %v", err)
+		}
+	}
+	entries, err := unlocker.Entries()
+	if err != nil {
+		t.Fatalf("Entries failed: %v", err)
+	}
+	if len(entries) != 2 || entries[1].PassageLength != 101 || !entries[0].Time.Equal(now) {
+		t.Errorf("entries = %+v", entries)
+	}
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Enforcer subscribes to window changes, checks them against the blocklist and
+// warns the user. It publishes BlockDetected, WarningShown and WarningAnswered
+type Enforcer struct {
+	bus           *EventBus
+	sub           *Subscription
+	processes     chan ProcessInfo // Blocked processes queued by the process scanner
+	mu            sync.Mutex
+	lastWarnedExe string // Track last warned app to avoid spam
+	done          chan struct{}
+}
+
+// enforcerProcessQueue is how many scanner warnings may wait for the warning
+// that is open; more than one per app is never needed
+const enforcerProcessQueue = 16
+
+// NewEnforcer creates an enforcer for the given bus
+func NewEnforcer(bus *EventBus) *Enforcer {
+	return &Enforcer{bus: bus, processes: make(chan ProcessInfo, enforcerProcessQueue)}
+}
+
+// Start subscribes to window changes
+func (e *Enforcer) Start() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.sub != nil {
+		return
+	}
+	e.sub = e.bus.Subscribe(0, EventWindowChanged)
+	e.done = make(chan struct{})
+	go e.run(e.sub, e.done)
+}
+
+// Stop unsubscribes and waits for the current check to finish
+func (e *Enforcer) Stop() {
+	e.mu.Lock()
+	sub, done := e.sub, e.done
+	e.sub = nil
+	e.mu.Unlock()
+	if sub == nil {
+		return
+	}
+	sub.Unsubscribe()
+	<-done
+}
+
+// run checks windows and queued processes one at a time, so only one warning
+// is open at once
+func (e *Enforcer) run(sub *Subscription, done chan struct{}) {
+	defer close(done)
+	for {
+		select {
+		case event, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if changed, ok := event.Payload.(WindowChangedEvent); ok {
+				e.checkWindow(changed.Window)
+			}
+		case p := <-e.processes:
+			e.CheckProcess(p)
+		}
+	}
+}
+
+// QueueProcess hands a blocked process found by the process scanner to the
+// enforcer's goroutine without waiting for the warning to be dismissed
+func (e *Enforcer) QueueProcess(p ProcessInfo) {
+	select {
+	case e.processes <- p:
+	default:
+		fmt.Printf("⚠️  Too many warnings waiting, skipping %s (pid %d)\n", p.Name, p.PID)
+	}
+}
+
+// CheckProcess warns about a blocked process found by the process scanner
+// like a focused window, sharing the de-duplication with checkWindow
+func (e *Enforcer) CheckProcess(p ProcessInfo) {
+	e.checkWindow(WindowInfo{
+		Exe:   p.Name,
+		Title: fmt.Sprintf("Running in the background (pid %d)", p.PID),
+		PID:   p.PID,
+		AppID: p.AppID,
+	})
+}
+
+// checkWindow warns if the window belongs to a blocked app
+func (e *Enforcer) checkWindow(info WindowInfo) {
+	// Check if app is blocked
+	bm, err := GetBlocklistManager()
+	if err != nil || bm == nil {
+		fmt.Printf("⚠️  Failed to get blocklist manager: %v\n", err)
+		return
+	}
+
+	// Normalize executable name for comparison
+	exeLower := strings.ToLower(strings.TrimSpace(info.Exe))
+	process := ProcessInfo{PID: info.PID, Name: exeLower, AppID: info.AppID}
+
+	// Sandboxed and interpreted apps are reported by their app ID rather than
+	// bwrap, AppRun or python
+	if info.AppID != "" {
+		exeLower = strings.ToLower(info.AppID)
+	}
+
+	// Debug logging
+	fmt.Printf("🔍 Checking if blocked: exe=%s\n", exeLower)
+
+	// Entries for launchers apply to everything they start
+	blockedApp := bm.MatchProcess(process, processAncestors(info.PID))
+	// A blocked app running in a focused terminal is focused too
+	if blockedApp == nil && info.Foreground.PID != 0 {
+		job := ProcessInfo{PID: info.Foreground.PID, Name: info.Foreground.Exe, AppID: info.Foreground.AppID}
+		if blockedApp = bm.MatchProcess(job, processAncestors(job.PID)); blockedApp != nil {
+			process = job
+			exeLower = strings.ToLower(info.Foreground.name())
+		}
+	}
+	// So is a blocked website in a browser with the sybr extension
+	if blockedApp == nil && info.Domain != "" {
+		if blockedApp = bm.MatchDomain(info.Domain); blockedApp != nil {
+			exeLower = blockedApp.ExecutableName
+		}
+	}
+	fmt.Printf("🔍 MatchProcess result for '%s': %v\n", exeLower, blockedApp != nil)
+
+	if blockedApp == nil {
+		// Reset last warned if app is not blocked
+		e.mu.Lock()
+		if e.lastWarnedExe == exeLower || e.lastWarnedExe == info.Domain ||
+			(info.Foreground.PID != 0 && e.lastWarnedExe == strings.ToLower(info.Foreground.name())) {
+			e.lastWarnedExe = ""
+		}
+		e.mu.Unlock()
+		return
+	}
+
+	fmt.Printf("✅ BLOCKED APP DETECTED!\n")
+	displayName := exeLower
+	if blockedApp.DisplayName != "" {
+		displayName = blockedApp.DisplayName
+	}
+	launchedBy := ""
+	if blockedApp.Kind != KindDomain && !blockedApp.identifies(process) {
+		launchedBy = displayName
+		displayName = exeLower
+	}
+	e.bus.Publish(EventBlockDetected, BlockDetectedEvent{
+		ExecutableName: exeLower,
+		DisplayName:    displayName,
+		Title:          info.Title,
+		LaunchedBy:     launchedBy,
+	})
+
+	// A snoozed app is still reported but not warned about; snoozing a
+	// launcher snoozes what it starts too
+	store, storeErr := GetStateStore()
+	if storeErr == nil {
+		if until := store.SnoozedUntil(blockedApp.ExecutableName, time.Now()); !until.IsZero() {
+			fmt.Printf("😴 %s is snoozed until %s, skipping warning\n", blockedApp.ExecutableName, until.Local().Format("15:04"))
+			return
+		}
+	}
+
+	// Only warn if this is a different app (avoid spam)
+	e.mu.Lock()
+	shouldWarn := e.lastWarnedExe != exeLower
+	if shouldWarn {
+		e.lastWarnedExe = exeLower
+	}
+	e.mu.Unlock()
+
+	if !shouldWarn {
+		fmt.Printf("⏭️  Same blocked app, skipping duplicate warning\n")
+		return
+	}
+
+	fmt.Printf("⚠️  Blocked app detected: [%s] %s\n", exeLower, info.Title)
+
+	// Count warnings per app and day so repeated attempts escalate
+	warnings := 0
+	if storeErr == nil {
+		if warnings, err = store.RecordWarning(exeLower, time.Now()); err != nil {
+			fmt.Printf("⚠️  Failed to record warning: %v\n", err)
+		}
+	}
+
+	// The frontend WarningModal listens for this through the Wails bridge
+	e.bus.Publish(EventWarningShown, WarningEvent{
+		ExecutableName: exeLower,
+		DisplayName:    displayName,
+		Title:          info.Title,
+		LaunchedBy:     launchedBy,
+	})
+
+	// Show native MessageBox (blocks until dismissed)
+	message := fmt.Sprintf("You're trying to open a blocked application:\n\n%s\n\nWindow: %s", displayName, info.Title)
+	if blockedApp.Kind == KindDomain {
+		message = fmt.Sprintf("You're trying to open a blocked website:\n\n%s\n\nPage: %s", displayName, info.Title)
+	}
+	if launchedBy != "" {
+		message += fmt.Sprintf("\nLaunched by: %s", launchedBy)
+	}
+	if warnings > 1 {
+		message += fmt.Sprintf("\n\nThis is warning #%d for this app today.", warnings)
+	}
+	fmt.Printf("📢 Calling ShowSystemWarning...\n")
+	result, err := ShowSystemWarning("⚠️ Focus Warning", message)
+	if err != nil {
+		fmt.Printf("❌ Failed to show warning MessageBox: %v\n", err)
+	} else {
+		fmt.Printf("✅ MessageBox shown successfully\n")
+	}
+
+	e.bus.Publish(EventWarningAnswered, WarningAnsweredEvent{
+		ExecutableName: exeLower,
+		Result:         result,
+	})
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// EventType identifies the kind of event published on the bus
+type EventType string
+
+const (
+	// EventWindowChanged is published by the watcher when the foreground window changes (WindowChangedEvent)
+	EventWindowChanged EventType = "window-changed"
+	// EventBlockDetected is published whenever a blocked app is in the foreground (BlockDetectedEvent)
+	EventBlockDetected EventType = "block-detected"
+	// EventWarningShown is published right before a warning is displayed (WarningEvent)
+	EventWarningShown EventType = "warning-shown"
+	// EventWarningAnswered is published once the user dismissed a warning (WarningAnsweredEvent)
+	EventWarningAnswered EventType = "warning-answered"
+	// EventIdleChanged is published when the user becomes idle or active again (IdleChangedEvent)
+	EventIdleChanged EventType = "idle-changed"
+	// EventSessionChanged is published by the focus session engine on every transition
+	EventSessionChanged EventType = "session-changed"
+	// EventPendingChanged is published when a delayed unlock is queued, cancelled or applied
+	EventPendingChanged EventType = "pending-changed"
+	// EventEmergencyUnlock is published when the lock was lifted by an emergency unlock (EmergencyUnlockEntry)
+	EventEmergencyUnlock EventType = "emergency-unlock"
+	// EventProcessDetected is published when the process scanner finds a blocked app running (ProcessDetectedEvent)
+	EventProcessDetected EventType = "process-detected"
+	// EventProcessTerminated is published when the process scanner terminated a blocked process (ProcessTerminatedEvent)
+	EventProcessTerminated EventType = "process-terminated"
+	// EventRequestBlocked is published when the HTTP proxy refused a blocked website (DistractionAttempt)
+	EventRequestBlocked EventType = "request-blocked"
+)
+
+// defaultSubscriberBuffer is used when Subscribe is called with a buffer <= 0
+const defaultSubscriberBuffer = 64
+
+// Event is delivered to subscribers. Payload holds the typed event struct
+// matching Type (e.g. WindowChangedEvent for EventWindowChanged)
+type Event struct {
+	Seq     uint64      `json:"seq"`
+	Type    EventType   `json:"type"`
+	Time    time.Time   `json:"time"`
+	Payload interface{} `json:"payload"`
+}
+
+// WindowChangedEvent is the payload of EventWindowChanged
+type WindowChangedEvent struct {
+	Window   WindowInfo  `json:"window"`
+	Previous *WindowInfo `json:"previous,omitempty"`
+}
+
+// BlockDetectedEvent is the payload of EventBlockDetected
+type BlockDetectedEvent struct {
+	ExecutableName string `json:"executableName"`
+	DisplayName    string `json:"displayName"`
+	Title          string `json:"title"`
+	LaunchedBy     string `json:"launchedBy,omitempty"` // Blocked launcher the app was started from
+}
+
+// WarningEvent is the payload of EventWarningShown
+type WarningEvent struct {
+	ExecutableName string `json:"executableName"`
+	DisplayName    string `json:"displayName"`
+	Title          string `json:"title"`
+	LaunchedBy     string `json:"launchedBy,omitempty"` // Blocked launcher the app was started from
+}
+
+// WarningAnsweredEvent is the payload of EventWarningAnswered
+type WarningAnsweredEvent struct {
+	ExecutableName string `json:"executableName"`
+	Result         int    `json:"result"` // MessageBox return code, 0 if it failed
+}
+
+// ProcessDetectedEvent is the payload of EventProcessDetected
+type ProcessDetectedEvent struct {
+	PID            int    `json:"pid"`
+	ExecutableName string `json:"executableName"`
+	DisplayName    string `json:"displayName"`
+	Action         string `json:"action"` // ScanActionWarn or ScanActionTerminate
+}
+
+// ProcessTerminatedEvent is the payload of EventProcessTerminated
+type ProcessTerminatedEvent struct {
+	PID            int    `json:"pid"`
+	ExecutableName string `json:"executableName"`
+}
+
+// IdleChangedEvent is the payload of EventIdleChanged
+type IdleChangedEvent struct {
+	Idle      bool      `json:"idle"`
+	IdleSince time.Time `json:"idleSince"`
+}
+
+// EventBus is a typed in-process publish/subscribe bus.
+// Publishing never blocks: a subscriber whose buffer is full misses the event
+// and its Dropped counter is incremented
+type EventBus struct {
+	mu   sync.Mutex
+	seq  uint64
+	subs map[*Subscription]struct{}
+}
+
+// Subscription receives events on C until Unsubscribe is called
+type Subscription struct {
+	C       <-chan Event
+	ch      chan Event
+	types   map[EventType]bool
+	bus     *EventBus
+	dropped atomic.Uint64
+	once    sync.Once
+}
+
+// NewEventBus creates an empty bus
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subs: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe registers a subscriber with a bounded buffer. With no types the
+// subscriber receives every event
+func (b *EventBus) Subscribe(buffer int, types ...EventType) *Subscription {
+	if buffer <= 0 {
+		buffer = defaultSubscriberBuffer
+	}
+	ch := make(chan Event, buffer)
+	sub := &Subscription{
+		C:   ch,
+		ch:  ch,
+		bus: b,
+	}
+	if len(types) > 0 {
+		sub.types = make(map[EventType]bool, len(types))
+		for _, t := range types {
+			sub.types[t] = true
+		}
+	}
+
+	b.mu.Lock()
+	b.subs[sub] = struct{}{}
+	b.mu.Unlock()
+	return sub
+}
+
+// Publish stamps the event with the next sequence number and delivers it to
+// all interested subscribers. Delivery happens under the bus lock so every
+// subscriber sees events in sequence order
+func (b *EventBus) Publish(eventType EventType, payload interface{}) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.seq++
+	event := Event{
+		Seq:     b.seq,
+		Type:    eventType,
+		Time:    time.Now(),
+		Payload: payload,
+	}
+
+	for sub := range b.subs {
+		if sub.types != nil && !sub.types[eventType] {
+			continue
+		}
+		select {
+		case sub.ch <- event:
+		default:
+			if sub.dropped.Add(1) == 1 {
+				fmt.Printf("⚠️  Event subscriber is not keeping up, dropping %s #%d\n", eventType, event.Seq)
+			}
+		}
+	}
+	return event
+}
+
+// Unsubscribe removes the subscription and closes its channel
+func (s *Subscription) Unsubscribe() {
+	s.once.Do(func() {
+		s.bus.mu.Lock()
+		delete(s.bus.subs, s)
+		close(s.ch)
+		s.bus.mu.Unlock()
+	})
+}
+
+// Dropped returns how many events this subscriber missed because its buffer was full
+func (s *Subscription) Dropped() uint64 {
+	return s.dropped.Load()
+}
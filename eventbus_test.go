@@ -0,0 +1,73 @@
+package main
+
+import (
+	"testing"
+)
+
+// TestEventBusSequence tests that subscribers see monotonic sequence numbers
+// and only the event types they asked for
+func TestEventBusSequence(t *testing.T) {
+	bus := NewEventBus()
+	all := bus.Subscribe(10)
+	windows := bus.Subscribe(10, EventWindowChanged)
+	defer all.Unsubscribe()
+	defer windows.Unsubscribe()
+
+	bus.Publish(EventWindowChanged, WindowChangedEvent{Window: WindowInfo{Exe: "a.exe"}})
+	bus.Publish(EventIdleChanged, IdleChangedEvent{Idle: true})
+	bus.Publish(EventWindowChanged, WindowChangedEvent{Window: WindowInfo{Exe: "b.exe"}})
+
+	var last uint64
+	for i := 0; i < 3; i++ {
+		event := <-all.C
+		if event.Seq <= last {
+			t.Errorf("event %d has seq %d after %d", i, event.Seq, last)
+		}
+		last = event.Seq
+	}
+
+	first := <-windows.C
+	second := <-windows.C
+	if first.Type != EventWindowChanged || second.Type != EventWindowChanged {
+		t.Fatalf("filtered subscriber got %s and %s", first.Type, second.Type)
+	}
+	if got := second.Payload.(WindowChangedEvent).Window.Exe; got != "b.exe" {
+		t.Errorf("second window = %s, want b.exe", got)
+	}
+	select {
+	case event := <-windows.C:
+		t.Errorf("unexpected extra event %+v", event)
+	default:
+	}
+}
+
+// TestEventBusSlowSubscriber tests that a full subscriber doesn't block publishing
+func TestEventBusSlowSubscriber(t *testing.T) {
+	bus := NewEventBus()
+	slow := bus.Subscribe(1)
+	defer slow.Unsubscribe()
+
+	for i := 0; i < 5; i++ {
+		bus.Publish(EventBlockDetected, BlockDetectedEvent{ExecutableName: "game.exe"})
+	}
+
+	if dropped := slow.Dropped(); dropped != 4 {
+		t.Errorf("Dropped() = %d, want 4", dropped)
+	}
+	if event := <-slow.C; event.Seq != 1 {
+		t.Errorf("buffered event seq = %d, want 1", event.Seq)
+	}
+}
+
+// TestEventBusUnsubscribe tests that the channel is closed and no longer receives events
+func TestEventBusUnsubscribe(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(1)
+	sub.Unsubscribe()
+	sub.Unsubscribe() // must be safe to call twice
+
+	bus.Publish(EventWindowChanged, WindowChangedEvent{})
+	if _, ok := <-sub.C; ok {
+		t.Error("expected closed channel after Unsubscribe")
+	}
+}
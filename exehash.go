@@ -0,0 +1,84 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxHashCacheEntries bounds the hash cache; it is cleared when full
+const maxHashCacheEntries = 4096
+
+// hashCacheEntry is a cached hash, valid while the file keeps its size and mtime
+type hashCacheEntry struct {
+	size    int64
+	modTime time.Time
+	sum     string
+}
+
+// hashCache remembers executable hashes by path so that checking the same
+// binaries every poll or scan only costs a stat
+type hashCache struct {
+	mu      sync.Mutex
+	entries map[string]hashCacheEntry
+}
+
+var executableHashes = &hashCache{entries: map[string]hashCacheEntry{}}
+
+// hashExecutable returns the lowercase hex SHA-256 of the file at path
+func hashExecutable(path string) (string, error) {
+	return executableHashes.hash(path)
+}
+
+func (hc *hashCache) hash(path string) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s is not a regular file", path)
+	}
+
+	hc.mu.Lock()
+	cached, ok := hc.entries[path]
+	hc.mu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.sum, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", fmt.Errorf("failed to hash %s: %w", path, err)
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+
+	hc.mu.Lock()
+	if len(hc.entries) >= maxHashCacheEntries {
+		hc.entries = map[string]hashCacheEntry{}
+	}
+	hc.entries[path] = hashCacheEntry{size: info.Size(), modTime: info.ModTime(), sum: sum}
+	hc.mu.Unlock()
+	return sum, nil
+}
+
+// normalizeHash validates a SHA-256 from user input and lowercases it
+func normalizeHash(sum string) (string, error) {
+	sum = strings.ToLower(strings.TrimSpace(sum))
+	if len(sum) != sha256.Size*2 {
+		return "", fmt.Errorf("'%s' is not a SHA-256 hash", sum)
+	}
+	if _, err := hex.DecodeString(sum); err != nil {
+		return "", fmt.Errorf("'%s' is not a SHA-256 hash", sum)
+	}
+	return sum, nil
+}
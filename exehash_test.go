@@ -0,0 +1,97 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestHashCache tests that hashes are cached until the file's size or mtime changes
+func TestHashCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "game.exe")
+	if err := os.WriteFile(path, []byte("game v1"), 0755); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	cache := &hashCache{entries: map[string]hashCacheEntry{}}
+
+	sum, err := cache.hash(path)
+	if err != nil {
+		t.Fatalf("hash failed: %v", err)
+	}
+	want := sha256.Sum256([]byte("game v1"))
+	if sum != hex.EncodeToString(want[:]) {
+		t.Fatalf("hash = %s, want the SHA-256 of the file", sum)
+	}
+
+	// A cached entry is trusted while size and mtime match
+	entry := cache.entries[path]
+	entry.sum = "cached"
+	cache.entries[path] = entry
+	if sum, _ := cache.hash(path); sum != "cached" {
+		t.Errorf("hash = %s, want the cached value", sum)
+	}
+
+	if err := os.WriteFile(path, []byte("game v2!"), 0755); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(path, later, later)
+	sum, err = cache.hash(path)
+	want = sha256.Sum256([]byte("game v2!"))
+	if err != nil || sum != hex.EncodeToString(want[:]) {
+		t.Errorf("hash after change = %s, %v, want the new SHA-256", sum, err)
+	}
+
+	if _, err := cache.hash(filepath.Dir(path)); err == nil {
+		t.Error("hashing a directory should fail")
+	}
+}
+
+// TestRenamedBinaryMatchesPinnedHash tests that a pinned entry blocks a copy
+// of the binary under another name
+func TestRenamedBinaryMatchesPinnedHash(t *testing.T) {
+	dir := t.TempDir()
+	original := filepath.Join(dir, "game.exe")
+	renamed := filepath.Join(dir, "notepad.exe")
+	for _, path := range []string{original, renamed} {
+		if err := os.WriteFile(path, []byte("the game"), 0755); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	other := filepath.Join(dir, "editor.exe")
+	if err := os.WriteFile(other, []byte("an editor"), 0755); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	bm := &BlocklistManager{filePath: filepath.Join(dir, "blocking_list.json")}
+	if err := bm.AddApp("game.exe", "Game"); err != nil {
+		t.Fatalf("AddApp failed: %v", err)
+	}
+	sum, err := hashExecutable(original)
+	if err != nil {
+		t.Fatalf("hashExecutable failed: %v", err)
+	}
+	if err := bm.PinHash("game.exe", strings.ToUpper(sum)); err != nil {
+		t.Fatalf("PinHash failed: %v", err)
+	}
+	if err := bm.PinHash("game.exe", sum); err != nil {
+		t.Fatalf("pinning again failed: %v", err)
+	}
+	if app := bm.GetBlockedAppAnyProfile("game.exe"); app == nil || len(app.Hashes) != 1 {
+		t.Fatalf("entry = %+v, want one pinned hash", app)
+	}
+	if err := bm.PinHash("game.exe", "not-a-hash"); err == nil {
+		t.Error("PinHash accepted an invalid hash")
+	}
+
+	if app := bm.MatchProcess(ProcessInfo{Name: "notepad.exe", Path: renamed}, nil); app == nil || app.ExecutableName != "game" {
+		t.Errorf("renamed copy matched %+v, want the game.exe entry", app)
+	}
+	if app := bm.MatchProcess(ProcessInfo{Name: "editor.exe", Path: other}, nil); app != nil {
+		t.Errorf("unrelated binary matched %+v", app)
+	}
+}
@@ -7,8 +7,12 @@ import WindowMonitor from './components/WindowMonitor'
 import HistoryLog from './components/HistoryLog'
 import AutoStartSettings from './components/AutoStartSettings'
 import BlocklistSettings from './components/BlocklistSettings'
+import FocusSession from './components/FocusSession'
+import AdminSettings, { AdminPrompt } from './components/AdminSettings'
+import PartnerSettings from './components/PartnerSettings'
 import WarningModal from './components/WarningModal'
 import { EventsOn } from './wailsjs/runtime/runtime'
+import { withAdminToken } from './adminAuth'
 
 // We'll use window.go.main.App directly - that's what Wails provides
 // The generated bindings are just wrappers, but window.go.main.App is the source
@@ -61,7 +65,10 @@ function App() {
     // Skip if same window
     if (lastWindowRef.current && 
         lastWindowRef.current.title === windowInfo.title && 
-        lastWindowRef.current.exe === windowInfo.exe) {
+        lastWindowRef.current.exe === windowInfo.exe &&
+        lastWindowRef.current.foreground?.pid === windowInfo.foreground?.pid &&
+        lastWindowRef.current.domain === windowInfo.domain &&
+        lastWindowRef.current.project === windowInfo.project) {
       console.log('⏭️ addToHistory: Same window, skipping duplicate:', {
         current: lastWindowRef.current,
         new: windowInfo
@@ -77,7 +84,7 @@ function App() {
       timestamp: now.getTime(),
       id: Date.now() + Math.random(),
       // Format like terminal output: "Active Window Changed: [exe] title"
-      terminalLine: `Active Window Changed: [${windowInfo.exe || 'unknown'}] ${windowInfo.title || 'Unknown'}`
+      terminalLine: `Active Window Changed: [${windowInfo.appId || windowInfo.exe || 'unknown'}${windowInfo.foreground ? ' > ' + (windowInfo.foreground.appId || windowInfo.foreground.exe) : ''}${windowInfo.domain ? ' @ ' + windowInfo.domain : ''}${windowInfo.project ? ' in ' + windowInfo.project : ''}] ${windowInfo.title || 'Unknown'}`
     }
     
     console.log('✅ addToHistory: Adding entry to history:', entry)
@@ -310,7 +317,7 @@ function App() {
     try {
       if (window.go?.main?.App?.EnableAutoStart) {
         console.log('📞 Calling window.go.main.App.EnableAutoStart...')
-        await window.go.main.App.EnableAutoStart()
+        await withAdminToken((token) => window.go.main.App.EnableAutoStart(token))
       } else {
         console.error('❌ EnableAutoStart not available')
         alert('Wails bindings not available')
@@ -330,7 +337,7 @@ function App() {
     try {
       if (window.go?.main?.App?.DisableAutoStart) {
         console.log('📞 Calling window.go.main.App.DisableAutoStart...')
-        await window.go.main.App.DisableAutoStart()
+        await withAdminToken((token) => window.go.main.App.DisableAutoStart(token))
       } else {
         console.error('❌ DisableAutoStart not available')
         alert('Wails bindings not available')
@@ -366,10 +373,22 @@ function App() {
             />
           </div>
 
+          <div className="card">
+            <FocusSession />
+          </div>
+
           <div className="card">
             <BlocklistSettings />
           </div>
 
+          <div className="card">
+            <AdminSettings />
+          </div>
+
+          <div className="card">
+            <PartnerSettings />
+          </div>
+
           <div className="card card-full">
             <HistoryLog 
               history={history} 
@@ -379,6 +398,7 @@ function App() {
         </div>
 
         <WarningModal />
+        <AdminPrompt />
       </div>
     </div>
   )
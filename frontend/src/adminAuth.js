@@ -0,0 +1,75 @@
+// Admin tokens and accountability partner codes for the gated bindings. A
+// token from AuthenticateAdmin is kept until it expires so one password
+// entry covers a few edits in a row; partner codes are asked for every time
+// because each one works only once
+
+let cachedToken = null
+let promptHandler = null
+
+// setAdminPrompt registers the function asking for the password or partner
+// code. It gets 'password' or 'partner' and returns a promise with what was
+// entered, or null when cancelled
+export function setAdminPrompt(handler) {
+  promptHandler = handler
+}
+
+// clearAdminToken forgets the cached token, e.g. after the password changed
+export function clearAdminToken() {
+  cachedToken = null
+}
+
+function currentToken() {
+  if (cachedToken && new Date(cachedToken.expiresAt) > new Date()) {
+    return cachedToken.token
+  }
+  cachedToken = null
+  return ''
+}
+
+// isAdminAuthError reports whether a binding was refused for a missing token
+export function isAdminAuthError(err) {
+  return String(err).includes('admin password required')
+}
+
+// isPartnerCodeError reports whether a binding was refused for a missing partner code
+export function isPartnerCodeError(err) {
+  return String(err).includes('accountability partner code required')
+}
+
+// withAdminToken calls fn(token, partnerCode) and, while the backend asks for
+// the admin password or a partner code, prompts for it and retries
+export async function withAdminToken(fn) {
+  let token = currentToken()
+  let partnerCode = ''
+  let askedPassword = false
+  for (;;) {
+    try {
+      return await fn(token, partnerCode)
+    } catch (err) {
+      let kind = null
+      // A cached token may have died with a restart of the backend
+      if (isAdminAuthError(err) && !askedPassword) {
+        kind = 'password'
+      } else if (isPartnerCodeError(err) && !partnerCode) {
+        kind = 'partner'
+      }
+      if (!kind || !promptHandler) {
+        throw err
+      }
+
+      const entered = await promptHandler(kind)
+      if (entered === null) {
+        throw new Error(kind === 'password'
+          ? 'Cancelled: the admin password is required'
+          : 'Cancelled: a code from your accountability partner is required')
+      }
+      if (kind === 'password') {
+        askedPassword = true
+        cachedToken = await window.go.main.App.AuthenticateAdmin(entered)
+        token = cachedToken.token
+      } else {
+        partnerCode = entered
+      }
+    }
+  }
+}
@@ -0,0 +1,175 @@
+import React, { useEffect, useRef, useState } from 'react'
+import './AdminSettings.css'
+import { clearAdminToken, setAdminPrompt, withAdminToken } from '../adminAuth'
+
+// AdminPrompt asks for the admin password or a partner code when a gated
+// binding is refused
+export function AdminPrompt() {
+  const [kind, setKind] = useState(null)
+  const [password, setPassword] = useState('')
+  const resolveRef = useRef(null)
+
+  useEffect(() => {
+    setAdminPrompt((nextKind) => new Promise((resolve) => {
+      resolveRef.current = resolve
+      setPassword('')
+      setKind(nextKind)
+    }))
+    return () => setAdminPrompt(null)
+  }, [])
+
+  const finish = (value) => {
+    setKind(null)
+    if (resolveRef.current) {
+      resolveRef.current(value)
+      resolveRef.current = null
+    }
+  }
+
+  if (!kind) {
+    return null
+  }
+  const partner = kind === 'partner'
+
+  return (
+    <div className="admin-prompt-overlay">
+      <form
+        className="admin-prompt"
+        onSubmit={(e) => {
+          e.preventDefault()
+          finish(password)
+        }}
+      >
+        <h2>{partner ? '🤝 Partner Code' : '🔑 Admin Password'}</h2>
+        <p className="admin-hint">
+          {partner
+            ? 'Ask your accountability partner for the current code from their authenticator app.'
+            : 'This change needs the admin password.'}
+        </p>
+        <input
+          type={partner ? 'text' : 'password'}
+          inputMode={partner ? 'numeric' : undefined}
+          className="admin-input"
+          value={password}
+          onChange={(e) => setPassword(e.target.value)}
+          autoFocus
+        />
+        <div className="session-actions">
+          <button type="button" onClick={() => finish(null)} className="btn btn-secondary">
+            Cancel
+          </button>
+          <button type="submit" className="btn btn-primary" disabled={!password}>
+            Unlock
+          </button>
+        </div>
+      </form>
+    </div>
+  )
+}
+
+// AdminSettings sets, changes, removes and resets the admin password
+function AdminSettings() {
+  const [status, setStatus] = useState({ enabled: false })
+  const [password, setPassword] = useState('')
+  const [recoveryCode, setRecoveryCode] = useState('')
+  const [shownCode, setShownCode] = useState('')
+  const [error, setError] = useState('')
+
+  const loadStatus = async () => {
+    try {
+      setStatus(await window.go.main.App.GetAdminStatus())
+    } catch (err) {
+      console.error('❌ Error loading admin status:', err)
+    }
+  }
+
+  useEffect(() => {
+    loadStatus()
+  }, [])
+
+  const run = async (action) => {
+    setError('')
+    try {
+      await action()
+      setPassword('')
+      setRecoveryCode('')
+    } catch (err) {
+      console.error('❌ Admin password action failed:', err)
+      setError(err.message || String(err))
+    } finally {
+      await loadStatus()
+    }
+  }
+
+  const handleSet = () => run(async () => {
+    const code = await withAdminToken((token) => window.go.main.App.SetAdminPassword(token, password))
+    clearAdminToken()
+    setShownCode(code)
+  })
+
+  const handleClear = () => run(async () => {
+    await withAdminToken((token) => window.go.main.App.ClearAdminPassword(token))
+    clearAdminToken()
+    setShownCode('')
+  })
+
+  const handleReset = () => run(async () => {
+    const code = await window.go.main.App.ResetAdminPassword(recoveryCode, password)
+    clearAdminToken()
+    setShownCode(code)
+  })
+
+  return (
+    <div className="admin-settings">
+      <h2>Admin Password</h2>
+      <p className="admin-hint">
+        {status.enabled
+          ? 'Editing the blocklist, auto-start and quitting need the admin password.'
+          : 'Set a password so only you can edit the blocklist, auto-start or quit sybr.'}
+      </p>
+
+      {shownCode && (
+        <div className="admin-recovery">
+          Recovery code: <code>{shownCode}</code>
+          <div className="admin-hint">Write it down, it is shown only once and resets the password.</div>
+        </div>
+      )}
+
+      <input
+        type="password"
+        className="admin-input"
+        placeholder={status.enabled ? 'New password' : 'Password'}
+        value={password}
+        onChange={(e) => setPassword(e.target.value)}
+      />
+      <div className="session-actions">
+        <button onClick={handleSet} className="btn btn-primary" disabled={!password}>
+          {status.enabled ? 'Change Password' : 'Set Password'}
+        </button>
+        {status.enabled && (
+          <button onClick={handleClear} className="btn btn-danger">
+            Remove Password
+          </button>
+        )}
+      </div>
+
+      {status.enabled && (
+        <div className="admin-reset">
+          <input
+            className="admin-input"
+            placeholder="Recovery code"
+            value={recoveryCode}
+            onChange={(e) => setRecoveryCode(e.target.value)}
+          />
+          <button onClick={handleReset} className="btn btn-secondary" disabled={!recoveryCode || !password}>
+            Reset with Recovery Code
+          </button>
+        </div>
+      )}
+
+      {error && <div className="session-error">{error}</div>}
+    </div>
+  )
+}
+
+export default AdminSettings
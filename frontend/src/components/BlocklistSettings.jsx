@@ -1,5 +1,7 @@
 import React, { useState, useEffect } from 'react'
+import { EventsOn } from '../wailsjs/runtime/runtime'
 import './BlocklistSettings.css'
+import { withAdminToken } from '../adminAuth'
 
 function BlocklistSettings() {
   console.log('🎨 BlocklistSettings component rendering')
@@ -9,13 +11,52 @@ function BlocklistSettings() {
   const [newDisplayName, setNewDisplayName] = useState('')
   const [loading, setLoading] = useState(false)
   const [error, setError] = useState('')
+  const [pending, setPending] = useState([])
+  const [runningApps, setRunningApps] = useState(null)
+  const [pickedPid, setPickedPid] = useState('')
+  const [newDomain, setNewDomain] = useState('')
 
   // Load blocklist on mount
   useEffect(() => {
     console.log('🔄 BlocklistSettings useEffect - loading blocklist on mount')
     loadBlocklist()
+    loadPending()
+
+    // Delayed removals are applied by the backend when their time is up
+    const unsubscribe = EventsOn('pending-changed', (event) => {
+      console.log('📡 pending-changed:', event)
+      loadPending()
+      loadBlocklist()
+    })
+    return () => {
+      if (typeof unsubscribe === 'function') {
+        unsubscribe()
+      }
+    }
   }, [])
 
+  const loadPending = async () => {
+    try {
+      if (window.go?.main?.App?.GetPendingChanges) {
+        const changes = await window.go.main.App.GetPendingChanges()
+        setPending(Array.isArray(changes) ? changes : [])
+      }
+    } catch (err) {
+      console.error('❌ Error loading pending changes:', err)
+    }
+  }
+
+  const handleCancelPending = async (id) => {
+    setError('')
+    try {
+      await window.go.main.App.CancelPendingChange(id)
+      await loadPending()
+    } catch (err) {
+      console.error('Error cancelling pending change:', err)
+      setError(err.message || String(err))
+    }
+  }
+
   const loadBlocklist = async () => {
     try {
       console.log('🔍 Loading blocklist...')
@@ -87,7 +128,7 @@ function BlocklistSettings() {
         // Call the method with timeout protection
         let addResult
         try {
-          const addPromise = window.go.main.App.AddToBlocklist(addedName, addedDisplayName)
+          const addPromise = withAdminToken((token) => window.go.main.App.AddToBlocklist(addedName, addedDisplayName, token))
           const timeoutPromise = new Promise((_, reject) => 
             setTimeout(() => reject(new Error('AddToBlocklist timed out after 5 seconds')), 5000)
           )
@@ -149,19 +190,97 @@ function BlocklistSettings() {
 
     try {
       if (window.go?.main?.App?.RemoveFromBlocklist) {
-        await window.go.main.App.RemoveFromBlocklist(executableName)
+        await withAdminToken((token, partnerCode) => window.go.main.App.RemoveFromBlocklist(executableName, token, partnerCode))
         await loadBlocklist()
+        await loadPending()
       } else {
         setError('Wails bindings not available')
       }
     } catch (err) {
       console.error('Error removing from blocklist:', err)
-      setError(err.message || 'Failed to remove app from blocklist')
+      setError(err.message || String(err) || 'Failed to remove app from blocklist')
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  const handleShowRunning = async () => {
+    setError('')
+    try {
+      const apps = await window.go.main.App.GetRunningApps()
+      setRunningApps(Array.isArray(apps) ? apps : [])
+      setPickedPid('')
+    } catch (err) {
+      console.error('Error listing running apps:', err)
+      setError(err.message || String(err))
+    }
+  }
+
+  // Blocking from the running apps pins the binary's hash, so renaming it doesn't help
+  const handleAddRunning = async () => {
+    if (!pickedPid) {
+      return
+    }
+    setLoading(true)
+    setError('')
+    try {
+      await withAdminToken((token) => window.go.main.App.AddRunningApp(Number(pickedPid), newDisplayName.trim(), token))
+      setRunningApps(null)
+      setNewDisplayName('')
+      await loadBlocklist()
+    } catch (err) {
+      console.error('Error adding running app:', err)
+      setError(err.message || String(err))
     } finally {
       setLoading(false)
     }
   }
 
+  // Websites are blocked in browsers that report their tabs through the extension
+  const handleAddDomain = async () => {
+    if (!newDomain.trim()) {
+      return
+    }
+    setLoading(true)
+    setError('')
+    try {
+      await withAdminToken((token) => window.go.main.App.AddDomainToBlocklist(newDomain.trim(), newDisplayName.trim(), token))
+      setNewDomain('')
+      setNewDisplayName('')
+      await loadBlocklist()
+    } catch (err) {
+      console.error('Error adding website:', err)
+      setError(err.message || String(err))
+    } finally {
+      setLoading(false)
+    }
+  }
+
+  const handleScopeChange = async (executableName, scope) => {
+    setError('')
+    try {
+      await withAdminToken((token, partnerCode) => window.go.main.App.SetBlocklistScope(executableName, scope, token, partnerCode))
+      await loadBlocklist()
+      await loadPending()
+    } catch (err) {
+      console.error('Error changing scope:', err)
+      setError(err.message || String(err))
+    }
+  }
+
+  const pendingLabel = (kind) => {
+    switch (kind) {
+      case 'remove-app':
+        return 'Unblock'
+      case 'narrow-scope':
+        return 'Narrow scope of'
+      case 'narrow-platforms':
+        return 'Limit platforms of'
+      default:
+        return 'Narrow profiles of'
+    }
+  }
+
   return (
     <div className="blocklist-settings">
       <h2>Focus Blocker</h2>
@@ -179,7 +298,7 @@ function BlocklistSettings() {
         <div className="blocklist-input-group">
           <input
             type="text"
-            placeholder="Executable name (e.g., chrome.exe)"
+            placeholder="Executable name (e.g., chrome.exe or firefox)"
             value={newAppName}
             onChange={(e) => {
               console.log('📝 Input changed:', e.target.value)
@@ -227,6 +346,49 @@ function BlocklistSettings() {
             {loading ? 'Adding...' : 'Add'}
           </button>
         </div>
+        <div className="blocklist-input-group blocklist-pick">
+          <input
+            type="text"
+            placeholder="Website (e.g., youtube.com), needs the browser extension"
+            value={newDomain}
+            onChange={(e) => setNewDomain(e.target.value)}
+            onKeyPress={(e) => {
+              if (e.key === 'Enter') {
+                handleAddDomain()
+              }
+            }}
+            className="blocklist-input"
+            disabled={loading}
+          />
+          <button onClick={handleAddDomain} className="btn btn-primary" disabled={loading || !newDomain.trim()}>
+            Block website
+          </button>
+        </div>
+        {runningApps === null ? (
+          <button onClick={handleShowRunning} className="btn btn-secondary btn-small blocklist-pick" disabled={loading}>
+            Pick a running app
+          </button>
+        ) : (
+          <div className="blocklist-input-group blocklist-pick">
+            <select
+              value={pickedPid}
+              onChange={(e) => setPickedPid(e.target.value)}
+              className="blocklist-input"
+              disabled={loading}
+            >
+              <option value="">Select a running app...</option>
+              {runningApps.map((p) => (
+                <option key={p.pid} value={p.pid} title={p.path}>{p.appId || p.name}</option>
+              ))}
+            </select>
+            <button onClick={handleAddRunning} className="btn btn-primary" disabled={loading || !pickedPid}>
+              Block
+            </button>
+            <button onClick={() => setRunningApps(null)} className="btn btn-secondary" disabled={loading}>
+              Cancel
+            </button>
+          </div>
+        )}
       </div>
 
       <div className="blocklist-list">
@@ -247,12 +409,30 @@ function BlocklistSettings() {
                 <div key={index} className="blocklist-item">
                   <div className="blocklist-item-info">
                     <div className="blocklist-item-name">{displayName}</div>
-                    <div className="blocklist-item-exe">{executableName}</div>
+                    <div className="blocklist-item-exe">
+                      {executableName}
+                      {app.kind === 'domain' && ' · website'}
+                      {app.platforms?.length > 0 && ` · ${app.platforms.join(', ')} only`}
+                      {app.hashes?.length > 0 && ` · ${app.hashes.length} binary hash${app.hashes.length > 1 ? 'es' : ''} pinned`}
+                    </div>
                   </div>
+                  {app.kind !== 'domain' && (
+                  <select
+                    value={app.scope || 'process'}
+                    onChange={(e) => handleScopeChange(executableName, e.target.value)}
+                    className="blocklist-scope"
+                    disabled={loading}
+                    title="Whether the block also covers the processes this app starts"
+                  >
+                    <option value="process">This app</option>
+                    <option value="tree">App and what it launches</option>
+                    <option value="launched">Only what it launches</option>
+                  </select>
+                  )}
                   <button
                     onClick={() => handleRemove(executableName)}
                     className="btn btn-danger btn-small"
-                    disabled={loading}
+                    disabled={loading || pending.some((change) => change.kind === 'remove-app' && change.executableName === executableName)}
                   >
                     Remove
                   </button>
@@ -262,6 +442,32 @@ function BlocklistSettings() {
           </div>
         )}
       </div>
+
+      {pending.length > 0 && (
+        <div className="blocklist-list">
+          <h3>Pending Changes ({pending.length})</h3>
+          <div className="blocklist-items">
+            {pending.map((change) => (
+              <div key={change.id} className="blocklist-item">
+                <div className="blocklist-item-info">
+                  <div className="blocklist-item-name">
+                    {pendingLabel(change.kind)} {change.executableName}
+                  </div>
+                  <div className="blocklist-item-exe">
+                    Takes effect {new Date(change.effectiveAt).toLocaleString()}
+                  </div>
+                </div>
+                <button
+                  onClick={() => handleCancelPending(change.id)}
+                  className="btn btn-secondary btn-small"
+                >
+                  Cancel
+                </button>
+              </div>
+            ))}
+          </div>
+        </div>
+      )}
     </div>
   )
 }
@@ -0,0 +1,91 @@
+import React, { useState } from 'react'
+import './EmergencyUnlock.css'
+
+// EmergencyUnlock shows the passage from the backend and sends back what was typed.
+// The backend checks the passage; pasting is blocked here only as a courtesy
+function EmergencyUnlock({ onUnlocked }) {
+  const [challenge, setChallenge] = useState(null)
+  const [typed, setTyped] = useState('')
+  const [error, setError] = useState('')
+  const [busy, setBusy] = useState(false)
+
+  const handleStart = async () => {
+    setError('')
+    try {
+      const next = await window.go.main.App.StartEmergencyUnlock()
+      console.log('🆘 Emergency unlock started:', next.id)
+      setChallenge(next)
+      setTyped('')
+    } catch (err) {
+      console.error('❌ Error starting emergency unlock:', err)
+      setError(String(err))
+    }
+  }
+
+  const handleSubmit = async () => {
+    setBusy(true)
+    setError('')
+    try {
+      await window.go.main.App.SubmitEmergencyUnlock(challenge.id, typed)
+      console.log('✅ Emergency unlock accepted')
+      setChallenge(null)
+      setTyped('')
+      if (onUnlocked) {
+        onUnlocked()
+      }
+    } catch (err) {
+      console.error('❌ Emergency unlock refused:', err)
+      setError(String(err))
+    } finally {
+      setBusy(false)
+    }
+  }
+
+  if (!challenge) {
+    return (
+      <div className="emergency-unlock">
+        <button onClick={handleStart} className="btn btn-danger btn-small">
+          Emergency Unlock
+        </button>
+        {error && <div className="session-error">{error}</div>}
+      </div>
+    )
+  }
+
+  return (
+    <div className="emergency-unlock">
+      <p className="emergency-hint">
+        Type this passage exactly to lift the lock. The unlock will be logged.
+      </p>
+      <div className="emergency-passage">{challenge.passage}</div>
+      <textarea
+        className="emergency-input"
+        value={typed}
+        onChange={(e) => setTyped(e.target.value)}
+        onPaste={(e) => e.preventDefault()}
+        onDrop={(e) => e.preventDefault()}
+        spellCheck={false}
+        autoComplete="off"
+        rows={5}
+      />
+      <div className="emergency-progress">
+        {typed.length} / {challenge.passage.length} characters
+      </div>
+      <div className="session-actions">
+        <button onClick={() => setChallenge(null)} className="btn btn-secondary">
+          Cancel
+        </button>
+        <button
+          onClick={handleSubmit}
+          className="btn btn-danger"
+          disabled={busy || typed.length !== challenge.passage.length}
+        >
+          Unlock
+        </button>
+      </div>
+      {error && <div className="session-error">{error}</div>}
+    </div>
+  )
+}
+
+export default EmergencyUnlock
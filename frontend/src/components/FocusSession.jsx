@@ -0,0 +1,206 @@
+import React, { useState, useEffect } from 'react'
+import { EventsOn } from '../wailsjs/runtime/runtime'
+import EmergencyUnlock from './EmergencyUnlock'
+import { withAdminToken } from '../adminAuth'
+import './FocusSession.css'
+
+const STATE_LABELS = {
+  idle: 'Not in a session',
+  focusing: 'Focusing',
+  short_break: 'Short break',
+  long_break: 'Long break',
+}
+
+// formatRemaining turns seconds into mm:ss
+const formatRemaining = (seconds) => {
+  const safe = Math.max(0, seconds)
+  const minutes = Math.floor(safe / 60)
+  return `${minutes}:${String(safe % 60).padStart(2, '0')}`
+}
+
+function FocusSession() {
+  const [status, setStatus] = useState({ state: 'idle' })
+  const [minutes, setMinutes] = useState('')
+  const [remaining, setRemaining] = useState(0)
+  const [error, setError] = useState('')
+  const [lock, setLock] = useState({ locked: false })
+  const [lockMinutes, setLockMinutes] = useState('')
+  const [partner, setPartner] = useState({ enabled: false })
+
+  const loadLock = async () => {
+    try {
+      if (window.go?.main?.App?.GetLockStatus) {
+        setLock(await window.go.main.App.GetLockStatus())
+      }
+      if (window.go?.main?.App?.GetPartnerStatus) {
+        setPartner(await window.go.main.App.GetPartnerStatus())
+      }
+    } catch (err) {
+      console.error('❌ Error loading lock status:', err)
+    }
+  }
+
+  // Load the current status and follow 'session-changed' events from the backend
+  useEffect(() => {
+    const load = async () => {
+      try {
+        if (window.go?.main?.App?.GetSessionStatus) {
+          const current = await window.go.main.App.GetSessionStatus()
+          console.log('⏱️ Session status:', current)
+          setStatus(current)
+        }
+      } catch (err) {
+        console.error('❌ Error loading session status:', err)
+      }
+    }
+    load()
+    loadLock()
+
+    const unsubscribe = EventsOn('session-changed', (event) => {
+      console.log('📡 session-changed:', event)
+      if (event?.payload?.status) {
+        setStatus(event.payload.status)
+      }
+      loadLock()
+    })
+    return () => {
+      if (typeof unsubscribe === 'function') {
+        unsubscribe()
+      }
+    }
+  }, [])
+
+  // Count down locally between events
+  useEffect(() => {
+    if (status.state === 'idle' || !status.endsAt) {
+      setRemaining(0)
+      return
+    }
+    const endsAt = new Date(status.endsAt).getTime()
+    const tick = () => setRemaining(Math.ceil((endsAt - Date.now()) / 1000))
+    tick()
+    const interval = setInterval(tick, 1000)
+    return () => clearInterval(interval)
+  }, [status])
+
+  const call = async (method, ...args) => {
+    setError('')
+    try {
+      if (!window.go?.main?.App?.[method]) {
+        setError(`${method} not available`)
+        return
+      }
+      await window.go.main.App[method](...args)
+      loadLock()
+    } catch (err) {
+      console.error(`❌ Error calling ${method}:`, err)
+      setError(String(err))
+    }
+  }
+
+  const handleStart = () => {
+    const length = parseInt(minutes, 10)
+    call('StartFocusSession', Number.isNaN(length) ? 0 : length)
+  }
+
+  const handleLock = () => {
+    const length = parseInt(lockMinutes, 10)
+    if (Number.isNaN(length) || length <= 0) {
+      setError('Please enter the lock length in minutes')
+      return
+    }
+    if (window.confirm(`Lock the blocklist for ${length} minutes? Blocks can't be removed until it ends.`)) {
+      call('LockFor', length)
+      setLockMinutes('')
+    }
+  }
+
+  // With an accountability partner only their code lifts the lock
+  const handlePartnerUnlock = async () => {
+    setError('')
+    try {
+      await withAdminToken((token, code) => window.go.main.App.LiftLockWithPartnerCode(code))
+      console.log('✅ Lock lifted with partner code')
+    } catch (err) {
+      console.error('❌ Error lifting lock:', err)
+      setError(err.message || String(err))
+    }
+    loadLock()
+  }
+
+  const running = status.state !== 'idle'
+
+  return (
+    <div className="focus-session">
+      <h2>Focus Session</h2>
+
+      <div className={`session-state session-${status.state}`}>
+        <span className="session-label">{STATE_LABELS[status.state] || status.state}</span>
+        {running && (
+          <>
+            <span className="session-remaining">{formatRemaining(remaining)}</span>
+            <span className="session-cycle">
+              Cycle {status.cycle}{status.totalCycles > 0 ? ` of ${status.totalCycles}` : ''}
+              {status.profile ? ` · profile "${status.profile}"` : ''}
+            </span>
+          </>
+        )}
+      </div>
+
+      {running ? (
+        <div className="session-actions">
+          <button onClick={() => call('SkipSessionPhase')} className="btn btn-secondary">
+            Skip
+          </button>
+          <button onClick={() => call('StopFocusSession')} className="btn btn-danger">
+            Stop
+          </button>
+        </div>
+      ) : (
+        <div className="session-actions">
+          <input
+            type="number"
+            min="1"
+            placeholder="Minutes (default)"
+            value={minutes}
+            onChange={(e) => setMinutes(e.target.value)}
+            className="session-input"
+          />
+          <button onClick={handleStart} className="btn btn-primary">
+            Start
+          </button>
+        </div>
+      )}
+
+      <div className={`session-lock ${lock.locked ? 'session-lock-active' : ''}`}>
+        {lock.locked ? `🔒 Locked ${lock.description}` : '🔓 Blocklist unlocked'}
+      </div>
+      <div className="session-actions">
+        <input
+          type="number"
+          min="1"
+          placeholder="Lock minutes"
+          value={lockMinutes}
+          onChange={(e) => setLockMinutes(e.target.value)}
+          className="session-input"
+        />
+        <button onClick={handleLock} className="btn btn-secondary">
+          {lock.locked ? 'Extend Lock' : 'Lock'}
+        </button>
+      </div>
+      {lock.locked && partner.enabled && (
+        <div className="session-actions">
+          <button onClick={handlePartnerUnlock} className="btn btn-danger btn-small">
+            Unlock with Partner Code
+          </button>
+        </div>
+      )}
+      {lock.locked && !partner.enabled && <EmergencyUnlock onUnlocked={loadLock} />}
+
+      {error && <div className="session-error">{error}</div>}
+      <p className="session-hint">Ctrl+Alt+F starts or stops a session, Ctrl+Alt+S skips the current phase.</p>
+    </div>
+  )
+}
+
+export default FocusSession
@@ -51,9 +51,18 @@ function HistoryLog({ history, onClear }) {
                 ) : (
                   <>
                     <div className="history-window-title">{entry.title || 'Unknown'}</div>
-                    <div className="history-window-exe">{entry.exe || '-'}</div>
+                    <div className="history-window-exe">{entry.appId || entry.exe || '-'}{entry.domain && ` @ ${entry.domain}`}</div>
                   </>
                 )}
+                {entry.foreground && (
+                  <div className="history-foreground">Running {entry.foreground.command || entry.foreground.exe}</div>
+                )}
+                {entry.project && (
+                  <div className="history-project">Project {entry.project}</div>
+                )}
+                {entry.launcher && (
+                  <div className="history-launcher">Launched by {entry.launcher}</div>
+                )}
               </div>
             ))}
           </div>
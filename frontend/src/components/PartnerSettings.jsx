@@ -0,0 +1,105 @@
+import React, { useEffect, useState } from 'react'
+import './AdminSettings.css'
+import { withAdminToken } from '../adminAuth'
+
+// PartnerSettings sets up an accountability partner whose authenticator app
+// codes are needed to lift the lock or remove blocks
+function PartnerSettings() {
+  const [status, setStatus] = useState({ enabled: false, pending: false })
+  const [setup, setSetup] = useState(null)
+  const [code, setCode] = useState('')
+  const [error, setError] = useState('')
+
+  const loadStatus = async () => {
+    try {
+      setStatus(await window.go.main.App.GetPartnerStatus())
+    } catch (err) {
+      console.error('❌ Error loading partner status:', err)
+    }
+  }
+
+  useEffect(() => {
+    loadStatus()
+  }, [])
+
+  const run = async (action) => {
+    setError('')
+    try {
+      await action()
+    } catch (err) {
+      console.error('❌ Partner action failed:', err)
+      setError(err.message || String(err))
+    } finally {
+      await loadStatus()
+    }
+  }
+
+  const handleSetup = () => run(async () => {
+    const next = await withAdminToken((token, partnerCode) => window.go.main.App.SetupPartnerCode(token, partnerCode))
+    setSetup(next)
+    setCode('')
+  })
+
+  const handleConfirm = () => run(async () => {
+    await window.go.main.App.ConfirmPartnerCode(code)
+    console.log('✅ Accountability partner set up')
+    // The secret is gone from the screen for good
+    setSetup(null)
+    setCode('')
+  })
+
+  const handleRemove = () => run(async () => {
+    await withAdminToken((token, partnerCode) => window.go.main.App.RemovePartnerCode(partnerCode))
+  })
+
+  return (
+    <div className="admin-settings">
+      <h2>Accountability Partner</h2>
+      <p className="admin-hint">
+        {status.enabled
+          ? 'Lifting the lock and removing blocks need a code from your partner\'s authenticator app.'
+          : 'Let someone else hold the key: their authenticator app codes will be needed to lift the lock or remove blocks.'}
+      </p>
+
+      {setup ? (
+        <div className="admin-recovery">
+          <div className="admin-hint">
+            Have your partner scan this with their authenticator app. It is shown only once.
+          </div>
+          <img src={setup.qrCode} alt="Partner setup QR code" className="partner-qr" />
+          <code className="partner-uri">{setup.uri}</code>
+          <input
+            className="admin-input"
+            inputMode="numeric"
+            placeholder="Code from their app"
+            value={code}
+            onChange={(e) => setCode(e.target.value)}
+          />
+          <div className="session-actions">
+            <button onClick={() => setSetup(null)} className="btn btn-secondary">
+              Cancel
+            </button>
+            <button onClick={handleConfirm} className="btn btn-primary" disabled={!code}>
+              Confirm
+            </button>
+          </div>
+        </div>
+      ) : (
+        <div className="session-actions">
+          <button onClick={handleSetup} className="btn btn-primary">
+            {status.enabled ? 'Replace Partner' : 'Set Up Partner'}
+          </button>
+          {status.enabled && (
+            <button onClick={handleRemove} className="btn btn-danger">
+              Remove Partner
+            </button>
+          )}
+        </div>
+      )}
+
+      {error && <div className="session-error">{error}</div>}
+    </div>
+  )
+}
+
+export default PartnerSettings
@@ -69,6 +69,11 @@ function WarningModal() {
                 Window: {warningData.title}
               </div>
             )}
+            {warningData.launchedBy && (
+              <div className="warning-app-window">
+                Launched by: {warningData.launchedBy}
+              </div>
+            )}
           </div>
           <p className="warning-question">
             Are you sure you want to continue?
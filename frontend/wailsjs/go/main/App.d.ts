@@ -3,28 +3,100 @@
 import {main} from '../models';
 import {context} from '../models';
 
-export function AddToBlocklist(arg1:string,arg2:string):Promise<void>;
+export function AddDomainToBlocklist(arg1:string,arg2:string,arg3:string):Promise<void>;
 
-export function DisableAutoStart():Promise<void>;
+export function AddRunningApp(arg1:number,arg2:string,arg3:string):Promise<void>;
 
-export function EnableAutoStart():Promise<void>;
+export function AddToBlocklist(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function AuthenticateAdmin(arg1:string):Promise<main.AuthToken>;
+
+export function CancelPendingChange(arg1:string):Promise<void>;
+
+export function ClearAdminPassword(arg1:string):Promise<void>;
+
+export function ConfirmPartnerCode(arg1:string):Promise<void>;
+
+export function DisableAutoStart(arg1:string):Promise<void>;
+
+export function EnableAutoStart(arg1:string):Promise<void>;
+
+export function GetAdminStatus():Promise<main.AdminStatus>;
 
 export function GetBlocklist():Promise<Array<main.BlockedApp>>;
 
+export function GetBrowserTabs():Promise<Array<main.BrowserTab>>;
+
 export function GetCurrentWindow():Promise<main.WindowInfo>;
 
+export function GetDistractionAttempts():Promise<Array<main.DistractionAttempt>>;
+
+export function GetEmergencyUnlocks():Promise<Array<main.EmergencyUnlockEntry>>;
+
+export function GetLockStatus():Promise<main.LockStatus>;
+
+export function GetPartnerStatus():Promise<main.PartnerStatus>;
+
+export function GetPendingChanges():Promise<Array<main.PendingChange>>;
+
+export function GetRunningApps():Promise<Array<main.ProcessInfo>>;
+
+export function GetSessionConfig():Promise<main.SessionConfig>;
+
+export function GetSessionStatus():Promise<main.SessionStatus>;
+
+export function GetSnoozes():Promise<{[key: string]: any}>;
+
+export function GetTamperEvents():Promise<Array<main.TamperEvent>>;
+
 export function HideWindow():Promise<void>;
 
 export function IsAutoStartEnabled():Promise<boolean>;
 
+export function LiftLockWithPartnerCode(arg1:string):Promise<void>;
+
+export function LockFor(arg1:number):Promise<void>;
+
 export function OnStartup(arg1:context.Context):Promise<void>;
 
 export function OnWindowChanged(arg1:any):Promise<void>;
 
-export function RemoveFromBlocklist(arg1:string):Promise<void>;
+export function PinBlocklistHash(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function Quit(arg1:string):Promise<void>;
+
+export function RemoveFromBlocklist(arg1:string,arg2:string,arg3:string):Promise<void>;
+
+export function RemovePartnerCode(arg1:string):Promise<void>;
+
+export function ResetAdminPassword(arg1:string,arg2:string):Promise<string>;
+
+export function SetAdminPassword(arg1:string,arg2:string):Promise<string>;
+
+export function SetBlocklistPlatforms(arg1:string,arg2:Array<string>,arg3:string,arg4:string):Promise<void>;
+
+export function SetBlocklistProfiles(arg1:string,arg2:Array<string>,arg3:string,arg4:string):Promise<void>;
+
+export function SetBlocklistScope(arg1:string,arg2:string,arg3:string,arg4:string):Promise<void>;
+
+export function SetSessionConfig(arg1:main.SessionConfig,arg2:string):Promise<void>;
+
+export function SetupPartnerCode(arg1:string,arg2:string):Promise<main.PartnerSetup>;
 
 export function ShowSystemWarning(arg1:string,arg2:string):Promise<void>;
 
 export function ShowWindow():Promise<void>;
 
+export function SkipSessionPhase():Promise<void>;
+
+export function SnoozeApp(arg1:string,arg2:number,arg3:string,arg4:string):Promise<void>;
+
+export function StartEmergencyUnlock():Promise<main.EmergencyChallenge>;
+
+export function StartFocusSession(arg1:number):Promise<void>;
+
+export function StopFocusSession():Promise<void>;
+
 export function StopMonitoring():Promise<void>;
+
+export function SubmitEmergencyUnlock(arg1:string,arg2:string):Promise<void>;
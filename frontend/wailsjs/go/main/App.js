@@ -2,26 +2,98 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
-export function AddToBlocklist(arg1, arg2) {
-  return window['go']['main']['App']['AddToBlocklist'](arg1, arg2);
+export function AddDomainToBlocklist(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AddDomainToBlocklist'](arg1, arg2, arg3);
 }
 
-export function DisableAutoStart() {
-  return window['go']['main']['App']['DisableAutoStart']();
+export function AddRunningApp(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AddRunningApp'](arg1, arg2, arg3);
 }
 
-export function EnableAutoStart() {
-  return window['go']['main']['App']['EnableAutoStart']();
+export function AddToBlocklist(arg1, arg2, arg3) {
+  return window['go']['main']['App']['AddToBlocklist'](arg1, arg2, arg3);
+}
+
+export function AuthenticateAdmin(arg1) {
+  return window['go']['main']['App']['AuthenticateAdmin'](arg1);
+}
+
+export function CancelPendingChange(arg1) {
+  return window['go']['main']['App']['CancelPendingChange'](arg1);
+}
+
+export function ClearAdminPassword(arg1) {
+  return window['go']['main']['App']['ClearAdminPassword'](arg1);
+}
+
+export function ConfirmPartnerCode(arg1) {
+  return window['go']['main']['App']['ConfirmPartnerCode'](arg1);
+}
+
+export function DisableAutoStart(arg1) {
+  return window['go']['main']['App']['DisableAutoStart'](arg1);
+}
+
+export function EnableAutoStart(arg1) {
+  return window['go']['main']['App']['EnableAutoStart'](arg1);
+}
+
+export function GetAdminStatus() {
+  return window['go']['main']['App']['GetAdminStatus']();
 }
 
 export function GetBlocklist() {
   return window['go']['main']['App']['GetBlocklist']();
 }
 
+export function GetBrowserTabs() {
+  return window['go']['main']['App']['GetBrowserTabs']();
+}
+
 export function GetCurrentWindow() {
   return window['go']['main']['App']['GetCurrentWindow']();
 }
 
+export function GetDistractionAttempts() {
+  return window['go']['main']['App']['GetDistractionAttempts']();
+}
+
+export function GetEmergencyUnlocks() {
+  return window['go']['main']['App']['GetEmergencyUnlocks']();
+}
+
+export function GetLockStatus() {
+  return window['go']['main']['App']['GetLockStatus']();
+}
+
+export function GetPartnerStatus() {
+  return window['go']['main']['App']['GetPartnerStatus']();
+}
+
+export function GetPendingChanges() {
+  return window['go']['main']['App']['GetPendingChanges']();
+}
+
+export function GetRunningApps() {
+  return window['go']['main']['App']['GetRunningApps']();
+}
+
+export function GetSessionConfig() {
+  return window['go']['main']['App']['GetSessionConfig']();
+}
+
+export function GetSessionStatus() {
+  return window['go']['main']['App']['GetSessionStatus']();
+}
+
+export function GetSnoozes() {
+  return window['go']['main']['App']['GetSnoozes']();
+}
+
+export function GetTamperEvents() {
+  return window['go']['main']['App']['GetTamperEvents']();
+}
+
 export function HideWindow() {
   return window['go']['main']['App']['HideWindow']();
 }
@@ -30,6 +102,14 @@ export function IsAutoStartEnabled() {
   return window['go']['main']['App']['IsAutoStartEnabled']();
 }
 
+export function LiftLockWithPartnerCode(arg1) {
+  return window['go']['main']['App']['LiftLockWithPartnerCode'](arg1);
+}
+
+export function LockFor(arg1) {
+  return window['go']['main']['App']['LockFor'](arg1);
+}
+
 export function OnStartup(arg1) {
   return window['go']['main']['App']['OnStartup'](arg1);
 }
@@ -38,8 +118,48 @@ export function OnWindowChanged(arg1) {
   return window['go']['main']['App']['OnWindowChanged'](arg1);
 }
 
-export function RemoveFromBlocklist(arg1) {
-  return window['go']['main']['App']['RemoveFromBlocklist'](arg1);
+export function PinBlocklistHash(arg1, arg2, arg3) {
+  return window['go']['main']['App']['PinBlocklistHash'](arg1, arg2, arg3);
+}
+
+export function Quit(arg1) {
+  return window['go']['main']['App']['Quit'](arg1);
+}
+
+export function RemoveFromBlocklist(arg1, arg2, arg3) {
+  return window['go']['main']['App']['RemoveFromBlocklist'](arg1, arg2, arg3);
+}
+
+export function RemovePartnerCode(arg1) {
+  return window['go']['main']['App']['RemovePartnerCode'](arg1);
+}
+
+export function ResetAdminPassword(arg1, arg2) {
+  return window['go']['main']['App']['ResetAdminPassword'](arg1, arg2);
+}
+
+export function SetAdminPassword(arg1, arg2) {
+  return window['go']['main']['App']['SetAdminPassword'](arg1, arg2);
+}
+
+export function SetBlocklistPlatforms(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SetBlocklistPlatforms'](arg1, arg2, arg3, arg4);
+}
+
+export function SetBlocklistProfiles(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SetBlocklistProfiles'](arg1, arg2, arg3, arg4);
+}
+
+export function SetBlocklistScope(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SetBlocklistScope'](arg1, arg2, arg3, arg4);
+}
+
+export function SetSessionConfig(arg1, arg2) {
+  return window['go']['main']['App']['SetSessionConfig'](arg1, arg2);
+}
+
+export function SetupPartnerCode(arg1, arg2) {
+  return window['go']['main']['App']['SetupPartnerCode'](arg1, arg2);
 }
 
 export function ShowSystemWarning(arg1, arg2) {
@@ -50,6 +170,30 @@ export function ShowWindow() {
   return window['go']['main']['App']['ShowWindow']();
 }
 
+export function SkipSessionPhase() {
+  return window['go']['main']['App']['SkipSessionPhase']();
+}
+
+export function SnoozeApp(arg1, arg2, arg3, arg4) {
+  return window['go']['main']['App']['SnoozeApp'](arg1, arg2, arg3, arg4);
+}
+
+export function StartEmergencyUnlock() {
+  return window['go']['main']['App']['StartEmergencyUnlock']();
+}
+
+export function StartFocusSession(arg1) {
+  return window['go']['main']['App']['StartFocusSession'](arg1);
+}
+
+export function StopFocusSession() {
+  return window['go']['main']['App']['StopFocusSession']();
+}
+
 export function StopMonitoring() {
   return window['go']['main']['App']['StopMonitoring']();
 }
+
+export function SubmitEmergencyUnlock(arg1, arg2) {
+  return window['go']['main']['App']['SubmitEmergencyUnlock'](arg1, arg2);
+}
@@ -1,8 +1,43 @@
 export namespace main {
 	
+	export class AdminStatus {
+	    enabled: boolean;
+	    // Go type: time
+	    blockedUntil: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new AdminStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.blockedUntil = source["blockedUntil"];
+	    }
+	}
+	export class AuthToken {
+	    token: string;
+	    // Go type: time
+	    expiresAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuthToken(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.token = source["token"];
+	        this.expiresAt = source["expiresAt"];
+	    }
+	}
 	export class BlockedApp {
 	    executableName: string;
 	    displayName: string;
+	    profiles?: string[];
+	    platforms?: string[];
+	    scope?: string;
+	    hashes?: string[];
+	    kind?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new BlockedApp(source);
@@ -12,11 +47,303 @@ export namespace main {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.executableName = source["executableName"];
 	        this.displayName = source["displayName"];
+	        this.profiles = source["profiles"];
+	        this.platforms = source["platforms"];
+	        this.scope = source["scope"];
+	        this.hashes = source["hashes"];
+	        this.kind = source["kind"];
+	    }
+	}
+	export class BrowserTab {
+	    browser: string;
+	    pid?: number;
+	    url: string;
+	    domain?: string;
+	    title?: string;
+	    // Go type: time
+	    time: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new BrowserTab(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.browser = source["browser"];
+	        this.pid = source["pid"];
+	        this.url = source["url"];
+	        this.domain = source["domain"];
+	        this.title = source["title"];
+	        this.time = source["time"];
+	    }
+	}
+	export class DistractionAttempt {
+	    // Go type: time
+	    time: any;
+	    host: string;
+	    method: string;
+	    executableName: string;
+	    displayName: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DistractionAttempt(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = source["time"];
+	        this.host = source["host"];
+	        this.method = source["method"];
+	        this.executableName = source["executableName"];
+	        this.displayName = source["displayName"];
+	    }
+	}
+	export class EmergencyChallenge {
+	    id: string;
+	    passage: string;
+	    // Go type: time
+	    createdAt: any;
+	    // Go type: time
+	    expiresAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new EmergencyChallenge(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.passage = source["passage"];
+	        this.createdAt = source["createdAt"];
+	        this.expiresAt = source["expiresAt"];
+	    }
+	}
+	export class EmergencyUnlockEntry {
+	    // Go type: time
+	    time: any;
+	    passageLength: number;
+	    tookSeconds: number;
+	    // Go type: time
+	    lockedUntil?: any;
+	    forSession: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new EmergencyUnlockEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = source["time"];
+	        this.passageLength = source["passageLength"];
+	        this.tookSeconds = source["tookSeconds"];
+	        this.lockedUntil = source["lockedUntil"];
+	        this.forSession = source["forSession"];
+	    }
+	}
+	export class LockStatus {
+	    locked: boolean;
+	    // Go type: time
+	    until: any;
+	    forSession: boolean;
+	    description: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new LockStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.locked = source["locked"];
+	        this.until = source["until"];
+	        this.forSession = source["forSession"];
+	        this.description = source["description"];
+	    }
+	}
+	export class PartnerSetup {
+	    secret: string;
+	    uri: string;
+	    qrCode: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PartnerSetup(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.secret = source["secret"];
+	        this.uri = source["uri"];
+	        this.qrCode = source["qrCode"];
+	    }
+	}
+	export class PartnerStatus {
+	    enabled: boolean;
+	    pending: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new PartnerStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.pending = source["pending"];
+	    }
+	}
+	export class PendingChange {
+	    id: string;
+	    kind: string;
+	    executableName: string;
+	    profiles?: string[];
+	    scope?: string;
+	    platforms?: string[];
+	    // Go type: time
+	    requestedAt: any;
+	    // Go type: time
+	    effectiveAt: any;
+	
+	    static createFrom(source: any = {}) {
+	        return new PendingChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.kind = source["kind"];
+	        this.executableName = source["executableName"];
+	        this.profiles = source["profiles"];
+	        this.scope = source["scope"];
+	        this.platforms = source["platforms"];
+	        this.requestedAt = source["requestedAt"];
+	        this.effectiveAt = source["effectiveAt"];
+	    }
+	}
+	export class ProcessInfo {
+	    pid: number;
+	    ppid: number;
+	    name: string;
+	    path?: string;
+	    appId?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ProcessInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pid = source["pid"];
+	        this.ppid = source["ppid"];
+	        this.name = source["name"];
+	        this.path = source["path"];
+	        this.appId = source["appId"];
+	    }
+	}
+	export class SessionConfig {
+	    focusMinutes: number;
+	    shortBreakMinutes: number;
+	    longBreakMinutes: number;
+	    cyclesBeforeLongBreak: number;
+	    totalCycles: number;
+	    focusProfile: string;
+	    shortBreakProfile: string;
+	    longBreakProfile: string;
+	    idleProfile: string;
+	    downtimePolicy: string;
+	    lockDuringSession: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.focusMinutes = source["focusMinutes"];
+	        this.shortBreakMinutes = source["shortBreakMinutes"];
+	        this.longBreakMinutes = source["longBreakMinutes"];
+	        this.cyclesBeforeLongBreak = source["cyclesBeforeLongBreak"];
+	        this.totalCycles = source["totalCycles"];
+	        this.focusProfile = source["focusProfile"];
+	        this.shortBreakProfile = source["shortBreakProfile"];
+	        this.longBreakProfile = source["longBreakProfile"];
+	        this.idleProfile = source["idleProfile"];
+	        this.downtimePolicy = source["downtimePolicy"];
+	        this.lockDuringSession = source["lockDuringSession"];
+	    }
+	}
+	export class SessionStatus {
+	    state: string;
+	    cycle: number;
+	    totalCycles: number;
+	    profile: string;
+	    // Go type: time
+	    startedAt: any;
+	    // Go type: time
+	    endsAt: any;
+	    remainingSeconds: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SessionStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.state = source["state"];
+	        this.cycle = source["cycle"];
+	        this.totalCycles = source["totalCycles"];
+	        this.profile = source["profile"];
+	        this.startedAt = source["startedAt"];
+	        this.endsAt = source["endsAt"];
+	        this.remainingSeconds = source["remainingSeconds"];
+	    }
+	}
+	export class TamperEvent {
+	    // Go type: time
+	    time: any;
+	    kind: string;
+	    pid: number;
+	    reason?: string;
+	    restarts: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TamperEvent(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.time = source["time"];
+	        this.kind = source["kind"];
+	        this.pid = source["pid"];
+	        this.reason = source["reason"];
+	        this.restarts = source["restarts"];
+	    }
+	}
+	export class TerminalJob {
+	    pid: number;
+	    exe: string;
+	    appId?: string;
+	    command?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new TerminalJob(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.pid = source["pid"];
+	        this.exe = source["exe"];
+	        this.appId = source["appId"];
+	        this.command = source["command"];
 	    }
 	}
 	export class WindowInfo {
 	    title: string;
 	    exe: string;
+	    pid?: number;
+	    appId?: string;
+	    launcher?: string;
+	    foreground: TerminalJob;
+	    url?: string;
+	    domain?: string;
+	    project?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new WindowInfo(source);
@@ -26,7 +353,32 @@ export namespace main {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.title = source["title"];
 	        this.exe = source["exe"];
+	        this.pid = source["pid"];
+	        this.appId = source["appId"];
+	        this.launcher = source["launcher"];
+	        this.foreground = this.convertValues(source["foreground"], TerminalJob);
+	        this.url = source["url"];
+	        this.domain = source["domain"];
+	        this.project = source["project"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 
 }
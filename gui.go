@@ -0,0 +1,291 @@
+//go:build !nogui
+
+package main
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"os"
+
+	"github.com/getlantern/systray"
+	"github.com/wailsapp/wails/v2"
+	"github.com/wailsapp/wails/v2/pkg/options"
+	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+//go:embed all:frontend/dist
+var assets embed.FS
+
+// guiAvailable reports whether this binary was built with the Wails window and systray
+const guiAvailable = true
+
+// runGUI starts the systray and runs the Wails window until the app quits
+func runGUI(app *App, watcher *WindowWatcher, launchArgs LaunchArgs, exePath string) error {
+	// Start systray in a goroutine
+	go runTray(exePath, watcher, false)
+
+	// Create application with options
+	// In Wails v2, we must explicitly bind the App struct using the Bind field
+	// This generates bindings that make methods available via window.go.main.App
+
+	// Check if we're in dev mode
+	// Wails v2 should automatically proxy to dev server when running wails dev
+	// But if it's not working, we can try to detect dev mode
+	isDev := os.Getenv("WAILS_ENV") == "dev" || os.Getenv("devmode") == "true"
+
+	assetServerOptions := &assetserver.Options{
+		Assets: assets,
+	}
+
+	// In dev mode, Wails should automatically proxy to Vite dev server
+	// Make sure Vite is running on http://localhost:34115 before starting Wails
+	if isDev {
+		fmt.Println("🔧 Dev mode detected - Wails should proxy to Vite dev server")
+		fmt.Println("🔧 Make sure Vite is running: cd frontend && npm run dev")
+	} else {
+		fmt.Println("📦 Production mode - using embedded assets")
+	}
+
+	return wails.Run(&options.App{
+		Title:            "Window Monitor",
+		Width:            1200,
+		Height:           800,
+		AssetServer:      assetServerOptions,
+		BackgroundColour: &options.RGBA{R: 18, G: 18, B: 18, A: 1},
+		// Bind the app instance - this is critical for frontend access
+		Bind: []interface{}{
+			app,
+		},
+		OnStartup: func(ctx context.Context) {
+			// Store context for systray menu
+			wailsCtx = ctx
+
+			// Call app's OnStartup - this will set the context on the watcher
+			// and start monitoring with the correct Wails context
+			app.OnStartup(ctx)
+
+			// Apply actions requested on our own command line
+			if launchArgs.HasActions() {
+				if err := applyLaunchArgs(app, launchArgs); err != nil {
+					fmt.Printf("⚠️  Failed to apply launch arguments: %v\n", err)
+				}
+			}
+		},
+		// Closing the window only hides it, quitting goes through App.Quit
+		OnBeforeClose: app.beforeClose,
+		OnShutdown: func(ctx context.Context) {
+			// Stop monitoring when app shuts down
+			if watcher != nil {
+				watcher.StopMonitoring()
+			}
+			// Quit systray
+			systray.Quit()
+		},
+	})
+}
+
+// quitApp stops monitoring and closes the window, the tray and headless mode
+func quitApp(app *App) {
+	if app != nil && app.watcher != nil {
+		app.watcher.StopMonitoring()
+	}
+	if wailsCtx != nil {
+		runtime.Quit(wailsCtx)
+	}
+	systray.Quit()
+	requestQuit()
+}
+
+// runTray runs the system tray until it is quit. It blocks, so the GUI runs it
+// in a goroutine while headless mode runs it on the main goroutine
+func runTray(exePath string, watcher *WindowWatcher, headless bool) {
+	systray.Run(func() {
+		setupSystemTray(exePath, watcher, headless)
+	}, func() {
+		// On exit, stop monitoring
+		if watcher != nil {
+			watcher.StopMonitoring()
+		}
+	})
+}
+
+func setupSystemTray(exePath string, watcher *WindowWatcher, headless bool) {
+	// Set up system tray icon and menu
+	// Only set icon if we have a valid one
+	if icon := getIcon(); icon != nil && len(icon) > 0 {
+		systray.SetIcon(icon)
+	}
+	systray.SetTitle("Window Monitor")
+	systray.SetTooltip("Window Monitor - Running")
+
+	// Create menu items
+	mStatus := systray.AddMenuItem("Window Monitor", "Window Monitor Status")
+	mStatus.Disable()
+	systray.AddSeparator()
+
+	mShowWindow := systray.AddMenuItem("Show Window", "Show the main window")
+	mHideWindow := systray.AddMenuItem("Hide Window", "Hide the main window")
+	if headless {
+		// There is no window to show in headless mode
+		mShowWindow.Hide()
+		mHideWindow.Hide()
+	}
+	systray.AddSeparator()
+
+	mStartSession := systray.AddMenuItem("Start Focus Session", "Start a focus session")
+	mSkipSession := systray.AddMenuItem("Skip Phase", "End the current focus or break early")
+	mStopSession := systray.AddMenuItem("Stop Session", "Stop the focus session")
+	mSkipSession.Hide()
+	mStopSession.Hide()
+	systray.AddSeparator()
+
+	mEnableAutoStart := systray.AddMenuItem("Enable Auto-Start", "Enable auto-start on Windows boot")
+	mDisableAutoStart := systray.AddMenuItem("Disable Auto-Start", "Disable auto-start on Windows boot")
+	systray.AddSeparator()
+
+	mQuit := systray.AddMenuItem("Quit", "Quit Window Monitor")
+
+	// Check auto-start status and update menu
+	updateAutoStartMenu(mEnableAutoStart, mDisableAutoStart)
+
+	// Keep the session items and tooltip in sync with the session engine
+	if globalApp != nil && globalApp.bus != nil {
+		sub := globalApp.bus.Subscribe(0, EventSessionChanged)
+		go func() {
+			for event := range sub.C {
+				if changed, ok := event.Payload.(SessionChangedEvent); ok {
+					updateSessionMenu(changed.Status, mStatus, mStartSession, mSkipSession, mStopSession)
+				}
+			}
+		}()
+	}
+
+	// Handle menu clicks
+	go func() {
+		for {
+			select {
+			case <-mShowWindow.ClickedCh:
+				if globalApp != nil {
+					globalApp.ShowWindow()
+				}
+			case <-mHideWindow.ClickedCh:
+				if globalApp != nil {
+					globalApp.HideWindow()
+				}
+			case <-mStartSession.ClickedCh:
+				if globalApp != nil {
+					if err := globalApp.StartFocusSession(0); err != nil {
+						fmt.Printf("Failed to start focus session: %v\n", err)
+					}
+				}
+			case <-mSkipSession.ClickedCh:
+				if globalApp != nil {
+					if err := globalApp.SkipSessionPhase(); err != nil {
+						fmt.Printf("Failed to skip session phase: %v\n", err)
+					}
+				}
+			case <-mStopSession.ClickedCh:
+				if globalApp != nil {
+					if err := globalApp.StopFocusSession(); err != nil {
+						fmt.Printf("Failed to stop focus session: %v\n", err)
+					}
+				}
+			case <-mEnableAutoStart.ClickedCh:
+				if globalApp != nil {
+					if err := globalApp.EnableAutoStart(""); err != nil {
+						fmt.Printf("Failed to enable auto-start: %v\n", err)
+						systray.SetTooltip("Window Monitor - Failed to enable auto-start")
+					} else {
+						fmt.Println("Auto-start enabled successfully")
+						systray.SetTooltip("Window Monitor - Auto-start Enabled")
+						updateAutoStartMenu(mEnableAutoStart, mDisableAutoStart)
+					}
+				}
+			case <-mDisableAutoStart.ClickedCh:
+				if globalApp == nil {
+					continue
+				}
+				if err := globalApp.DisableAutoStart(""); err != nil {
+					fmt.Printf("Failed to disable auto-start: %v\n", err)
+					systray.SetTooltip("Window Monitor - Failed to disable auto-start")
+				} else {
+					fmt.Println("Auto-start disabled successfully")
+					systray.SetTooltip("Window Monitor - Auto-start Disabled")
+					updateAutoStartMenu(mEnableAutoStart, mDisableAutoStart)
+				}
+			case <-mQuit.ClickedCh:
+				if globalApp != nil {
+					// The tray can't ask for the admin password, so with one set
+					// quitting has to go through the window or the CLI
+					if err := globalApp.Quit(""); err != nil {
+						systray.SetTooltip("Window Monitor - Can't quit right now")
+						go ShowSystemWarning("🔒 sybr", "sybr can't be quit from the tray right now:\n\n"+err.Error())
+						continue
+					}
+					return
+				}
+				if watcher != nil {
+					watcher.StopMonitoring()
+				}
+				quitApp(nil)
+				return
+			}
+		}
+	}()
+}
+
+// updateSessionMenu shows the session state in the status item and tooltip
+func updateSessionMenu(status SessionStatus, mStatus, mStart, mSkip, mStop *systray.MenuItem) {
+	if status.State == SessionIdle {
+		mStatus.SetTitle("Window Monitor")
+		systray.SetTooltip("Window Monitor - Running")
+		mStart.Show()
+		mSkip.Hide()
+		mStop.Hide()
+		return
+	}
+
+	// The menu is only updated on transitions, so show the end time rather than a countdown
+	label := fmt.Sprintf("%s %d until %s", status.State, status.Cycle, status.EndsAt.Local().Format("15:04"))
+	mStatus.SetTitle("Session: " + label)
+	systray.SetTooltip("Window Monitor - " + label)
+	mStart.Hide()
+	mSkip.Show()
+	mStop.Show()
+}
+
+// updateAutoStartMenu updates the menu items based on auto-start status
+func updateAutoStartMenu(mEnable, mDisable *systray.MenuItem) {
+	enabled, err := IsAutoStartEnabled()
+	if err != nil {
+		fmt.Printf("Error checking auto-start status: %v\n", err)
+		return
+	}
+
+	if enabled {
+		mEnable.Hide()
+		mDisable.Show()
+	} else {
+		mEnable.Show()
+		mDisable.Hide()
+	}
+}
+
+// getIcon returns a simple icon byte array
+// For a real application, you would load an actual .ico file
+// Returning nil means no custom icon will be set (systray will use default)
+func getIcon() []byte {
+	// Return nil - systray will handle the default icon
+	// To add a custom icon:
+	// 1. Create a .ico file (16x16 or 32x32 recommended)
+	// 2. Embed it: //go:embed icon.ico
+	// 3. var iconData []byte
+	// 4. Return iconData here
+	iconData, err := os.ReadFile("build/windows/icon.ico")
+	if err != nil {
+		return nil
+	}
+	return iconData
+}
@@ -0,0 +1,27 @@
+//go:build nogui
+
+package main
+
+import "fmt"
+
+// guiAvailable reports whether this binary was built with the Wails window and systray
+// Builds with the nogui tag don't link WebKit/WebView2 or GTK and always run headless
+const guiAvailable = false
+
+// runGUI is not available without the GUI toolkits
+func runGUI(app *App, watcher *WindowWatcher, launchArgs LaunchArgs, exePath string) error {
+	return fmt.Errorf("this build has no GUI support, run with --headless")
+}
+
+// runTray is a no-op without the GUI toolkits
+func runTray(exePath string, watcher *WindowWatcher, headless bool) {
+	fmt.Println("⚠️  System tray is not available in this build")
+}
+
+// quitApp stops monitoring and ends headless mode
+func quitApp(app *App) {
+	if app != nil && app.watcher != nil {
+		app.watcher.StopMonitoring()
+	}
+	requestQuit()
+}
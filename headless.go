@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// quitRequested is signalled by requestQuit to end headless mode
+var quitRequested = make(chan struct{}, 1)
+
+// requestQuit asks runHeadless to return; it never blocks
+func requestQuit() {
+	select {
+	case quitRequested <- struct{}{}:
+	default:
+	}
+}
+
+// runHeadless runs enforcement without the Wails window: the watcher, the
+// blocklist, notifications and the IPC/CLI interface. It returns when the
+// process is asked to stop (signal or tray Quit)
+func runHeadless(app *App, watcher *WindowWatcher, launchArgs LaunchArgs, exePath string) error {
+	fmt.Printf("🕶️  Starting in headless mode (pid %d)\n", os.Getpid())
+
+	if err := watcher.StartMonitoring(); err != nil {
+		return fmt.Errorf("failed to start window monitoring: %w", err)
+	}
+	fmt.Println("✓ Window monitoring started successfully")
+
+	if launchArgs.HasActions() {
+		if err := applyLaunchArgs(app, launchArgs); err != nil {
+			fmt.Printf("⚠️  Failed to apply launch arguments: %v\n", err)
+		}
+	}
+
+	stop := make(chan struct{})
+	go runSDWatchdog(stop, watcher.IsMonitoring)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	trayDone := make(chan struct{})
+	if !launchArgs.NoTray && guiAvailable {
+		go func() {
+			runTray(exePath, watcher, true)
+			close(trayDone)
+		}()
+	}
+
+	if _, err := sdNotify("READY=1\nSTATUS=Monitoring active window"); err != nil {
+		fmt.Printf("⚠️  Failed to notify systemd: %v\n", err)
+	}
+
+	select {
+	case sig := <-signals:
+		fmt.Printf("🛑 Received %s, shutting down\n", sig)
+	case <-trayDone:
+		fmt.Println("🛑 Quit from tray, shutting down")
+	case <-quitRequested:
+		fmt.Println("🛑 Quit requested, shutting down")
+	}
+
+	sdNotify("STOPPING=1")
+	close(stop)
+	watcher.StopMonitoring()
+	return nil
+}
+
+// defaultLogFilePath returns the log file used in headless mode
+// %LOCALAPPDATA% on Windows, $XDG_STATE_HOME (~/.local/state) elsewhere
+func defaultLogFilePath() (string, error) {
+	if runtime.GOOS == "windows" {
+		dir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, "sybr", "sybr.log"), nil
+	}
+
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(dir, "sybr", "sybr.log"), nil
+}
+
+// redirectOutputToLogFile sends stdout and stderr (where all of the app's
+// logging goes) to a file. "-" keeps logging on stdout, which is what journald
+// captures when running as a systemd service
+func redirectOutputToLogFile(path string) (func(), error) {
+	if path == "-" {
+		return func() {}, nil
+	}
+	if path == "" {
+		defaultPath, err := defaultLogFilePath()
+		if err != nil {
+			return nil, err
+		}
+		path = defaultPath
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create log directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	stdout, stderr := os.Stdout, os.Stderr
+	fmt.Printf("📝 Logging to %s\n", path)
+	os.Stdout = file
+	os.Stderr = file
+	fmt.Printf("\n===== sybr started %s =====\n", time.Now().Format(time.RFC3339))
+
+	return func() {
+		os.Stdout = stdout
+		os.Stderr = stderr
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,189 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// maxHistoryEntries caps the number of entries kept in memory and, trimmed
+// when it is loaded, in the history file
+const maxHistoryEntries = 5000
+
+// HistoryEntry is a single recorded window change
+type HistoryEntry struct {
+	Time     time.Time `json:"time"`
+	Title    string    `json:"title"`
+	Exe      string    `json:"exe"`
+	AppID    string    `json:"appId,omitempty"`
+	Launcher string    `json:"launcher,omitempty"`
+
+	Foreground TerminalJob `json:"foreground,omitzero"` // Job in a focused terminal
+	Domain     string      `json:"domain,omitempty"`    // Website in a focused browser; the URL isn't kept
+	Project    string      `json:"project,omitempty"`   // Project an editor plugin reported
+}
+
+// HistoryStore keeps the window change history and appends it to a JSON Lines file
+// so that it survives restarts and can be queried from the CLI
+type HistoryStore struct {
+	filePath string
+	mu       sync.RWMutex
+	entries  []HistoryEntry
+	fileMu   sync.Mutex // Serializes writes to the file without holding up readers
+}
+
+var (
+	globalHistory *HistoryStore
+	historyOnce   sync.Once
+)
+
+// GetHistoryStore returns the global history store instance
+func GetHistoryStore() (*HistoryStore, error) {
+	var err error
+	historyOnce.Do(func() {
+		filePath, pathErr := getDataFilePath("history.jsonl")
+		if pathErr != nil {
+			err = pathErr
+			return
+		}
+		globalHistory = &HistoryStore{
+			filePath: filePath,
+			entries:  []HistoryEntry{},
+		}
+		if loadErr := globalHistory.load(); loadErr != nil && !os.IsNotExist(loadErr) {
+			err = loadErr
+		}
+	})
+	return globalHistory, err
+}
+
+// load reads previously recorded entries from the history file and trims
+// the file to the newest maxHistoryEntries lines
+func (hs *HistoryStore) load() error {
+	file, err := os.Open(hs.filePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	entries := []HistoryEntry{}
+	lines := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines++
+		var entry HistoryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			// Skip corrupt lines (e.g. a partial write before a crash)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	if len(entries) > maxHistoryEntries {
+		entries = entries[len(entries)-maxHistoryEntries:]
+	}
+	file.Close()
+
+	if lines > len(entries) {
+		if err := hs.rewrite(entries); err != nil {
+			fmt.Printf("⚠️  Failed to trim history: %v\n", err)
+		}
+	}
+
+	hs.mu.Lock()
+	hs.entries = entries
+	hs.mu.Unlock()
+	return nil
+}
+
+// rewrite replaces the history file with entries
+func (hs *HistoryStore) rewrite(entries []HistoryEntry) error {
+	var data []byte
+	for _, entry := range entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal history entry: %w", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	hs.fileMu.Lock()
+	defer hs.fileMu.Unlock()
+	return writeFileAtomic(hs.filePath, data, 0644)
+}
+
+// Record appends a window change to the history
+func (hs *HistoryStore) Record(info *WindowInfo, at time.Time) error {
+	if info == nil {
+		return nil
+	}
+	entry := HistoryEntry{
+		Time:       at,
+		Title:      info.Title,
+		Exe:        info.Exe,
+		AppID:      info.AppID,
+		Launcher:   info.Launcher,
+		Foreground: info.Foreground,
+		Domain:     info.Domain,
+		Project:    info.Project,
+	}
+
+	hs.mu.Lock()
+	hs.entries = append(hs.entries, entry)
+	if len(hs.entries) > maxHistoryEntries {
+		hs.entries = hs.entries[len(hs.entries)-maxHistoryEntries:]
+	}
+	hs.mu.Unlock()
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal history entry: %w", err)
+	}
+	hs.fileMu.Lock()
+	defer hs.fileMu.Unlock()
+	file, err := os.OpenFile(hs.filePath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write history entry: %w", err)
+	}
+	return nil
+}
+
+// Since returns a copy of all entries recorded at or after the given time
+func (hs *HistoryStore) Since(since time.Time) []HistoryEntry {
+	hs.mu.RLock()
+	defer hs.mu.RUnlock()
+
+	result := []HistoryEntry{}
+	for _, entry := range hs.entries {
+		if !entry.Time.Before(since) {
+			result = append(result, entry)
+		}
+	}
+	return result
+}
+
+// StartHistoryRecorder records every window change published on the bus
+// Unsubscribe the returned subscription to stop recording
+func StartHistoryRecorder(bus *EventBus, hs *HistoryStore) *Subscription {
+	sub := bus.Subscribe(0, EventWindowChanged)
+	go func() {
+		for event := range sub.C {
+			changed, ok := event.Payload.(WindowChangedEvent)
+			if !ok {
+				continue
+			}
+			if err := hs.Record(&changed.Window, event.Time); err != nil {
+				fmt.Printf("⚠️  Failed to record history: %v\n", err)
+			}
+		}
+	}()
+	return sub
+}
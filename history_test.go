@@ -0,0 +1,50 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestHistoryTrimmedOnLoad tests that the history file is cut down to the
+// newest entries when it is loaded, like the history in memory
+func TestHistoryTrimmedOnLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+	writer := &HistoryStore{filePath: path}
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	for i := 0; i < maxHistoryEntries+100; i++ {
+		if err := writer.Record(&WindowInfo{Exe: "code"}, start.Add(time.Duration(i)*time.Second)); err != nil {
+			t.Fatalf("Record failed: %v", err)
+		}
+	}
+
+	hs := &HistoryStore{filePath: path}
+	if err := hs.load(); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	entries := hs.Since(time.Time{})
+	if len(entries) != maxHistoryEntries || !entries[0].Time.Equal(start.Add(100*time.Second)) {
+		t.Fatalf("loaded %d entries starting at %v, want the newest %d", len(entries), entries[0].Time, maxHistoryEntries)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile failed: %v", err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != maxHistoryEntries {
+		t.Errorf("history file has %d lines after loading, want %d", lines, maxHistoryEntries)
+	}
+
+	// Appending keeps working on the trimmed file
+	if err := hs.Record(&WindowInfo{Exe: "firefox"}, start.Add(24*time.Hour)); err != nil {
+		t.Fatalf("Record after trimming failed: %v", err)
+	}
+	reloaded := &HistoryStore{filePath: path}
+	if err := reloaded.load(); err != nil {
+		t.Fatalf("reloading failed: %v", err)
+	}
+	if entries := reloaded.Since(start.Add(24 * time.Hour)); len(entries) != 1 || entries[0].Exe != "firefox" {
+		t.Errorf("entries after reload = %+v, want the firefox entry", entries)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+import "fmt"
+
+// hotkeyAction is what a global hotkey does
+type hotkeyAction int
+
+const (
+	hotkeyToggleSession hotkeyAction = iota // Ctrl+Alt+F
+	hotkeySkipPhase                         // Ctrl+Alt+S
+)
+
+// handleHotkey runs a hotkey action against the app
+func handleHotkey(app *App, action hotkeyAction) {
+	var err error
+	switch action {
+	case hotkeyToggleSession:
+		status, _ := app.GetSessionStatus()
+		if status.State == SessionIdle {
+			err = app.StartFocusSession(0)
+		} else {
+			err = app.StopFocusSession()
+		}
+	case hotkeySkipPhase:
+		err = app.SkipSessionPhase()
+	}
+	if err != nil {
+		fmt.Printf("⚠️  Hotkey failed: %v\n", err)
+	}
+}
+
+// StartHotkeys registers the global session hotkeys
+// The returned function unregisters them
+func StartHotkeys(app *App) (func(), error) {
+	return registerHotkeys(func(action hotkeyAction) {
+		handleHotkey(app, action)
+	})
+}
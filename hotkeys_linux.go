@@ -0,0 +1,9 @@
+package main
+
+import "fmt"
+
+// registerHotkeys is not supported on Linux: X11 key grabs conflict with the
+// desktop's own shortcuts and don't work at all under Wayland
+func registerHotkeys(handler func(hotkeyAction)) (func(), error) {
+	return func() {}, fmt.Errorf("global hotkeys are not supported on Linux; bind `sybr session start` and `sybr session skip` to desktop shortcuts instead")
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	modAlt      = 0x0001
+	modControl  = 0x0002
+	modNoRepeat = 0x4000
+	wmHotkey    = 0x0312
+	wmQuit      = 0x0012
+)
+
+// windowsHotkeys maps hotkey IDs to their modifiers, virtual key and action
+var windowsHotkeys = []struct {
+	mods   uint32
+	vk     uint32
+	action hotkeyAction
+}{
+	{modControl | modAlt | modNoRepeat, 'F', hotkeyToggleSession},
+	{modControl | modAlt | modNoRepeat, 'S', hotkeySkipPhase},
+}
+
+// winMsg mirrors the Win32 MSG structure
+type winMsg struct {
+	hwnd    uintptr
+	message uint32
+	wParam  uintptr
+	lParam  uintptr
+	time    uint32
+	pt      struct{ x, y int32 }
+}
+
+// registerHotkeys registers the hotkeys with RegisterHotKey and runs a message
+// loop for them. Hotkeys belong to the thread that registered them, so the
+// loop runs on a locked OS thread
+func registerHotkeys(handler func(hotkeyAction)) (func(), error) {
+	user32 := windows.NewLazyDLL("user32.dll")
+	registerHotKey := user32.NewProc("RegisterHotKey")
+	unregisterHotKey := user32.NewProc("UnregisterHotKey")
+	getMessage := user32.NewProc("GetMessageW")
+	postThreadMessage := user32.NewProc("PostThreadMessageW")
+
+	started := make(chan error, 1)
+	var threadID uint32
+
+	go func() {
+		runtime.LockOSThread()
+		defer runtime.UnlockOSThread()
+		threadID = windows.GetCurrentThreadId()
+
+		registered := 0
+		for id, hk := range windowsHotkeys {
+			ret, _, err := registerHotKey.Call(0, uintptr(id+1), uintptr(hk.mods), uintptr(hk.vk))
+			if ret == 0 {
+				// Another application owns the combination; keep the others
+				fmt.Printf("⚠️  Failed to register hotkey %d: %v\n", id+1, err)
+				continue
+			}
+			registered++
+		}
+		if registered == 0 {
+			started <- fmt.Errorf("no hotkeys could be registered")
+			return
+		}
+		started <- nil
+
+		var msg winMsg
+		for {
+			ret, _, _ := getMessage.Call(uintptr(unsafe.Pointer(&msg)), 0, 0, 0)
+			if int32(ret) <= 0 {
+				break // WM_QUIT or error
+			}
+			if msg.message == wmHotkey {
+				id := int(msg.wParam) - 1
+				if id >= 0 && id < len(windowsHotkeys) {
+					handler(windowsHotkeys[id].action)
+				}
+			}
+		}
+
+		for id := range windowsHotkeys {
+			unregisterHotKey.Call(0, uintptr(id+1))
+		}
+	}()
+
+	if err := <-started; err != nil {
+		return func() {}, err
+	}
+	return func() {
+		postThreadMessage.Call(uintptr(threadID), wmQuit, 0, 0)
+	}, nil
+}
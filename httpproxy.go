@@ -0,0 +1,336 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultHTTPProxyListen is where the proxy listens unless configured
+	defaultHTTPProxyListen = "127.0.0.1:8089"
+	// pacPath is where the proxy serves its PAC file
+	pacPath = "/proxy.pac"
+	// httpProxyDialTimeout is how long connecting to a website may take
+	httpProxyDialTimeout = 10 * time.Second
+	// distractionLogInterval limits how often attempts to reach the same host
+	// are recorded; one page load makes many requests
+	distractionLogInterval = time.Minute
+)
+
+// HTTPProxyConfig configures the local HTTP(S) proxy in settings.json
+type HTTPProxyConfig struct {
+	Enabled      bool   `json:"enabled"`
+	Listen       string `json:"listen,omitempty"`       // "" = 127.0.0.1:8089
+	SessionsOnly bool   `json:"sessionsOnly,omitempty"` // Only block while a focus session is in its focus phase
+}
+
+// listen returns the listen address, falling back to the default
+func (c HTTPProxyConfig) listen() string {
+	if c.Listen == "" {
+		return defaultHTTPProxyListen
+	}
+	return c.Listen
+}
+
+// DistractionAttempt is one line of the distraction log: a blocked website
+// the proxy refused to connect to
+type DistractionAttempt struct {
+	Time           time.Time `json:"time"`
+	Host           string    `json:"host"`
+	Method         string    `json:"method"`         // CONNECT for HTTPS, GET etc. for plain HTTP
+	ExecutableName string    `json:"executableName"` // Blocklist entry, e.g. reddit.com
+	DisplayName    string    `json:"displayName"`
+}
+
+// HTTPProxy is a forward proxy that refuses blocked websites: plain HTTP gets
+// a block page, HTTPS tunnels are refused. Browsers find it through the PAC
+// file it serves at /proxy.pac
+type HTTPProxy struct {
+	bus       *EventBus
+	config    HTTPProxyConfig
+	blocked   func(host string) *BlockedApp
+	logPath   string
+	now       func() time.Time
+	forwarder *httputil.ReverseProxy
+
+	mu       sync.Mutex
+	server   *http.Server
+	listener net.Listener
+	logged   map[string]time.Time // Blocked host -> when an attempt was last recorded
+}
+
+// NewHTTPProxy creates a proxy blocking the blocklist's websites in the active
+// profile and recording attempts in the distraction log at logPath; store,
+// session and logPath may be empty
+func NewHTTPProxy(bus *EventBus, config HTTPProxyConfig, bm *BlocklistManager, store *StateStore, session sessionSource, logPath string) *HTTPProxy {
+	p := &HTTPProxy{
+		bus:     bus,
+		config:  config,
+		blocked: websiteBlocker(bm, store, session, config.SessionsOnly),
+		logPath: logPath,
+		now:     time.Now,
+		logged:  map[string]time.Time{},
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	// Never send requests on to another proxy, least of all this one
+	transport.Proxy = nil
+	p.forwarder = &httputil.ReverseProxy{
+		// Requests to a proxy carry the full URL already
+		Director: func(r *http.Request) {
+			r.Header["X-Forwarded-For"] = nil
+		},
+		Transport: transport,
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			fmt.Printf("⚠️  HTTP proxy: %s %s failed: %v\n", r.Method, r.URL.Host, err)
+			http.Error(w, "sybr couldn't reach "+r.URL.Host, http.StatusBadGateway)
+		},
+	}
+	return p
+}
+
+// Start listens for proxy requests
+func (p *HTTPProxy) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.server != nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", p.config.listen())
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", p.config.listen(), err)
+	}
+	p.listener = listener
+	p.server = &http.Server{Handler: p, ReadHeaderTimeout: httpProxyDialTimeout}
+	go p.server.Serve(listener)
+	fmt.Printf("🧱 HTTP proxy listening on %s (PAC file at http://%s%s)\n", listener.Addr(), listener.Addr(), pacPath)
+	return nil
+}
+
+// Stop closes the listener and open connections; HTTPS tunnels run on
+// until either side closes them
+func (p *HTTPProxy) Stop() {
+	p.mu.Lock()
+	server := p.server
+	p.server, p.listener = nil, nil
+	p.mu.Unlock()
+	if server != nil {
+		server.Close()
+	}
+}
+
+// Addr returns the address the proxy listens on, "" when it isn't running
+func (p *HTTPProxy) Addr() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.listener == nil {
+		return ""
+	}
+	return p.listener.Addr().String()
+}
+
+// ServeHTTP handles one request: CONNECT for HTTPS, requests with a full URL
+// for plain HTTP and anything else, like the PAC file, for the proxy itself
+func (p *HTTPProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodConnect {
+		p.serveConnect(w, r)
+		return
+	}
+	if !r.URL.IsAbs() || r.URL.Host == p.Addr() {
+		p.serveLocal(w, r)
+		return
+	}
+	host := strings.ToLower(r.URL.Hostname())
+	if app := p.blocked(host); app != nil {
+		p.recordBlock(host, r.Method, app)
+		serveBlockPage(w, host, app)
+		return
+	}
+	p.forwarder.ServeHTTP(w, r)
+}
+
+// serveConnect opens a tunnel to the host unless it is blocked. Browsers
+// don't show what a proxy answers to CONNECT, only that it failed
+func (p *HTTPProxy) serveConnect(w http.ResponseWriter, r *http.Request) {
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		http.Error(w, "CONNECT needs host:port", http.StatusBadRequest)
+		return
+	}
+	host = strings.ToLower(host)
+	if app := p.blocked(host); app != nil {
+		p.recordBlock(host, r.Method, app)
+		http.Error(w, host+" is blocked by sybr", http.StatusForbidden)
+		return
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "tunnels aren't supported", http.StatusInternalServerError)
+		return
+	}
+	upstream, err := net.DialTimeout("tcp", r.Host, httpProxyDialTimeout)
+	if err != nil {
+		http.Error(w, "sybr couldn't reach "+r.Host, http.StatusBadGateway)
+		return
+	}
+	client, buffered, err := hijacker.Hijack()
+	if err != nil {
+		upstream.Close()
+		return
+	}
+	if _, err := client.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		client.Close()
+		upstream.Close()
+		return
+	}
+	// The client may have sent the start of the TLS handshake already
+	if n := buffered.Reader.Buffered(); n > 0 {
+		data, _ := buffered.Reader.Peek(n)
+		upstream.Write(data)
+	}
+	go tunnel(client, upstream)
+}
+
+// tunnel copies between both connections until either side is done
+func tunnel(client, upstream net.Conn) {
+	done := make(chan struct{}, 2)
+	copyAndSignal := func(dst, src net.Conn) {
+		io.Copy(dst, src)
+		done <- struct{}{}
+	}
+	go copyAndSignal(upstream, client)
+	go copyAndSignal(client, upstream)
+	<-done
+	client.Close()
+	upstream.Close()
+	<-done
+}
+
+// serveLocal answers requests meant for the proxy itself
+func (p *HTTPProxy) serveLocal(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != pacPath {
+		http.NotFound(w, r)
+		return
+	}
+	// The PAC file names the proxy the way the browser reached it
+	address := r.Host
+	if address == "" {
+		address = p.Addr()
+	}
+	w.Header().Set("Content-Type", "application/x-ns-proxy-autoconfig")
+	io.WriteString(w, pacFile(address))
+}
+
+// pacFile sends everything but local names through the proxy at address,
+// going direct while sybr isn't running
+func pacFile(address string) string {
+	return fmt.Sprintf(`function FindProxyForURL(url, host) {
+  if (isPlainHostName(host) || host == "localhost" || shExpMatch(host, "127.*") || host == "[::1]") {
+    return "DIRECT";
+  }
+  return "PROXY %s; DIRECT";
+}
+`, address)
+}
+
+// blockPage is shown for blocked plain HTTP pages
+var blockPage = template.Must(template.New("block").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>Blocked by sybr</title></head>
+<body style="font-family: sans-serif; text-align: center; margin-top: 20vh; color: #333">
+<h1>{{.DisplayName}} is blocked</h1>
+<p>sybr blocked <b>{{.Host}}</b> to help you stay focused.</p>
+</body>
+</html>
+`))
+
+// serveBlockPage answers a request for a blocked host with the block page
+func serveBlockPage(w http.ResponseWriter, host string, app *BlockedApp) {
+	displayName := app.DisplayName
+	if displayName == "" {
+		displayName = app.ExecutableName
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusForbidden)
+	blockPage.Execute(w, struct{ Host, DisplayName string }{host, displayName})
+}
+
+// recordBlock logs a refused request as a distraction attempt, at most once
+// a minute per host
+func (p *HTTPProxy) recordBlock(host, method string, app *BlockedApp) {
+	now := p.now()
+	p.mu.Lock()
+	last, seen := p.logged[host]
+	if seen && now.Sub(last) < distractionLogInterval {
+		p.mu.Unlock()
+		return
+	}
+	p.logged[host] = now
+	p.mu.Unlock()
+
+	attempt := DistractionAttempt{
+		Time:           now,
+		Host:           host,
+		Method:         method,
+		ExecutableName: app.ExecutableName,
+		DisplayName:    app.DisplayName,
+	}
+	fmt.Printf("🚫 HTTP proxy: blocked %s %s (%s)\n", method, host, app.ExecutableName)
+	if err := appendDistractionAttempt(p.logPath, attempt); err != nil {
+		fmt.Printf("⚠️  %v\n", err)
+	}
+	if p.bus != nil {
+		p.bus.Publish(EventRequestBlocked, attempt)
+	}
+}
+
+// appendDistractionAttempt appends an attempt to the distraction log at path
+func appendDistractionAttempt(path string, attempt DistractionAttempt) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.Marshal(attempt)
+	if err != nil {
+		return fmt.Errorf("failed to marshal distraction attempt: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open distraction log: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write distraction log: %w", err)
+	}
+	return nil
+}
+
+// readDistractionAttempts returns the attempts in the distraction log at
+// path, oldest first
+func readDistractionAttempts(path string) ([]DistractionAttempt, error) {
+	attempts := []DistractionAttempt{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return attempts, nil
+		}
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		var attempt DistractionAttempt
+		if line == "" || json.Unmarshal([]byte(line), &attempt) != nil {
+			continue
+		}
+		attempts = append(attempts, attempt)
+	}
+	return attempts, nil
+}
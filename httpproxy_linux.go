@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+)
+
+// setupSystemProxy points GNOME's proxy settings, which Chrome and Firefox
+// follow by default, at the PAC file
+func setupSystemProxy(pacURL string, out io.Writer) error {
+	if _, err := exec.LookPath("gsettings"); err != nil {
+		return fmt.Errorf("gsettings isn't available: set the automatic proxy configuration URL to %s in your desktop's or browser's network settings", pacURL)
+	}
+	if err := gsettings("set", "org.gnome.system.proxy", "autoconfig-url", pacURL); err != nil {
+		return err
+	}
+	if err := gsettings("set", "org.gnome.system.proxy", "mode", "auto"); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "The system proxy now uses %s\n", pacURL)
+	return nil
+}
+
+// restoreSystemProxy turns GNOME's proxy settings off again
+func restoreSystemProxy(out io.Writer) error {
+	if _, err := exec.LookPath("gsettings"); err != nil {
+		return fmt.Errorf("gsettings isn't available: remove the automatic proxy configuration URL in your desktop's or browser's network settings")
+	}
+	if err := gsettings("set", "org.gnome.system.proxy", "mode", "none"); err != nil {
+		return err
+	}
+	fmt.Fprintln(out, "The system proxy is turned off again")
+	return nil
+}
+
+// gsettings runs gsettings with args
+func gsettings(args ...string) error {
+	if output, err := exec.Command("gsettings", args...).CombinedOutput(); err != nil {
+		return fmt.Errorf("gsettings %s failed: %s", strings.Join(args, " "), strings.TrimSpace(string(output)))
+	}
+	return nil
+}
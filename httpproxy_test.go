@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// startTestHTTPProxy runs a proxy on a local port blocking reddit.com and
+// returns it with the path of its distraction log
+func startTestHTTPProxy(t *testing.T, bus *EventBus) (*HTTPProxy, string) {
+	t.Helper()
+	dir := t.TempDir()
+	bm := &BlocklistManager{filePath: filepath.Join(dir, "blocking_list.json")}
+	if err := bm.AddDomain("reddit.com", "Reddit"); err != nil {
+		t.Fatalf("AddDomain failed: %v", err)
+	}
+	logPath := filepath.Join(dir, "distractions.jsonl")
+	proxy := NewHTTPProxy(bus, HTTPProxyConfig{Listen: "127.0.0.1:0"}, bm, nil, nil, logPath)
+	if err := proxy.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	t.Cleanup(proxy.Stop)
+	return proxy, logPath
+}
+
+// proxyClient returns a client sending every request through proxy
+func proxyClient(proxy *HTTPProxy, transport *http.Transport) *http.Client {
+	if transport == nil {
+		transport = &http.Transport{}
+	}
+	transport.Proxy = http.ProxyURL(&url.URL{Scheme: "http", Host: proxy.Addr()})
+	return &http.Client{Transport: transport, Timeout: 5 * time.Second}
+}
+
+// TestHTTPProxyForwards tests that plain HTTP and HTTPS to hosts that aren't
+// blocked go through
+func TestHTTPProxyForwards(t *testing.T) {
+	proxy, _ := startTestHTTPProxy(t, nil)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Forwarded-For") != "" {
+			t.Errorf("request carried X-Forwarded-For %q", r.Header.Get("X-Forwarded-For"))
+		}
+		io.WriteString(w, "hello")
+	})
+
+	plain := httptest.NewServer(handler)
+	defer plain.Close()
+	secure := httptest.NewTLSServer(handler)
+	defer secure.Close()
+
+	for _, test := range []struct {
+		url       string
+		transport *http.Transport
+	}{
+		{plain.URL, nil},
+		{secure.URL, secure.Client().Transport.(*http.Transport).Clone()},
+	} {
+		resp, err := proxyClient(proxy, test.transport).Get(test.url)
+		if err != nil {
+			t.Fatalf("GET %s failed: %v", test.url, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || string(body) != "hello" {
+			t.Errorf("GET %s = %d %q, want 200 \"hello\"", test.url, resp.StatusCode, body)
+		}
+	}
+}
+
+// TestHTTPProxyBlocks tests that blocked hosts get the block page over plain
+// HTTP, that CONNECT to them is refused and that attempts are logged once a
+// minute per host
+func TestHTTPProxyBlocks(t *testing.T) {
+	bus := NewEventBus()
+	sub := bus.Subscribe(8, EventRequestBlocked)
+	defer sub.Unsubscribe()
+	proxy, logPath := startTestHTTPProxy(t, bus)
+
+	for i := 0; i < 2; i++ {
+		resp, err := proxyClient(proxy, nil).Get("http://www.reddit.com/r/golang")
+		if err != nil {
+			t.Fatalf("GET failed: %v", err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusForbidden || !strings.Contains(string(body), "Reddit is blocked") {
+			t.Errorf("GET = %d %q, want 403 and the block page", resp.StatusCode, body)
+		}
+	}
+
+	conn, err := net.Dial("tcp", proxy.Addr())
+	if err != nil {
+		t.Fatalf("failed to dial the proxy: %v", err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "CONNECT old.reddit.com:443 HTTP/1.1\r\nHost: old.reddit.com:443\r\n\r\n")
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatalf("no reply to CONNECT: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Errorf("CONNECT = %d, want 403", resp.StatusCode)
+	}
+
+	attempts, err := readDistractionAttempts(logPath)
+	if err != nil {
+		t.Fatalf("readDistractionAttempts failed: %v", err)
+	}
+	if len(attempts) != 2 {
+		t.Fatalf("got %d attempts %+v, want www.reddit.com once and old.reddit.com once", len(attempts), attempts)
+	}
+	if attempts[0].Host != "www.reddit.com" || attempts[0].Method != http.MethodGet || attempts[0].ExecutableName != "reddit.com" {
+		t.Errorf("first attempt = %+v", attempts[0])
+	}
+	if attempts[1].Host != "old.reddit.com" || attempts[1].Method != http.MethodConnect {
+		t.Errorf("second attempt = %+v", attempts[1])
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case event := <-sub.C:
+			if _, ok := event.Payload.(DistractionAttempt); !ok {
+				t.Errorf("event payload = %T, want DistractionAttempt", event.Payload)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for request-blocked event %d", i)
+		}
+	}
+}
+
+// TestHTTPProxyPAC tests that the PAC file sends browsers to the proxy
+func TestHTTPProxyPAC(t *testing.T) {
+	proxy, _ := startTestHTTPProxy(t, nil)
+	resp, err := http.Get("http://" + proxy.Addr() + pacPath)
+	if err != nil {
+		t.Fatalf("GET %s failed: %v", pacPath, err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/x-ns-proxy-autoconfig" {
+		t.Errorf("GET %s = %d %s", pacPath, resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	if want := `"PROXY ` + proxy.Addr() + `; DIRECT"`; !strings.Contains(string(body), want) {
+		t.Errorf("PAC file %q doesn't contain %s", body, want)
+	}
+
+	resp, err = http.Get("http://" + proxy.Addr() + "/other")
+	if err != nil {
+		t.Fatalf("GET /other failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /other = %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// internetSettingsKey holds the proxy settings Chrome, Edge and Firefox
+// follow by default
+const internetSettingsKey = `Software\Microsoft\Windows\CurrentVersion\Internet Settings`
+
+// setupSystemProxy points the current user's proxy settings at the PAC file;
+// browsers started afterwards pick it up
+func setupSystemProxy(pacURL string, out io.Writer) error {
+	key, err := registry.OpenKey(registry.CURRENT_USER, internetSettingsKey, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to open the proxy settings: %w", err)
+	}
+	defer key.Close()
+	if err := key.SetStringValue("AutoConfigURL", pacURL); err != nil {
+		return fmt.Errorf("failed to set the proxy configuration URL: %w", err)
+	}
+	fmt.Fprintf(out, "The system proxy now uses %s (restart browsers to pick it up)\n", pacURL)
+	return nil
+}
+
+// restoreSystemProxy removes the PAC file from the proxy settings
+func restoreSystemProxy(out io.Writer) error {
+	key, err := registry.OpenKey(registry.CURRENT_USER, internetSettingsKey, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to open the proxy settings: %w", err)
+	}
+	defer key.Close()
+	if err := key.DeleteValue("AutoConfigURL"); err != nil {
+		if err == registry.ErrNotExist {
+			fmt.Fprintln(out, "The system proxy wasn't pointed at sybr")
+			return nil
+		}
+		return fmt.Errorf("failed to remove the proxy configuration URL: %w", err)
+	}
+	fmt.Fprintln(out, "The system proxy no longer uses sybr")
+	return nil
+}
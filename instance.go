@@ -0,0 +1,241 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// errAlreadyRunning is returned when another instance holds the lock
+var errAlreadyRunning = errors.New("another instance is already running")
+
+// forwardTimeout bounds how long a second launch waits for the running instance
+const forwardTimeout = 5 * time.Second
+
+// InstanceLock is the per-user lock held for the lifetime of the primary instance
+type InstanceLock struct {
+	file *os.File
+	path string
+}
+
+// AcquireInstanceLock takes the per-user single-instance lock.
+// The lock is an OS file lock, so it is released automatically if the process
+// crashes; the PID left in the file is only used to report stale locks
+func AcquireInstanceLock() (*InstanceLock, error) {
+	path, err := instanceLockPath()
+	if err != nil {
+		return nil, err
+	}
+	return acquireInstanceLockAt(path)
+}
+
+// acquireInstanceLockAt takes the lock on the given file
+func acquireInstanceLockAt(path string) (*InstanceLock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+
+	if err := lockFile(file); err != nil {
+		file.Close()
+		if errors.Is(err, errAlreadyRunning) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+
+	// We own the lock - if a PID is still recorded, its owner died without cleaning up
+	if previous := readLockPID(file); previous != 0 && previous != os.Getpid() {
+		fmt.Printf("♻️  Recovered stale instance lock left by pid %d\n", previous)
+	}
+
+	if err := file.Truncate(0); err != nil {
+		unlockFile(file)
+		file.Close()
+		return nil, fmt.Errorf("failed to reset lock file: %w", err)
+	}
+	if _, err := file.WriteAt([]byte(strconv.Itoa(os.Getpid())), 0); err != nil {
+		unlockFile(file)
+		file.Close()
+		return nil, fmt.Errorf("failed to write lock file: %w", err)
+	}
+	file.Sync()
+
+	return &InstanceLock{file: file, path: path}, nil
+}
+
+// readLockPID returns the PID recorded in the lock file, or 0
+func readLockPID(file *os.File) int {
+	data, err := io.ReadAll(io.NewSectionReader(file, 0, 32))
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// Release clears the recorded PID and unlocks the file
+func (l *InstanceLock) Release() {
+	if l == nil || l.file == nil {
+		return
+	}
+	l.file.Truncate(0)
+	unlockFile(l.file)
+	l.file.Close()
+	l.file = nil
+}
+
+// LaunchArgs are the GUI command-line options. Show, Block and Session are
+// actions that can be forwarded to a running instance; the rest select how
+// this process runs
+type LaunchArgs struct {
+	Show           bool
+	Block          []string
+	Session        bool
+	SessionMinutes int // 0 = the configured focus length
+
+	Headless bool
+	NoTray   bool
+	LogFile  string
+}
+
+// HasActions reports whether any forwardable action was requested
+func (l LaunchArgs) HasActions() bool {
+	return l.Show || len(l.Block) > 0 || l.Session
+}
+
+// stringList collects a repeatable string flag
+type stringList []string
+
+func (s *stringList) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringList) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// sessionFlag is --session with an optional length in minutes
+type sessionFlag struct {
+	set     *bool
+	minutes *int
+}
+
+func (s sessionFlag) String() string {
+	if s.minutes == nil || *s.minutes == 0 {
+		return ""
+	}
+	return strconv.Itoa(*s.minutes)
+}
+
+func (s sessionFlag) Set(value string) error {
+	*s.set = true
+	if value == "true" {
+		*s.minutes = 0
+		return nil
+	}
+	minutes, err := strconv.Atoi(value)
+	if err != nil || minutes <= 0 {
+		return fmt.Errorf("session length must be a number of minutes")
+	}
+	*s.minutes = minutes
+	return nil
+}
+
+// IsBoolFlag lets --session be given without a value
+func (s sessionFlag) IsBoolFlag() bool {
+	return true
+}
+
+// parseLaunchArgs parses the GUI command line
+func parseLaunchArgs(args []string) (LaunchArgs, error) {
+	var launch LaunchArgs
+	var blocks stringList
+
+	fs := flag.NewFlagSet("sybr", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.BoolVar(&launch.Show, "show", false, "show the main window")
+	fs.Var(&blocks, "block", "add an executable to the blocklist (repeatable)")
+	fs.Var(sessionFlag{&launch.Session, &launch.SessionMinutes}, "session", "start a focus session, optionally =minutes long")
+	fs.BoolVar(&launch.Headless, "headless", false, "run enforcement without the window")
+	fs.BoolVar(&launch.NoTray, "no-tray", false, "don't show a tray icon in headless mode")
+	fs.StringVar(&launch.LogFile, "log-file", "", "write logs to this file ('-' for stdout)")
+	if err := fs.Parse(args); err != nil {
+		return launch, err
+	}
+	if fs.NArg() > 0 {
+		return launch, fmt.Errorf("unexpected argument '%s'", fs.Arg(0))
+	}
+	launch.Block = blocks
+	return launch, nil
+}
+
+// forwardParams are the params of "instance.forward"
+type forwardParams struct {
+	Args []string `json:"args"`
+}
+
+// forwardToRunningInstance hands the command line to the primary instance.
+// The primary may still be starting up, so connecting is retried for a while
+func forwardToRunningInstance(address string, args []string) error {
+	deadline := time.Now().Add(forwardTimeout)
+	for {
+		client, err := DialIPC(address)
+		if err == nil {
+			defer client.Close()
+			return client.Call("instance.forward", forwardParams{Args: args}, nil)
+		}
+		if time.Now().After(deadline) {
+			return fmt.Errorf("running instance did not respond: %w", err)
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+}
+
+// applyLaunchArgs performs the actions requested on the command line.
+// A launch without any action just brings the existing window to the front
+func applyLaunchArgs(app *App, launch LaunchArgs) error {
+	var errs []error
+	for _, exe := range launch.Block {
+		if err := app.AddToBlocklist(exe, "", ""); err != nil {
+			errs = append(errs, fmt.Errorf("block %s: %w", exe, err))
+		}
+	}
+	if launch.Session {
+		if app.session == nil {
+			errs = append(errs, fmt.Errorf("session: session engine not available"))
+		} else if err := app.session.Start(time.Duration(launch.SessionMinutes) * time.Minute); err != nil {
+			errs = append(errs, fmt.Errorf("session: %w", err))
+		}
+	}
+	if launch.Show || !launch.HasActions() {
+		app.ShowWindow()
+	}
+	return errors.Join(errs...)
+}
+
+// registerInstanceHandlers handles arguments forwarded by a second launch
+func registerInstanceHandlers(server *IPCServer, app *App) {
+	server.Handle("instance.forward", func(params json.RawMessage) (interface{}, error) {
+		var p forwardParams
+		if err := decodeIPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		fmt.Printf("📨 Received arguments from second launch: %v\n", p.Args)
+		launch, err := parseLaunchArgs(p.Args)
+		if err != nil {
+			return nil, &IPCError{Code: ipcErrInvalidParams, Message: err.Error()}
+		}
+		return nil, applyLaunchArgs(app, launch)
+	})
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// instanceLockPath returns the lock file path, next to the IPC socket
+func instanceLockPath() (string, error) {
+	dir := filepath.Dir(defaultIPCAddress())
+	if err := ensurePrivateDir(dir); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "sybr.lock"), nil
+}
+
+// lockFile takes a non-blocking exclusive flock on the file
+func lockFile(file *os.File) error {
+	err := unix.Flock(int(file.Fd()), unix.LOCK_EX|unix.LOCK_NB)
+	if errors.Is(err, unix.EWOULDBLOCK) {
+		return errAlreadyRunning
+	}
+	return err
+}
+
+// unlockFile releases the flock
+func unlockFile(file *os.File) error {
+	return unix.Flock(int(file.Fd()), unix.LOCK_UN)
+}
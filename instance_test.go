@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestInstanceLockExclusive tests that a second lock on the same file is refused
+// until the first one is released
+func TestInstanceLockExclusive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sybr.lock")
+
+	first, err := acquireInstanceLockAt(path)
+	if err != nil {
+		t.Fatalf("first acquire failed: %v", err)
+	}
+
+	if _, err := acquireInstanceLockAt(path); !errors.Is(err, errAlreadyRunning) {
+		t.Fatalf("second acquire error = %v, want errAlreadyRunning", err)
+	}
+
+	first.Release()
+
+	second, err := acquireInstanceLockAt(path)
+	if err != nil {
+		t.Fatalf("acquire after release failed: %v", err)
+	}
+	second.Release()
+}
+
+// TestInstanceLockStale tests that a lock file left behind by a crashed
+// process does not prevent startup
+func TestInstanceLockStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sybr.lock")
+	if err := os.WriteFile(path, []byte("999999"), 0600); err != nil {
+		t.Fatalf("failed to write stale lock: %v", err)
+	}
+
+	lock, err := acquireInstanceLockAt(path)
+	if err != nil {
+		t.Fatalf("acquire over stale lock failed: %v", err)
+	}
+	defer lock.Release()
+
+	if pid := readLockPID(lock.file); pid != os.Getpid() {
+		t.Errorf("lock file records pid %d, want %d", pid, os.Getpid())
+	}
+}
+
+// TestParseLaunchArgs tests the options that can be forwarded to a running instance
+func TestParseLaunchArgs(t *testing.T) {
+	launch, err := parseLaunchArgs([]string{"--show", "--block", "discord.exe", "--block=steam.exe"})
+	if err != nil {
+		t.Fatalf("parseLaunchArgs() failed: %v", err)
+	}
+	if !launch.Show {
+		t.Error("Show = false, want true")
+	}
+	if len(launch.Block) != 2 || launch.Block[0] != "discord.exe" || launch.Block[1] != "steam.exe" {
+		t.Errorf("Block = %v, want [discord.exe steam.exe]", launch.Block)
+	}
+
+	if _, err := parseLaunchArgs([]string{"stray"}); err == nil {
+		t.Error("expected error for positional argument")
+	}
+}
+
+// TestLaunchArgsSession tests that --session parses with and without a length
+// and starts a focus session in the running instance
+func TestLaunchArgsSession(t *testing.T) {
+	tests := []struct {
+		args    []string
+		minutes int
+	}{
+		{[]string{"--session"}, 0},
+		{[]string{"--session=50"}, 50},
+		{[]string{"--session", "--show"}, 0},
+	}
+	for _, tt := range tests {
+		launch, err := parseLaunchArgs(tt.args)
+		if err != nil {
+			t.Fatalf("parseLaunchArgs(%q) failed: %v", tt.args, err)
+		}
+		if !launch.Session || launch.SessionMinutes != tt.minutes || !launch.HasActions() {
+			t.Errorf("parseLaunchArgs(%q) = %+v, want a %d minute session", tt.args, launch, tt.minutes)
+		}
+	}
+	if _, err := parseLaunchArgs([]string{"--session=soon"}); err == nil {
+		t.Error("expected error for a session length that isn't a number")
+	}
+
+	clock := newFakeClock()
+	app := &App{session: NewSessionEngine(nil, clock, nil, testSessionConfig())}
+	launch, _ := parseLaunchArgs([]string{"--session=50"})
+	if err := applyLaunchArgs(app, launch); err != nil {
+		t.Fatalf("applyLaunchArgs() failed: %v", err)
+	}
+	status := app.session.Status()
+	if status.State != SessionFocusing || status.RemainingSeconds != 50*60 {
+		t.Errorf("session after --session=50 = %+v, want 50 minutes of focus", status)
+	}
+}
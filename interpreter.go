@@ -0,0 +1,245 @@
+package main
+
+import (
+	"strings"
+)
+
+// Interpreters whose processes only say which runtime they are; the app is
+// in the command line
+const (
+	InterpreterPython   = "python"
+	InterpreterJava     = "java"
+	InterpreterNode     = "node"
+	InterpreterElectron = "electron"
+)
+
+// Options that take the next argument as their value, per interpreter, so
+// the value isn't mistaken for the script
+var interpreterValueOptions = map[string]map[string]bool{
+	InterpreterPython: {"-W": true, "-X": true, "--check-hash-based-pycs": true},
+	InterpreterJava: {
+		"-cp": true, "-classpath": true, "--class-path": true, "-p": true, "--module-path": true,
+		"--upgrade-module-path": true, "--add-modules": true, "--limit-modules": true,
+		"--add-reads": true, "--add-exports": true, "--add-opens": true, "--patch-module": true,
+		"--enable-native-access": true,
+	},
+	InterpreterNode: {
+		"-r": true, "--require": true, "--import": true, "--loader": true,
+		"--experimental-loader": true, "--inspect-port": true, "--title": true,
+		"--env-file": true, "-C": true, "--conditions": true,
+	},
+	InterpreterElectron: {},
+}
+
+// genericScriptNames are entry points that say nothing about the app, so the
+// directory they are in names it instead
+var genericScriptNames = map[string]bool{
+	"__main__": true, "index": true, "main": true, "cli": true, "app": true, "server": true,
+}
+
+// genericDirNames are build and package folders skipped when naming an app
+// by its directory
+var genericDirNames = map[string]bool{
+	"bin": true, "lib": true, "dist": true, "src": true, "out": true, "build": true,
+	"resources": true, "app": true, "scripts": true,
+}
+
+// interpreterKind returns which interpreter the executable path or name is,
+// "" if it isn't one. Versioned names like python3.12 or electron32 count
+func interpreterKind(exe string) string {
+	name := normalizeExecutableName(pathBase(exe))
+	switch {
+	case name == "java" || name == "javaw":
+		return InterpreterJava
+	case name == "node" || name == "nodejs":
+		return InterpreterNode
+	case strings.HasPrefix(name, "python"):
+		rest := strings.TrimSuffix(strings.TrimPrefix(name, "python"), "w")
+		if strings.Trim(rest, "0123456789.") == "" {
+			return InterpreterPython
+		}
+	case strings.HasPrefix(name, "electron"):
+		if strings.Trim(strings.TrimPrefix(name, "electron"), "0123456789") == "" {
+			return InterpreterElectron
+		}
+	}
+	return ""
+}
+
+// interpreterAppID returns the app an interpreter process runs, from its
+// command line: the main script for Python and Node, the jar, module or main
+// class for Java and the app folder or archive for Electron. "" if exe is no
+// interpreter or the command line doesn't name an app, e.g. python -c
+func interpreterAppID(exe string, args []string) string {
+	kind := interpreterKind(exe)
+	if kind == "" || len(args) < 2 {
+		return ""
+	}
+	valueOptions := interpreterValueOptions[kind]
+
+	// args[0] is the interpreter itself
+	for i := 1; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			if i+1 < len(args) {
+				return scriptAppID(args[i+1])
+			}
+			return ""
+		}
+		if arg == "-" {
+			return "" // Script from stdin
+		}
+		if !strings.HasPrefix(arg, "-") {
+			if kind == InterpreterJava {
+				return strings.ToLower(arg) // Main class
+			}
+			if kind == InterpreterElectron {
+				return electronAppID(arg)
+			}
+			return scriptAppID(arg)
+		}
+
+		name, value, hasValue := strings.Cut(arg, "=")
+		switch {
+		case kind == InterpreterPython && name == "-c",
+			kind == InterpreterNode && (name == "-e" || name == "--eval" || name == "-p" || name == "--print"):
+			return "" // Inline code
+		case kind == InterpreterPython && strings.HasPrefix(arg, "-m"):
+			// -m module, also written -mmodule
+			if module := strings.TrimPrefix(arg, "-m"); module != "" {
+				return strings.ToLower(module)
+			}
+			if i+1 < len(args) {
+				return strings.ToLower(args[i+1])
+			}
+			return ""
+		case kind == InterpreterJava && name == "-jar":
+			if i+1 < len(args) {
+				return archiveAppID(args[i+1])
+			}
+			return ""
+		case kind == InterpreterJava && (name == "-m" || name == "--module"):
+			module := value
+			if !hasValue && i+1 < len(args) {
+				module = args[i+1]
+			}
+			module, _, _ = strings.Cut(module, "/")
+			return strings.ToLower(module)
+		case kind == InterpreterElectron && name == "--app":
+			if hasValue {
+				return electronAppID(value)
+			}
+			if i+1 < len(args) {
+				return electronAppID(args[i+1])
+			}
+			return ""
+		case valueOptions[name] && !hasValue:
+			i++
+		}
+	}
+	return ""
+}
+
+// scriptAppID names an app by its script, or by the folder of a generic
+// entry point: /opt/tool/bin/cli.js is "tool"
+func scriptAppID(script string) string {
+	parts := pathParts(script)
+	if len(parts) == 0 {
+		return ""
+	}
+	name := strings.ToLower(parts[len(parts)-1])
+	for _, ext := range []string{".py", ".pyw", ".pyz", ".js", ".mjs", ".cjs", ".ts"} {
+		if strings.HasSuffix(name, ext) {
+			name = strings.TrimSuffix(name, ext)
+			break
+		}
+	}
+	if !genericScriptNames[name] {
+		return name
+	}
+	if dir := appDirName(parts[:len(parts)-1]); dir != "" {
+		return dir
+	}
+	return name
+}
+
+// archiveAppID names an app by a jar or similar archive without its version:
+// server-1.20.4.jar is "server"
+func archiveAppID(archive string) string {
+	name := strings.ToLower(pathBase(archive))
+	if i := strings.LastIndexByte(name, '.'); i > 0 {
+		name = name[:i]
+	}
+	return stripVersionSuffix(name)
+}
+
+// electronAppID names an Electron app by its app folder or app.asar, skipping
+// the resources folder they are usually in:
+// /usr/lib/signal-desktop/resources/app.asar is "signal-desktop"
+func electronAppID(app string) string {
+	parts := pathParts(app)
+	if len(parts) == 0 {
+		return ""
+	}
+	if last := strings.ToLower(parts[len(parts)-1]); strings.HasSuffix(last, ".asar") {
+		if last != "app.asar" {
+			return stripVersionSuffix(strings.TrimSuffix(last, ".asar"))
+		}
+		parts = parts[:len(parts)-1]
+	}
+	return stripVersionSuffix(appDirName(parts))
+}
+
+// appDirName returns the innermost folder of parts that isn't a generic build
+// or package folder, "" if there is none
+func appDirName(parts []string) string {
+	for i := len(parts) - 1; i >= 0; i-- {
+		dir := strings.ToLower(parts[i])
+		if dir == "." || dir == ".." || strings.HasSuffix(dir, ":") || genericDirNames[dir] {
+			continue
+		}
+		return dir
+	}
+	return ""
+}
+
+// pathParts splits a Windows or Unix path into its non-empty elements, since
+// command lines from either OS may be looked at
+func pathParts(path string) []string {
+	return strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' })
+}
+
+// pathBase returns the last element of a Windows or Unix path
+func pathBase(path string) string {
+	parts := pathParts(path)
+	if len(parts) == 0 {
+		return ""
+	}
+	return parts[len(parts)-1]
+}
+
+// stripVersionSuffix cuts a version or architecture from a name at the first
+// - or _ that starts one: Obsidian-1.4.16-x86_64 is Obsidian
+func stripVersionSuffix(name string) string {
+	for i := 1; i < len(name)-1; i++ {
+		if (name[i] == '-' || name[i] == '_') && isVersionOrArch(strings.ToLower(name[i+1:])) {
+			return name[:i]
+		}
+	}
+	return name
+}
+
+// isVersionOrArch reports whether s starts with a version like 1.2 or v1.2,
+// or with an architecture name
+func isVersionOrArch(s string) bool {
+	s = strings.TrimPrefix(s, "v")
+	if s != "" && s[0] >= '0' && s[0] <= '9' {
+		return true
+	}
+	for _, arch := range []string{"x86", "amd64", "aarch64", "arm", "i386", "i686"} {
+		if strings.HasPrefix(s, arch) {
+			return true
+		}
+	}
+	return false
+}
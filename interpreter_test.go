@@ -0,0 +1,78 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestInterpreterKind tests recognizing interpreters by name, versioned or not
+func TestInterpreterKind(t *testing.T) {
+	tests := map[string]string{
+		"/usr/bin/python3.12":          InterpreterPython,
+		`C:\Python312\pythonw.exe`:     InterpreterPython,
+		"java":                         InterpreterJava,
+		"javaw.exe":                    InterpreterJava,
+		"/usr/bin/nodejs":              InterpreterNode,
+		"/usr/lib/electron32/electron": InterpreterElectron,
+		"/usr/bin/python-config":       "",
+		"/usr/bin/electron-builder":    "",
+		"/usr/share/code/code":         "",
+		"":                             "",
+	}
+	for exe, want := range tests {
+		if got := interpreterKind(exe); got != want {
+			t.Errorf("interpreterKind(%q) = %q, want %q", exe, got, want)
+		}
+	}
+}
+
+// TestInterpreterAppID tests picking the app out of interpreter command lines
+func TestInterpreterAppID(t *testing.T) {
+	tests := []struct {
+		exe  string
+		args []string
+		want string
+	}{
+		{"/usr/bin/python3.12", []string{"/usr/bin/python3", "/usr/bin/jupyter-lab", "--no-browser"}, "jupyter-lab"},
+		{"/usr/bin/python3", []string{"python3", "-u", "-X", "dev", "game.py"}, "game"},
+		{"/usr/bin/python3", []string{"python3", "-Wignore", "-m", "jupyter", "lab"}, "jupyter"},
+		{"/usr/bin/python3", []string{"python3", "-mhttp.server"}, "http.server"},
+		{"/usr/bin/python3", []string{"python3", "/home/me/tool/__main__.py"}, "tool"},
+		{"/usr/bin/python3", []string{"python3", "-c", "print(1)"}, ""},
+		{"/usr/bin/python3", []string{"python3"}, ""},
+		{`C:\Python312\pythonw.exe`, []string{`C:\Python312\pythonw.exe`, `C:\Games\Snake\snake.pyw`}, "snake"},
+		{"java", []string{"java", "-Xmx2G", "-jar", "/opt/mc/server-1.20.4.jar", "nogui"}, "server"},
+		{"java", []string{"java", "-cp", "libs/*", "-Dx=y", "net.minecraft.client.main.Main", "--demo"}, "net.minecraft.client.main.main"},
+		{"java", []string{"java", "--module-path", "mods", "-m", "com.example.app/com.example.Main"}, "com.example.app"},
+		{"node", []string{"node", "--require", "dotenv/config", "/opt/chat/dist/index.js"}, "chat"},
+		{"node", []string{"node", "/usr/lib/node_modules/npm/bin/npm-cli.js", "install"}, "npm-cli"},
+		{"node", []string{"node", "-e", "console.log(1)"}, ""},
+		{"/usr/lib/electron32/electron", []string{"electron32", "/usr/lib/signal-desktop/resources/app.asar"}, "signal-desktop"},
+		{"/usr/lib/electron/electron", []string{"electron", "--no-sandbox", "--app=/opt/Notes-2.1.0/resources/app"}, "notes"},
+		{"/usr/lib/electron/electron", []string{"electron", "/opt/tools/discord.asar"}, "discord"},
+		{"/usr/lib/electron/electron", []string{"electron", "--type=renderer", "--enable-sandbox"}, ""},
+		{"/usr/bin/bash", []string{"bash", "game.py"}, ""},
+	}
+	for _, tt := range tests {
+		if got := interpreterAppID(tt.exe, tt.args); got != tt.want {
+			t.Errorf("interpreterAppID(%q, %q) = %q, want %q", tt.exe, tt.args, got, tt.want)
+		}
+	}
+}
+
+// TestBlocklistMatchesInterpretedApp tests that an entry for a script blocks
+// it but not other programs run by the same interpreter
+func TestBlocklistMatchesInterpretedApp(t *testing.T) {
+	bm := &BlocklistManager{filePath: filepath.Join(t.TempDir(), "blocking_list.json")}
+	if err := bm.AddApp("jupyter-lab", "Jupyter"); err != nil {
+		t.Fatalf("AddApp failed: %v", err)
+	}
+	jupyter := ProcessInfo{Name: "python3.12", AppID: interpreterAppID("python3.12", []string{"python3", "/usr/bin/jupyter-lab"})}
+	other := ProcessInfo{Name: "python3.12", AppID: interpreterAppID("python3.12", []string{"python3", "backup.py"})}
+	if bm.MatchProcess(jupyter, nil) == nil {
+		t.Error("jupyter-lab run by python isn't blocked")
+	}
+	if bm.MatchProcess(other, nil) != nil {
+		t.Error("another python script is blocked")
+	}
+}
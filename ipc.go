@@ -0,0 +1,334 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ipcProtocolVersion is bumped whenever the request/response format changes
+// in a way that older clients or servers can't understand
+const ipcProtocolVersion = 1
+
+// JSON-RPC style error codes
+const (
+	ipcErrParse          = -32700
+	ipcErrInvalidRequest = -32600
+	ipcErrMethodNotFound = -32601
+	ipcErrInvalidParams  = -32602
+	ipcErrServer         = -32000
+	ipcErrUnauthorized   = -32001 // The admin password is required
+	ipcErrPartnerCode    = -32002 // A code from the accountability partner is required
+)
+
+// ipcCallTimeout bounds how long a client waits for a response
+const ipcCallTimeout = 10 * time.Second
+
+// errIPCClosed is returned by Accept once the listener has been closed
+var errIPCClosed = errors.New("ipc listener closed")
+
+// ipcRequest is a single call sent by a client. Requests are newline-delimited JSON
+type ipcRequest struct {
+	Version int             `json:"version"`
+	ID      uint64          `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// ipcResponse answers the request with the same ID
+type ipcResponse struct {
+	Version int             `json:"version"`
+	ID      uint64          `json:"id"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	Error   *IPCError       `json:"error,omitempty"`
+}
+
+// IPCError is returned to the client when a call fails
+type IPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *IPCError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// IPCHandler handles one method. The returned value is marshalled as the result
+type IPCHandler func(params json.RawMessage) (interface{}, error)
+
+// ipcListener abstracts the platform transport (Unix socket or named pipe)
+type ipcListener interface {
+	Accept() (io.ReadWriteCloser, error)
+	Close() error
+}
+
+// deadliner is implemented by transports that support I/O deadlines
+type deadliner interface {
+	SetDeadline(t time.Time) error
+}
+
+// IPCServer serves the local control interface used by the `sybr` CLI
+type IPCServer struct {
+	address  string
+	listener ipcListener
+	mu       sync.RWMutex
+	handlers map[string]IPCHandler
+	conns    map[io.ReadWriteCloser]struct{}
+	wg       sync.WaitGroup
+	running  bool
+}
+
+// NewIPCServer creates a server listening on the given address once started
+func NewIPCServer(address string) *IPCServer {
+	return &IPCServer{
+		address:  address,
+		handlers: make(map[string]IPCHandler),
+		conns:    make(map[io.ReadWriteCloser]struct{}),
+	}
+}
+
+// Handle registers a handler for a method, replacing any previous one
+func (s *IPCServer) Handle(method string, handler IPCHandler) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[method] = handler
+}
+
+// Start opens the listener and begins accepting connections
+func (s *IPCServer) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.running {
+		return fmt.Errorf("ipc server already running")
+	}
+
+	listener, err := ipcListen(s.address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.address, err)
+	}
+	s.listener = listener
+	s.running = true
+
+	s.wg.Add(1)
+	go s.acceptLoop(listener)
+	fmt.Printf("🔌 IPC server listening on %s\n", s.address)
+	return nil
+}
+
+// Stop closes the listener and all open connections
+func (s *IPCServer) Stop() {
+	s.mu.Lock()
+	if !s.running {
+		s.mu.Unlock()
+		return
+	}
+	s.running = false
+	s.listener.Close()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// acceptLoop accepts connections until the listener is closed
+func (s *IPCServer) acceptLoop(listener ipcListener) {
+	defer s.wg.Done()
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			s.mu.RLock()
+			running := s.running
+			s.mu.RUnlock()
+			if !running || errors.Is(err, errIPCClosed) {
+				return
+			}
+			fmt.Printf("⚠️  IPC accept error: %v\n", err)
+			continue
+		}
+
+		s.mu.Lock()
+		if !s.running {
+			s.mu.Unlock()
+			conn.Close()
+			return
+		}
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+
+		s.wg.Add(1)
+		go s.serveConn(conn)
+	}
+}
+
+// serveConn reads requests from one connection until it is closed
+func (s *IPCServer) serveConn(conn io.ReadWriteCloser) {
+	defer s.wg.Done()
+	defer func() {
+		s.mu.Lock()
+		delete(s.conns, conn)
+		s.mu.Unlock()
+		conn.Close()
+	}()
+
+	decoder := json.NewDecoder(conn)
+	encoder := json.NewEncoder(conn)
+	for {
+		var req ipcRequest
+		if err := decoder.Decode(&req); err != nil {
+			if err != io.EOF {
+				var syntaxErr *json.SyntaxError
+				if errors.As(err, &syntaxErr) {
+					encoder.Encode(ipcResponse{
+						Version: ipcProtocolVersion,
+						Error:   &IPCError{Code: ipcErrParse, Message: "parse error"},
+					})
+				}
+			}
+			return
+		}
+		if err := encoder.Encode(s.dispatch(req)); err != nil {
+			return
+		}
+	}
+}
+
+// dispatch runs the handler for a request and builds the response
+func (s *IPCServer) dispatch(req ipcRequest) (resp ipcResponse) {
+	resp = ipcResponse{Version: ipcProtocolVersion, ID: req.ID}
+
+	if req.Version != ipcProtocolVersion {
+		resp.Error = &IPCError{
+			Code:    ipcErrInvalidRequest,
+			Message: fmt.Sprintf("unsupported protocol version %d (server speaks %d)", req.Version, ipcProtocolVersion),
+		}
+		return resp
+	}
+
+	s.mu.RLock()
+	handler, ok := s.handlers[req.Method]
+	s.mu.RUnlock()
+	if !ok {
+		resp.Error = &IPCError{Code: ipcErrMethodNotFound, Message: fmt.Sprintf("unknown method '%s'", req.Method)}
+		return resp
+	}
+
+	// A misbehaving handler must not take the whole app down
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("❌ PANIC in IPC handler %s: %v\n", req.Method, r)
+			resp.Result = nil
+			resp.Error = &IPCError{Code: ipcErrServer, Message: fmt.Sprintf("internal error: %v", r)}
+		}
+	}()
+
+	result, err := handler(req.Params)
+	if err != nil {
+		var ipcErr *IPCError
+		if errors.As(err, &ipcErr) {
+			resp.Error = ipcErr
+		} else if errors.Is(err, errAdminAuthRequired) {
+			resp.Error = &IPCError{Code: ipcErrUnauthorized, Message: err.Error()}
+		} else if errors.Is(err, errPartnerCodeRequired) {
+			resp.Error = &IPCError{Code: ipcErrPartnerCode, Message: err.Error()}
+		} else {
+			resp.Error = &IPCError{Code: ipcErrServer, Message: err.Error()}
+		}
+		return resp
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		resp.Error = &IPCError{Code: ipcErrServer, Message: fmt.Sprintf("failed to marshal result: %v", err)}
+		return resp
+	}
+	resp.Result = data
+	return resp
+}
+
+// decodeIPCParams unmarshals handler params, reporting failures as invalid params
+func decodeIPCParams(params json.RawMessage, v interface{}) error {
+	if len(params) == 0 {
+		return &IPCError{Code: ipcErrInvalidParams, Message: "missing params"}
+	}
+	if err := json.Unmarshal(params, v); err != nil {
+		return &IPCError{Code: ipcErrInvalidParams, Message: fmt.Sprintf("invalid params: %v", err)}
+	}
+	return nil
+}
+
+// IPCClient talks to a running instance over the local control interface
+type IPCClient struct {
+	conn    io.ReadWriteCloser
+	encoder *json.Encoder
+	decoder *json.Decoder
+	mu      sync.Mutex
+	nextID  uint64
+}
+
+// DialIPC connects to the instance listening on the given address
+func DialIPC(address string) (*IPCClient, error) {
+	conn, err := ipcDial(address)
+	if err != nil {
+		return nil, err
+	}
+	return &IPCClient{
+		conn:    conn,
+		encoder: json.NewEncoder(conn),
+		decoder: json.NewDecoder(conn),
+	}, nil
+}
+
+// Call invokes a method and decodes its result into result (which may be nil)
+func (c *IPCClient) Call(method string, params interface{}, result interface{}) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextID++
+	req := ipcRequest{
+		Version: ipcProtocolVersion,
+		ID:      c.nextID,
+		Method:  method,
+	}
+	if params != nil {
+		data, err := json.Marshal(params)
+		if err != nil {
+			return fmt.Errorf("failed to marshal params: %w", err)
+		}
+		req.Params = data
+	}
+
+	if d, ok := c.conn.(deadliner); ok {
+		d.SetDeadline(time.Now().Add(ipcCallTimeout))
+		defer d.SetDeadline(time.Time{})
+	}
+
+	if err := c.encoder.Encode(req); err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	var resp ipcResponse
+	if err := c.decoder.Decode(&resp); err != nil {
+		return fmt.Errorf("failed to read response: %w", err)
+	}
+	if resp.Error != nil {
+		return resp.Error
+	}
+	if resp.ID != req.ID {
+		return fmt.Errorf("response id %d does not match request id %d", resp.ID, req.ID)
+	}
+	if result != nil && len(resp.Result) > 0 {
+		if err := json.Unmarshal(resp.Result, result); err != nil {
+			return fmt.Errorf("failed to decode result: %w", err)
+		}
+	}
+	return nil
+}
+
+// Close closes the connection
+func (c *IPCClient) Close() error {
+	return c.conn.Close()
+}
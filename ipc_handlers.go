@@ -0,0 +1,420 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+// StatusInfo is returned by the "status" method
+type StatusInfo struct {
+	ProtocolVersion int            `json:"protocolVersion"`
+	PID             int            `json:"pid"`
+	Monitoring      bool           `json:"monitoring"`
+	CurrentWindow   *WindowInfo    `json:"currentWindow,omitempty"`
+	BlockedApps     int            `json:"blockedApps"`
+	Session         *SessionStatus `json:"session,omitempty"`
+	Lock            LockStatus     `json:"lock"`
+}
+
+// blockParams are the params of "block.add" and "block.remove"
+type blockParams struct {
+	ExecutableName string `json:"executableName"`
+	DisplayName    string `json:"displayName,omitempty"`
+	Token          string `json:"token,omitempty"`       // From auth.login when an admin password is set
+	PartnerCode    string `json:"partnerCode,omitempty"` // Accountability partner's TOTP code
+}
+
+// snoozeParams are the params of "block.snooze"
+type snoozeParams struct {
+	ExecutableName string `json:"executableName"`
+	Duration       string `json:"duration"` // e.g. "30m"; "0" ends the snooze
+	Token          string `json:"token,omitempty"`
+	PartnerCode    string `json:"partnerCode,omitempty"`
+}
+
+// scopeParams are the params of "block.scope"
+type scopeParams struct {
+	ExecutableName string `json:"executableName"`
+	Scope          string `json:"scope"` // "process", "tree" or "launched"
+	Token          string `json:"token,omitempty"`
+	PartnerCode    string `json:"partnerCode,omitempty"`
+}
+
+// platformsParams are the params of "block.platforms"
+type platformsParams struct {
+	ExecutableName string   `json:"executableName"`
+	Platforms      []string `json:"platforms"` // Empty = every OS
+	Token          string   `json:"token,omitempty"`
+	PartnerCode    string   `json:"partnerCode,omitempty"`
+}
+
+// pinParams are the params of "block.pin"
+type pinParams struct {
+	ExecutableName string `json:"executableName"`
+	Target         string `json:"target"` // Path of the binary or its SHA-256
+	Token          string `json:"token,omitempty"`
+}
+
+// domainParams are the params of "block.domain"
+type domainParams struct {
+	Domain      string `json:"domain"` // Domain or URL, e.g. youtube.com
+	DisplayName string `json:"displayName,omitempty"`
+	Token       string `json:"token,omitempty"`
+}
+
+// browserTabParams are the params of "browser.tab", sent by the native
+// messaging host whenever the active tab changes
+type browserTabParams struct {
+	Browser string `json:"browser"` // Executable of the browser that started the host
+	PID     int    `json:"pid,omitempty"`
+	URL     string `json:"url"`
+	Title   string `json:"title,omitempty"`
+}
+
+// browserTabResult tells the extension whether its active tab is blocked
+type browserTabResult struct {
+	Domain      string `json:"domain,omitempty"`
+	Blocked     bool   `json:"blocked"`
+	DisplayName string `json:"displayName,omitempty"`
+}
+
+// lockParams are the params of "lock"
+type lockParams struct {
+	Duration string `json:"duration"` // e.g. "2h"
+}
+
+// pendingParams are the params of "pending.cancel"
+type pendingParams struct {
+	ID string `json:"id"`
+}
+
+// unlockParams are the params of "unlock.submit"
+type unlockParams struct {
+	ID    string `json:"id"`
+	Typed string `json:"typed"`
+}
+
+// authParams are the params of "auth.login"
+type authParams struct {
+	Password string `json:"password"`
+}
+
+// codeParams are the params of "lock.lift" and the "partner.*" methods
+type codeParams struct {
+	Code  string `json:"code,omitempty"`
+	Token string `json:"token,omitempty"` // For "partner.setup" when an admin password is set
+}
+
+// tokenParams are the params of methods that only need an admin token
+type tokenParams struct {
+	Token string `json:"token,omitempty"`
+}
+
+// historyParams are the params of "history"
+type historyParams struct {
+	Since time.Time `json:"since"`
+}
+
+// sessionParams are the params of "session.start"
+type sessionParams struct {
+	Duration string `json:"duration,omitempty"` // e.g. "50m"; empty uses the configured length
+}
+
+// registerIPCHandlers wires the control methods to the App bindings so the CLI
+// goes through exactly the same code paths as the frontend
+func registerIPCHandlers(server *IPCServer, app *App) {
+	server.Handle("status", func(params json.RawMessage) (interface{}, error) {
+		status := StatusInfo{
+			ProtocolVersion: ipcProtocolVersion,
+			PID:             os.Getpid(),
+		}
+		if app.watcher != nil {
+			snapshot := app.watcher.Snapshot()
+			status.Monitoring = snapshot.Monitoring
+			status.CurrentWindow = snapshot.Window
+		}
+		if apps, err := app.GetBlocklist(); err == nil {
+			status.BlockedApps = len(apps)
+		}
+		if app.session != nil {
+			session := app.session.Status()
+			status.Session = &session
+		}
+		status.Lock = app.lock.Status()
+		return status, nil
+	})
+
+	server.Handle("block.add", func(params json.RawMessage) (interface{}, error) {
+		var p blockParams
+		if err := decodeIPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		return nil, app.AddToBlocklist(p.ExecutableName, p.DisplayName, p.Token)
+	})
+
+	server.Handle("block.remove", func(params json.RawMessage) (interface{}, error) {
+		var p blockParams
+		if err := decodeIPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		// The result is the queued change, null if the app was removed right away
+		return app.requestRemoval(p.ExecutableName, p.Token, p.PartnerCode)
+	})
+
+	server.Handle("pending.list", func(params json.RawMessage) (interface{}, error) {
+		return app.GetPendingChanges()
+	})
+
+	server.Handle("pending.cancel", func(params json.RawMessage) (interface{}, error) {
+		var p pendingParams
+		if err := decodeIPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		return nil, app.CancelPendingChange(p.ID)
+	})
+
+	server.Handle("block.list", func(params json.RawMessage) (interface{}, error) {
+		return app.GetBlocklist()
+	})
+
+	server.Handle("block.snooze", func(params json.RawMessage) (interface{}, error) {
+		var p snoozeParams
+		if err := decodeIPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		length := time.Duration(0)
+		if p.Duration != "0" {
+			var err error
+			if length, err = parseSessionLength(p.Duration); err != nil {
+				return nil, err
+			}
+		}
+		return nil, app.SnoozeApp(p.ExecutableName, int(length/time.Minute), p.Token, p.PartnerCode)
+	})
+
+	server.Handle("block.pin", func(params json.RawMessage) (interface{}, error) {
+		var p pinParams
+		if err := decodeIPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		return nil, app.PinBlocklistHash(p.ExecutableName, p.Target, p.Token)
+	})
+
+	server.Handle("block.domain", func(params json.RawMessage) (interface{}, error) {
+		var p domainParams
+		if err := decodeIPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		return nil, app.AddDomainToBlocklist(p.Domain, p.DisplayName, p.Token)
+	})
+
+	server.Handle("browser.tab", func(params json.RawMessage) (interface{}, error) {
+		var p browserTabParams
+		if err := decodeIPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		if app.tabs == nil {
+			return nil, fmt.Errorf("browser tabs are not tracked")
+		}
+		tab := app.tabs.Report(BrowserTab{Browser: p.Browser, PID: p.PID, URL: p.URL, Title: p.Title})
+		result := browserTabResult{Domain: tab.Domain}
+		if bm, err := GetBlocklistManager(); err == nil && tab.Domain != "" {
+			if blocked := bm.MatchDomain(tab.Domain); blocked != nil {
+				result.Blocked = true
+				result.DisplayName = blocked.DisplayName
+			}
+		}
+		return result, nil
+	})
+
+	server.Handle("browser.tabs", func(params json.RawMessage) (interface{}, error) {
+		return app.GetBrowserTabs(), nil
+	})
+
+	server.Handle("proxy.attempts", func(params json.RawMessage) (interface{}, error) {
+		return app.GetDistractionAttempts()
+	})
+
+	server.Handle("block.platforms", func(params json.RawMessage) (interface{}, error) {
+		var p platformsParams
+		if err := decodeIPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		return app.requestPlatforms(p.ExecutableName, p.Platforms, p.Token, p.PartnerCode)
+	})
+
+	server.Handle("block.scope", func(params json.RawMessage) (interface{}, error) {
+		var p scopeParams
+		if err := decodeIPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		return app.requestScope(p.ExecutableName, p.Scope, p.Token, p.PartnerCode)
+	})
+
+	server.Handle("history", func(params json.RawMessage) (interface{}, error) {
+		var p historyParams
+		if len(params) > 0 {
+			if err := decodeIPCParams(params, &p); err != nil {
+				return nil, err
+			}
+		}
+		hs, err := GetHistoryStore()
+		if err != nil {
+			return nil, err
+		}
+		return hs.Since(p.Since), nil
+	})
+
+	server.Handle("session.start", func(params json.RawMessage) (interface{}, error) {
+		var p sessionParams
+		if len(params) > 0 {
+			if err := decodeIPCParams(params, &p); err != nil {
+				return nil, err
+			}
+		}
+		focus, err := parseSessionLength(p.Duration)
+		if err != nil {
+			return nil, err
+		}
+		if app.session == nil {
+			return nil, fmt.Errorf("session engine not available")
+		}
+		if err := app.session.Start(focus); err != nil {
+			return nil, err
+		}
+		return app.GetSessionStatus()
+	})
+
+	server.Handle("session.stop", func(params json.RawMessage) (interface{}, error) {
+		return nil, app.StopFocusSession()
+	})
+
+	server.Handle("session.skip", func(params json.RawMessage) (interface{}, error) {
+		if err := app.SkipSessionPhase(); err != nil {
+			return nil, err
+		}
+		return app.GetSessionStatus()
+	})
+
+	server.Handle("session.status", func(params json.RawMessage) (interface{}, error) {
+		return app.GetSessionStatus()
+	})
+
+	server.Handle("lock", func(params json.RawMessage) (interface{}, error) {
+		var p lockParams
+		if err := decodeIPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		length, err := parseSessionLength(p.Duration)
+		if err != nil {
+			return nil, err
+		}
+		if err := app.LockFor(int(length / time.Minute)); err != nil {
+			return nil, err
+		}
+		return app.GetLockStatus()
+	})
+
+	server.Handle("lock.status", func(params json.RawMessage) (interface{}, error) {
+		return app.GetLockStatus()
+	})
+
+	server.Handle("lock.lift", func(params json.RawMessage) (interface{}, error) {
+		var p codeParams
+		if len(params) > 0 {
+			if err := decodeIPCParams(params, &p); err != nil {
+				return nil, err
+			}
+		}
+		return nil, app.LiftLockWithPartnerCode(p.Code)
+	})
+
+	server.Handle("partner.status", func(params json.RawMessage) (interface{}, error) {
+		return app.GetPartnerStatus()
+	})
+
+	server.Handle("partner.setup", func(params json.RawMessage) (interface{}, error) {
+		var p codeParams
+		if len(params) > 0 {
+			if err := decodeIPCParams(params, &p); err != nil {
+				return nil, err
+			}
+		}
+		return app.SetupPartnerCode(p.Token, p.Code)
+	})
+
+	server.Handle("partner.confirm", func(params json.RawMessage) (interface{}, error) {
+		var p codeParams
+		if err := decodeIPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		return nil, app.ConfirmPartnerCode(p.Code)
+	})
+
+	server.Handle("partner.remove", func(params json.RawMessage) (interface{}, error) {
+		var p codeParams
+		if len(params) > 0 {
+			if err := decodeIPCParams(params, &p); err != nil {
+				return nil, err
+			}
+		}
+		return nil, app.RemovePartnerCode(p.Code)
+	})
+
+	server.Handle("unlock.start", func(params json.RawMessage) (interface{}, error) {
+		return app.StartEmergencyUnlock()
+	})
+
+	server.Handle("unlock.submit", func(params json.RawMessage) (interface{}, error) {
+		var p unlockParams
+		if err := decodeIPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		return nil, app.SubmitEmergencyUnlock(p.ID, p.Typed)
+	})
+
+	server.Handle("auth.login", func(params json.RawMessage) (interface{}, error) {
+		var p authParams
+		if err := decodeIPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		return app.AuthenticateAdmin(p.Password)
+	})
+
+	server.Handle("auth.status", func(params json.RawMessage) (interface{}, error) {
+		return app.GetAdminStatus()
+	})
+
+	server.Handle("quit", func(params json.RawMessage) (interface{}, error) {
+		var p tokenParams
+		if len(params) > 0 {
+			if err := decodeIPCParams(params, &p); err != nil {
+				return nil, err
+			}
+		}
+		// Respond before the process goes away
+		if err := app.admin.Authorize(p.Token, "quitting"); err != nil {
+			return nil, err
+		}
+		if err := app.lock.Check("quitting"); err != nil {
+			return nil, err
+		}
+		app.quitting.Store(true)
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			quitApp(app)
+		}()
+		return nil, nil
+	})
+
+	server.Handle("watchdog.ping", func(params json.RawMessage) (interface{}, error) {
+		return app.watchdogStatus(), nil
+	})
+
+	server.Handle("window.show", func(params json.RawMessage) (interface{}, error) {
+		app.ShowWindow()
+		return nil, nil
+	})
+}
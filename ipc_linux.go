@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// defaultIPCAddress returns the per-user Unix socket path
+// $XDG_RUNTIME_DIR is already private to the user; otherwise a 0700 directory in /tmp is used
+func defaultIPCAddress() string {
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		return filepath.Join(dir, "sybr", "sybr.sock")
+	}
+	return filepath.Join(os.TempDir(), "sybr-"+strconv.Itoa(os.Getuid()), "sybr.sock")
+}
+
+// ensurePrivateDir creates dir with 0700 permissions and refuses to use it
+// if it is owned by somebody else or accessible to other users
+func ensurePrivateDir(dir string) error {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create socket directory: %w", err)
+	}
+	fi, err := os.Lstat(dir)
+	if err != nil {
+		return err
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("%s is not a directory", dir)
+	}
+	if st, ok := fi.Sys().(*syscall.Stat_t); ok && int(st.Uid) != os.Getuid() {
+		return fmt.Errorf("%s is owned by another user", dir)
+	}
+	if fi.Mode().Perm()&0077 != 0 {
+		if err := os.Chmod(dir, 0700); err != nil {
+			return fmt.Errorf("failed to restrict permissions on %s: %w", dir, err)
+		}
+	}
+	return nil
+}
+
+// unixListener wraps a Unix socket listener and only accepts peers running as the same user
+type unixListener struct {
+	listener *net.UnixListener
+	path     string
+}
+
+// ipcListen creates the Unix socket, removing a stale one left behind by a crash
+func ipcListen(address string) (ipcListener, error) {
+	if err := ensurePrivateDir(filepath.Dir(address)); err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(address); err == nil {
+		// Something is there - if nobody answers it is a leftover from a crash
+		if conn, dialErr := net.Dial("unix", address); dialErr == nil {
+			conn.Close()
+			return nil, fmt.Errorf("another instance is already listening on %s", address)
+		}
+		if err := os.Remove(address); err != nil {
+			return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+		}
+	}
+
+	listener, err := net.ListenUnix("unix", &net.UnixAddr{Name: address, Net: "unix"})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(address, 0600); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to restrict socket permissions: %w", err)
+	}
+	return &unixListener{listener: listener, path: address}, nil
+}
+
+// Accept waits for the next connection from the same user
+func (l *unixListener) Accept() (io.ReadWriteCloser, error) {
+	for {
+		conn, err := l.listener.AcceptUnix()
+		if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return nil, errIPCClosed
+			}
+			return nil, err
+		}
+		if err := checkPeerUID(conn); err != nil {
+			fmt.Printf("⚠️  Rejected IPC connection: %v\n", err)
+			conn.Close()
+			continue
+		}
+		return conn, nil
+	}
+}
+
+// Close stops listening and removes the socket file
+func (l *unixListener) Close() error {
+	err := l.listener.Close()
+	os.Remove(l.path)
+	return err
+}
+
+// checkPeerUID verifies via SO_PEERCRED that the peer runs as the current user
+func checkPeerUID(conn *net.UnixConn) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var cred *unix.Ucred
+	var credErr error
+	if err := raw.Control(func(fd uintptr) {
+		cred, credErr = unix.GetsockoptUcred(int(fd), unix.SOL_SOCKET, unix.SO_PEERCRED)
+	}); err != nil {
+		return err
+	}
+	if credErr != nil {
+		return fmt.Errorf("failed to read peer credentials: %w", credErr)
+	}
+	if int(cred.Uid) != os.Getuid() {
+		return fmt.Errorf("peer uid %d does not match %d", cred.Uid, os.Getuid())
+	}
+	return nil
+}
+
+// ipcDial connects to the Unix socket of a running instance
+func ipcDial(address string) (io.ReadWriteCloser, error) {
+	conn, err := net.Dial("unix", address)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+	}
+	return conn, nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// testIPCAddress returns a private address for one test
+func testIPCAddress(t *testing.T) string {
+	if runtime.GOOS == "windows" {
+		return fmt.Sprintf(`\\.\pipe\sybr-test-%d-%s`, os.Getpid(), t.Name())
+	}
+	return filepath.Join(t.TempDir(), "sybr.sock")
+}
+
+// startTestIPCServer starts a server with an "echo" method and stops it on cleanup
+func startTestIPCServer(t *testing.T) (*IPCServer, string) {
+	address := testIPCAddress(t)
+	server := NewIPCServer(address)
+	server.Handle("echo", func(params json.RawMessage) (interface{}, error) {
+		var p map[string]string
+		if err := decodeIPCParams(params, &p); err != nil {
+			return nil, err
+		}
+		return p, nil
+	})
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	t.Cleanup(server.Stop)
+	return server, address
+}
+
+// TestIPCRoundTrip tests that a client can call a method and decode its result
+func TestIPCRoundTrip(t *testing.T) {
+	_, address := startTestIPCServer(t)
+
+	client, err := DialIPC(address)
+	if err != nil {
+		t.Fatalf("DialIPC() failed: %v", err)
+	}
+	defer client.Close()
+
+	// Several calls over the same connection
+	for i := 0; i < 3; i++ {
+		var result map[string]string
+		want := fmt.Sprintf("hello-%d", i)
+		if err := client.Call("echo", map[string]string{"msg": want}, &result); err != nil {
+			t.Fatalf("Call() failed: %v", err)
+		}
+		if result["msg"] != want {
+			t.Errorf("echo returned %q, want %q", result["msg"], want)
+		}
+	}
+}
+
+// TestIPCErrors tests unknown methods and invalid params are reported as IPCError
+func TestIPCErrors(t *testing.T) {
+	_, address := startTestIPCServer(t)
+
+	client, err := DialIPC(address)
+	if err != nil {
+		t.Fatalf("DialIPC() failed: %v", err)
+	}
+	defer client.Close()
+
+	var ipcErr *IPCError
+	err = client.Call("does.not.exist", nil, nil)
+	if !errors.As(err, &ipcErr) || ipcErr.Code != ipcErrMethodNotFound {
+		t.Errorf("unknown method error = %v, want code %d", err, ipcErrMethodNotFound)
+	}
+
+	err = client.Call("echo", nil, nil)
+	if !errors.As(err, &ipcErr) || ipcErr.Code != ipcErrInvalidParams {
+		t.Errorf("missing params error = %v, want code %d", err, ipcErrInvalidParams)
+	}
+}
+
+// TestIPCVersionMismatch tests that requests from a newer protocol are rejected
+func TestIPCVersionMismatch(t *testing.T) {
+	_, address := startTestIPCServer(t)
+
+	conn, err := ipcDial(address)
+	if err != nil {
+		t.Fatalf("ipcDial() failed: %v", err)
+	}
+	defer conn.Close()
+
+	req := ipcRequest{Version: ipcProtocolVersion + 1, ID: 7, Method: "echo"}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		t.Fatalf("Encode() failed: %v", err)
+	}
+	var resp ipcResponse
+	if err := json.NewDecoder(conn).Decode(&resp); err != nil {
+		t.Fatalf("Decode() failed: %v", err)
+	}
+	if resp.ID != 7 || resp.Error == nil || resp.Error.Code != ipcErrInvalidRequest {
+		t.Errorf("unexpected response: %+v", resp)
+	}
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const pipeBufferSize = 4096
+
+// currentUserSID returns the SID string of the user running this process
+func currentUserSID() (string, error) {
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return "", fmt.Errorf("failed to get current user: %w", err)
+	}
+	return user.User.Sid.String(), nil
+}
+
+// defaultIPCAddress returns the per-user named pipe path
+func defaultIPCAddress() string {
+	sid, err := currentUserSID()
+	if err != nil {
+		return `\\.\pipe\sybr`
+	}
+	return `\\.\pipe\sybr-` + sid
+}
+
+// pipeListener accepts connections on a named pipe that only the current user can open
+type pipeListener struct {
+	name    string
+	sa      *windows.SecurityAttributes
+	mu      sync.Mutex
+	closed  bool
+	first   bool
+	pending windows.Handle // instance waiting for the next client
+}
+
+// ipcListen creates the named pipe with a DACL granting access to the current user only
+func ipcListen(address string) (ipcListener, error) {
+	sid, err := currentUserSID()
+	if err != nil {
+		return nil, err
+	}
+	// Protected DACL: full access for our own SID, nothing for anyone else
+	sd, err := windows.SecurityDescriptorFromString("D:P(A;;GA;;;" + sid + ")")
+	if err != nil {
+		return nil, fmt.Errorf("failed to build security descriptor: %w", err)
+	}
+	sa := &windows.SecurityAttributes{SecurityDescriptor: sd}
+	sa.Length = uint32(unsafe.Sizeof(*sa))
+
+	l := &pipeListener{name: address, sa: sa, first: true}
+
+	// Create the first instance now so a second server fails immediately
+	h, err := l.createInstance()
+	if err != nil {
+		return nil, err
+	}
+	l.pending = h
+	return l, nil
+}
+
+// createInstance creates a new server end of the pipe
+func (l *pipeListener) createInstance() (windows.Handle, error) {
+	name, err := windows.UTF16PtrFromString(l.name)
+	if err != nil {
+		return windows.InvalidHandle, err
+	}
+
+	l.mu.Lock()
+	flags := uint32(windows.PIPE_ACCESS_DUPLEX)
+	if l.first {
+		flags |= windows.FILE_FLAG_FIRST_PIPE_INSTANCE
+		l.first = false
+	}
+	l.mu.Unlock()
+
+	h, err := windows.CreateNamedPipe(
+		name,
+		flags,
+		windows.PIPE_TYPE_BYTE|windows.PIPE_READMODE_BYTE|windows.PIPE_WAIT|windows.PIPE_REJECT_REMOTE_CLIENTS,
+		windows.PIPE_UNLIMITED_INSTANCES,
+		pipeBufferSize,
+		pipeBufferSize,
+		0,
+		l.sa,
+	)
+	if err != nil {
+		if err == windows.ERROR_ACCESS_DENIED {
+			return windows.InvalidHandle, fmt.Errorf("another instance is already listening on %s", l.name)
+		}
+		return windows.InvalidHandle, fmt.Errorf("failed to create named pipe: %w", err)
+	}
+	return h, nil
+}
+
+// Accept blocks until a client connects to a fresh pipe instance
+func (l *pipeListener) Accept() (io.ReadWriteCloser, error) {
+	if l.isClosed() {
+		return nil, errIPCClosed
+	}
+
+	l.mu.Lock()
+	h := l.pending
+	l.pending = 0
+	l.mu.Unlock()
+	if h == 0 {
+		var err error
+		h, err = l.createInstance()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := windows.ConnectNamedPipe(h, nil)
+	if err != nil && err != windows.ERROR_PIPE_CONNECTED {
+		windows.CloseHandle(h)
+		return nil, fmt.Errorf("failed to connect named pipe: %w", err)
+	}
+
+	if l.isClosed() {
+		// Woken up by Close dialing in
+		windows.DisconnectNamedPipe(h)
+		windows.CloseHandle(h)
+		return nil, errIPCClosed
+	}
+
+	// Keep an instance listening so clients never see the pipe disappear
+	if next, err := l.createInstance(); err == nil {
+		l.mu.Lock()
+		l.pending = next
+		l.mu.Unlock()
+	}
+
+	return &pipeConn{handle: h, file: os.NewFile(uintptr(h), l.name)}, nil
+}
+
+// Close stops accepting connections and unblocks a pending Accept
+func (l *pipeListener) Close() error {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return nil
+	}
+	l.closed = true
+	l.mu.Unlock()
+
+	// ConnectNamedPipe has no cancellation in blocking mode, so connect to ourselves
+	if conn, err := ipcDial(l.name); err == nil {
+		conn.Close()
+	}
+
+	l.mu.Lock()
+	if l.pending != 0 {
+		windows.CloseHandle(l.pending)
+		l.pending = 0
+	}
+	l.mu.Unlock()
+	return nil
+}
+
+func (l *pipeListener) isClosed() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.closed
+}
+
+// pipeConn is the server side of one pipe connection
+type pipeConn struct {
+	handle windows.Handle
+	file   *os.File
+	once   sync.Once
+}
+
+func (c *pipeConn) Read(p []byte) (int, error) {
+	return c.file.Read(p)
+}
+
+func (c *pipeConn) Write(p []byte) (int, error) {
+	return c.file.Write(p)
+}
+
+// Close flushes pending output before disconnecting the client
+func (c *pipeConn) Close() error {
+	var err error
+	c.once.Do(func() {
+		windows.FlushFileBuffers(c.handle)
+		windows.DisconnectNamedPipe(c.handle)
+		err = c.file.Close()
+	})
+	return err
+}
+
+// ipcDial opens the client end of the named pipe, retrying while all instances are busy
+func ipcDial(address string) (io.ReadWriteCloser, error) {
+	name, err := windows.UTF16PtrFromString(address)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		h, err := windows.CreateFile(
+			name,
+			windows.GENERIC_READ|windows.GENERIC_WRITE,
+			0,
+			nil,
+			windows.OPEN_EXISTING,
+			windows.SECURITY_SQOS_PRESENT|windows.SECURITY_IDENTIFICATION,
+			0,
+		)
+		if err == nil {
+			return os.NewFile(uintptr(h), address), nil
+		}
+		if err != windows.ERROR_PIPE_BUSY || time.Now().After(deadline) {
+			return nil, fmt.Errorf("failed to connect to %s: %w", address, err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
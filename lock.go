@@ -0,0 +1,122 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// errCommitmentLocked is wrapped by every action refused by the commitment lock
+var errCommitmentLocked = errors.New("commitment lock is active")
+
+// LockStatus describes the commitment lock
+type LockStatus struct {
+	Locked      bool      `json:"locked"`
+	Until       time.Time `json:"until"`       // End of the timed lock, zero if there is none
+	ForSession  bool      `json:"forSession"`  // Locked because a session is running
+	Description string    `json:"description"` // e.g. "until 15:30"
+}
+
+// sessionSource is the part of SessionEngine the lock looks at
+type sessionSource interface {
+	Status() SessionStatus
+	Config() SessionConfig
+}
+
+// CommitmentLock refuses actions that weaken enforcement while a timed lock or a
+// locked focus session is active. Adding blocks is always allowed. The timed
+// lock's expiry lives in the state store so restarting sybr doesn't lift it
+type CommitmentLock struct {
+	clock   Clock
+	store   *StateStore
+	session sessionSource
+}
+
+// NewCommitmentLock creates a lock; store and session may be nil
+func NewCommitmentLock(clock Clock, store *StateStore, session sessionSource) *CommitmentLock {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &CommitmentLock{clock: clock, store: store, session: session}
+}
+
+// Status reports whether the lock is active and why
+func (cl *CommitmentLock) Status() LockStatus {
+	status := LockStatus{}
+	if cl == nil {
+		return status
+	}
+
+	now := cl.clock.Now()
+	broken := false
+	if cl.store != nil {
+		// An unreadable state.json may hold a timed lock, so assume it does
+		broken = cl.store.Err() != nil
+		status.Locked = broken
+		if until := cl.store.Get().LockedUntil; until.After(now) {
+			status.Locked = true
+			status.Until = until
+		}
+	}
+	if cl.session != nil && cl.session.Config().LockDuringSession && cl.session.Status().State != SessionIdle {
+		status.Locked = true
+		status.ForSession = true
+	}
+
+	switch {
+	case broken:
+		status.Description = "until state.json is fixed or the lock is lifted"
+	case status.ForSession && !status.Until.IsZero():
+		status.Description = fmt.Sprintf("until %s and while the focus session runs", status.Until.Local().Format("Jan 2 15:04"))
+	case status.ForSession:
+		status.Description = "while the focus session runs"
+	case status.Locked:
+		status.Description = fmt.Sprintf("until %s", status.Until.Local().Format("Jan 2 15:04"))
+	}
+	return status
+}
+
+// Check returns an error if action is not allowed right now
+// action describes what is being refused, e.g. "removing apps from the blocklist"
+func (cl *CommitmentLock) Check(action string) error {
+	status := cl.Status()
+	if !status.Locked {
+		return nil
+	}
+	fmt.Printf("🔒 Refused %s: locked %s\n", action, status.Description)
+	return fmt.Errorf("%w: %s is not allowed %s", errCommitmentLocked, action, status.Description)
+}
+
+// LockFor starts or extends the timed lock. It never shortens a running lock
+func (cl *CommitmentLock) LockFor(d time.Duration) error {
+	if d <= 0 {
+		return fmt.Errorf("lock length must be positive")
+	}
+	if cl.store == nil {
+		return fmt.Errorf("timed lock unavailable: state can't be saved")
+	}
+	until := cl.clock.Now().Add(d)
+	return cl.store.Update(func(state *EnforcementState) error {
+		if state.LockedUntil.After(until) {
+			return nil
+		}
+		state.LockedUntil = until
+		fmt.Printf("🔒 Commitment lock until %s\n", until.Local().Format("Jan 2 15:04"))
+		return nil
+	})
+}
+
+// Lift ends the timed lock early; used by the emergency unlock. It also
+// replaces a state.json that couldn't be read
+func (cl *CommitmentLock) Lift() error {
+	if cl.store == nil {
+		return nil
+	}
+	if err := cl.store.Update(func(state *EnforcementState) error {
+		state.LockedUntil = time.Time{}
+		return nil
+	}); err != nil {
+		return err
+	}
+	return cl.store.Reset()
+}
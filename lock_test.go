@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestCommitmentLockTimed tests that a timed lock refuses actions until it
+// expires, survives reopening the store and can't be shortened
+func TestCommitmentLockTimed(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	store, err := newStateStore(path)
+	if err != nil {
+		t.Fatalf("newStateStore failed: %v", err)
+	}
+	clock := newFakeClock()
+	lock := NewCommitmentLock(clock, store, nil)
+
+	if err := lock.Check("removing apps"); err != nil {
+		t.Fatalf("unlocked Check failed: %v", err)
+	}
+	if err := lock.LockFor(2 * time.Hour); err != nil {
+		t.Fatalf("LockFor failed: %v", err)
+	}
+	if err := lock.LockFor(time.Hour); err != nil {
+		t.Fatalf("shorter LockFor failed: %v", err)
+	}
+
+	// A restart must not lift the lock
+	reopened, err := newStateStore(path)
+	if err != nil {
+		t.Fatalf("reopening failed: %v", err)
+	}
+	lock = NewCommitmentLock(clock, reopened, nil)
+	clock.Advance(90 * time.Minute)
+	if err := lock.Check("removing apps"); !errors.Is(err, errCommitmentLocked) {
+		t.Fatalf("Check after 90m = %v, want errCommitmentLocked", err)
+	}
+	clock.Advance(31 * time.Minute)
+	if err := lock.Check("removing apps"); err != nil {
+		t.Errorf("Check after expiry failed: %v", err)
+	}
+}
+
+// TestCommitmentLockBrokenState tests that an unreadable state.json keeps the
+// lock on without being overwritten until the lock is lifted
+func TestCommitmentLockBrokenState(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	corrupt := []byte(`{"lockedUntil": "2099-01-01T00:00:00Z"}x`)
+	if err := os.WriteFile(path, corrupt, 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	store, err := newStateStore(path)
+	if err == nil || store == nil {
+		t.Fatalf("newStateStore = %v, %v, want a broken store and an error", store, err)
+	}
+	lock := NewCommitmentLock(newFakeClock(), store, nil)
+	if err := lock.Check("quitting"); !errors.Is(err, errCommitmentLocked) {
+		t.Fatalf("Check with a broken state = %v, want errCommitmentLocked", err)
+	}
+
+	// Changes are kept in memory without replacing the file
+	if err := store.Snooze("discord", time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Snooze failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, corrupt) {
+		t.Fatalf("state.json was overwritten with %s", data)
+	}
+
+	if err := lock.Lift(); err != nil {
+		t.Fatalf("Lift failed: %v", err)
+	}
+	if err := lock.Check("quitting"); err != nil {
+		t.Errorf("Check after lifting failed: %v", err)
+	}
+	if _, err := newStateStore(path); err != nil {
+		t.Errorf("state.json is still unreadable after lifting: %v", err)
+	}
+}
+
+// TestCommitmentLockSession tests that a session only locks when configured to
+func TestCommitmentLockSession(t *testing.T) {
+	clock := newFakeClock()
+	config := testSessionConfig()
+	engine := NewSessionEngine(nil, clock, nil, config)
+	lock := NewCommitmentLock(clock, nil, engine)
+
+	if err := engine.Start(0); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if lock.Status().Locked {
+		t.Error("session without lockDuringSession should not lock")
+	}
+
+	config.LockDuringSession = true
+	if err := engine.SetConfig(config); err != nil {
+		t.Fatalf("SetConfig failed: %v", err)
+	}
+	if status := lock.Status(); !status.Locked || !status.ForSession {
+		t.Errorf("Status = %+v, want locked for the session", status)
+	}
+
+	if err := engine.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+	if lock.Status().Locked {
+		t.Error("lock should end with the session")
+	}
+}
+
+// TestBlocklistGuard tests that removals and profile downgrades go through the
+// guard while adding blocks and widening profiles don't
+func TestBlocklistGuard(t *testing.T) {
+	bm := &BlocklistManager{filePath: filepath.Join(t.TempDir(), "blocking_list.json")}
+	if err := bm.AddApp("discord.exe", "Discord"); err != nil {
+		t.Fatalf("AddApp failed: %v", err)
+	}
+	if err := bm.SetAppProfiles("discord.exe", []string{"focus"}); err != nil {
+		t.Fatalf("SetAppProfiles failed: %v", err)
+	}
+
+	refused := []string{}
+	bm.SetGuard(func(action string) error {
+		refused = append(refused, action)
+		return errCommitmentLocked
+	})
+
+	if err := bm.AddApp("steam.exe", "Steam"); err != nil {
+		t.Errorf("AddApp under lock failed: %v", err)
+	}
+	if err := bm.SetAppProfiles("discord.exe", []string{"focus", "evening"}); err != nil {
+		t.Errorf("widening profiles under lock failed: %v", err)
+	}
+	if err := bm.SetAppProfiles("discord.exe", []string{"evening"}); !errors.Is(err, errCommitmentLocked) {
+		t.Errorf("narrowing profiles = %v, want errCommitmentLocked", err)
+	}
+	if err := bm.RemoveApp("discord.exe"); !errors.Is(err, errCommitmentLocked) {
+		t.Errorf("RemoveApp = %v, want errCommitmentLocked", err)
+	}
+	if len(refused) != 2 {
+		t.Errorf("guard consulted for %v, want 2 refusals", refused)
+	}
+	if !bm.IsBlocked("steam.exe") {
+		t.Error("steam.exe should be blocked")
+	}
+}
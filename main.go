@@ -2,21 +2,13 @@ package main
 
 import (
 	"context"
-	"embed"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
-
-	"github.com/getlantern/systray"
-	"github.com/wailsapp/wails/v2"
-	"github.com/wailsapp/wails/v2/pkg/options"
-	"github.com/wailsapp/wails/v2/pkg/options/assetserver"
-	"github.com/wailsapp/wails/v2/pkg/runtime"
+	"time"
 )
 
-//go:embed all:frontend/dist
-var assets embed.FS
-
 // Global variables to share between Wails and systray
 var (
 	globalWatcher *WindowWatcher
@@ -25,6 +17,50 @@ var (
 )
 
 func main() {
+	// A subcommand turns this binary into a client for the running instance
+	if len(os.Args) > 1 && isCLICommand(os.Args[1]) {
+		os.Exit(runCLI(os.Args[1:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == watchdogCommand {
+		os.Exit(runWatchdog(os.Args[2:]))
+	}
+	// Browsers start the binary as the native messaging host of the extension
+	if isNativeHostLaunch(os.Args[1:]) {
+		os.Exit(runNativeHost())
+	}
+
+	launchArgs, err := parseLaunchArgs(os.Args[1:])
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(2)
+	}
+
+	// Only one instance per user may run; a second launch hands over its
+	// arguments (e.g. --show, --block, --session) to the running one and exits
+	instanceLock, err := AcquireInstanceLock()
+	if errors.Is(err, errAlreadyRunning) {
+		fmt.Println("sybr is already running, forwarding arguments")
+		if err := forwardToRunningInstance(defaultIPCAddress(), os.Args[1:]); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		os.Exit(0)
+	} else if err != nil {
+		fmt.Printf("⚠️  Failed to acquire instance lock: %v\n", err)
+	}
+	defer instanceLock.Release()
+
+	// Headless mode has no console window to look at, so log to a file by default
+	headless := launchArgs.Headless || !guiAvailable
+	if launchArgs.LogFile != "" || headless {
+		closeLog, err := redirectOutputToLogFile(launchArgs.LogFile)
+		if err != nil {
+			fmt.Printf("⚠️  Failed to open log file: %v\n", err)
+		} else {
+			defer closeLog()
+		}
+	}
+
 	// Get executable path for auto-start
 	exePath, err := os.Executable()
 	if err != nil {
@@ -37,185 +73,171 @@ func main() {
 	app := NewApp()
 	globalApp = app
 
-	// Create watcher with nil context initially
-	// The context will be set in OnStartup with the live Wails context
+	// Everything the watcher and enforcement observe goes through the bus;
+	// the Wails bridge subscribes in OnStartup once the live context exists
+	bus := NewEventBus()
+	app.bus = bus
+
 	watcher := NewWindowWatcher(nil)
+	watcher.SetEventBus(bus)
+	app.tabs = NewBrowserTabs()
+	watcher.SetBrowserTabs(app.tabs)
 	globalWatcher = watcher
 	app.watcher = watcher
 
-	// Start systray in a goroutine
-	go func() {
-		systray.Run(func() {
-			setupSystemTray(exePath, watcher)
-		}, func() {
-			// On exit, stop monitoring
-			if watcher != nil {
-				watcher.StopMonitoring()
-			}
-		})
-	}()
+	enforcer := NewEnforcer(bus)
+	enforcer.Start()
+	defer enforcer.Stop()
 
-	// Create application with options
-	// In Wails v2, we must explicitly bind the App struct using the Bind field
-	// This generates bindings that make methods available via window.go.main.App
+	// Focus sessions switch the enforced blocklist profile as they advance
+	settings := DefaultSettings()
+	if sm, err := GetSettingsManager(); err == nil {
+		settings = sm.Get()
+	} else {
+		fmt.Printf("⚠️  Failed to load settings: %v\n", err)
+	}
+	var profiles ProfileSwitcher
+	if bm, err := GetBlocklistManager(); err == nil {
+		profiles = bm
+	}
+	app.session = NewSessionEngine(bus, realClock{}, profiles, settings.Session)
+
+	// Resume a session that was running when sybr quit or crashed
+	store, err := GetStateStore()
+	if err != nil && store != nil {
+		fmt.Printf("⚠️  Refusing weakening changes and quitting until state.json is fixed or the lock is lifted: %v\n", err)
+	} else if err != nil {
+		fmt.Printf("⚠️  Failed to load state, sessions won't survive a restart: %v\n", err)
+	}
+	if store != nil {
+		saved := store.Get()
+		app.session.SetStore(store)
+		app.session.Restore(saved.Session, saved.LastSeen)
+
+		stopHeartbeat := make(chan struct{})
+		StartStateHeartbeat(store, stopHeartbeat)
+		defer close(stopHeartbeat)
+	}
 
-	// Check if we're in dev mode
-	// Wails v2 should automatically proxy to dev server when running wails dev
-	// But if it's not working, we can try to detect dev mode
-	isDev := os.Getenv("WAILS_ENV") == "dev" || os.Getenv("devmode") == "true"
+	// The commitment lock refuses weakening the blocklist during sessions and timed locks
+	app.lock = NewCommitmentLock(realClock{}, store, app.session)
+	if bm, err := GetBlocklistManager(); err == nil {
+		bm.SetGuard(app.lock.Check)
+
+		// Removals and profile downgrades wait for the cooling-off period
+		delay := time.Duration(settings.UnlockDelayMinutes) * time.Minute
+		app.pending = NewPendingQueue(realClock{}, store, bm, bus, delay)
+		app.pending.Start()
+		defer app.pending.Stop()
+
+		// Catch blocked apps running in the background or minimized
+		if settings.ProcessScan.Enabled {
+			scanner := NewProcessScanner(bus, settings.ProcessScan, bm, enforcer, store)
+			scanner.Start()
+			defer scanner.Stop()
+		}
 
-	assetServerOptions := &assetserver.Options{
-		Assets: assets,
-	}
+		// Block websites for every browser once the system resolver points here
+		if settings.DNS.Enabled {
+			proxy := NewDNSProxy(settings.DNS, bm, store, app.session)
+			if err := proxy.Start(); err != nil {
+				fmt.Printf("⚠️  Failed to start the DNS proxy: %v\n", err)
+			} else {
+				defer proxy.Stop()
+			}
+		}
 
-	// In dev mode, Wails should automatically proxy to Vite dev server
-	// Make sure Vite is running on http://localhost:34115 before starting Wails
-	if isDev {
-		fmt.Println("🔧 Dev mode detected - Wails should proxy to Vite dev server")
-		fmt.Println("🔧 Make sure Vite is running: cd frontend && npm run dev")
-	} else {
-		fmt.Println("📦 Production mode - using embedded assets")
-	}
-
-	err = wails.Run(&options.App{
-		Title:            "Window Monitor",
-		Width:            1200,
-		Height:           800,
-		AssetServer:      assetServerOptions,
-		BackgroundColour: &options.RGBA{R: 18, G: 18, B: 18, A: 1},
-		// Bind the app instance - this is critical for frontend access
-		Bind: []interface{}{
-			app,
-		},
-		OnStartup: func(ctx context.Context) {
-			// Store context for systray menu
-			wailsCtx = ctx
-
-			// Call app's OnStartup - this will set the context on the watcher
-			// and start monitoring with the correct Wails context
-			app.OnStartup(ctx)
-		},
-		OnShutdown: func(ctx context.Context) {
-			// Stop monitoring when app shuts down
-			if watcher != nil {
-				watcher.StopMonitoring()
+		// Or once browsers use the PAC file of the HTTP proxy
+		if settings.HTTPProxy.Enabled {
+			app.distractionLog, _ = getDataFilePath("distractions.jsonl")
+			proxy := NewHTTPProxy(bus, settings.HTTPProxy, bm, store, app.session, app.distractionLog)
+			if err := proxy.Start(); err != nil {
+				fmt.Printf("⚠️  Failed to start the HTTP proxy: %v\n", err)
+			} else {
+				defer proxy.Stop()
 			}
-			// Quit systray
-			systray.Quit()
-		},
-	})
+		}
+	}
 
+	// The emergency unlock lifts the lock after a tedious typing challenge
+	unlockLog, err := getDataFilePath("emergency_unlocks.jsonl")
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
+		fmt.Printf("⚠️  Emergency unlocks won't be logged: %v\n", err)
+	}
+	app.emergency = NewEmergencyUnlocker(realClock{}, settings.EmergencyPassageWords, unlockLog)
+
+	// An optional admin password gates blocklist and settings changes, and an
+	// accountability partner's TOTP codes gate lifting locks and removing blocks.
+	// Settings that can't be loaded may hold either, so then everything gated is refused
+	sm, err := GetSettingsManager()
+	app.admin = NewAdminAuth(realClock{}, sm, store)
+	app.partner = NewPartnerAuth(realClock{}, sm, store)
+	if err != nil {
+		fmt.Printf("⚠️  Refusing protected changes until settings.json is fixed: %v\n", err)
+		app.admin.refuseAll(err)
+		app.partner.refuseAll(err)
 	}
-}
 
-func setupSystemTray(exePath string, watcher *WindowWatcher) {
-	// Set up system tray icon and menu
-	// Only set icon if we have a valid one
-	if icon := getIcon(); icon != nil && len(icon) > 0 {
-		systray.SetIcon(icon)
-	}
-	systray.SetTitle("Window Monitor")
-	systray.SetTooltip("Window Monitor - Running")
-
-	// Create menu items
-	mStatus := systray.AddMenuItem("Window Monitor", "Window Monitor Status")
-	mStatus.Disable()
-	systray.AddSeparator()
-
-	mShowWindow := systray.AddMenuItem("Show Window", "Show the main window")
-	mHideWindow := systray.AddMenuItem("Hide Window", "Hide the main window")
-	systray.AddSeparator()
-
-	mEnableAutoStart := systray.AddMenuItem("Enable Auto-Start", "Enable auto-start on Windows boot")
-	mDisableAutoStart := systray.AddMenuItem("Disable Auto-Start", "Disable auto-start on Windows boot")
-	systray.AddSeparator()
-
-	mQuit := systray.AddMenuItem("Quit", "Quit Window Monitor")
-
-	// Check auto-start status and update menu
-	updateAutoStartMenu(mEnableAutoStart, mDisableAutoStart)
-
-	// Handle menu clicks
-	go func() {
-		for {
-			select {
-			case <-mShowWindow.ClickedCh:
-				if globalApp != nil {
-					globalApp.ShowWindow()
-				}
-			case <-mHideWindow.ClickedCh:
-				if globalApp != nil {
-					globalApp.HideWindow()
-				}
-			case <-mEnableAutoStart.ClickedCh:
-				if exePath != "" {
-					if err := EnableAutoStart(exePath); err != nil {
-						fmt.Printf("Failed to enable auto-start: %v\n", err)
-						systray.SetTooltip("Window Monitor - Failed to enable auto-start")
-					} else {
-						fmt.Println("Auto-start enabled successfully")
-						systray.SetTooltip("Window Monitor - Auto-start Enabled")
-						updateAutoStartMenu(mEnableAutoStart, mDisableAutoStart)
-					}
-				}
-			case <-mDisableAutoStart.ClickedCh:
-				if err := DisableAutoStart(); err != nil {
-					fmt.Printf("Failed to disable auto-start: %v\n", err)
-					systray.SetTooltip("Window Monitor - Failed to disable auto-start")
-				} else {
-					fmt.Println("Auto-start disabled successfully")
-					systray.SetTooltip("Window Monitor - Auto-start Disabled")
-					updateAutoStartMenu(mEnableAutoStart, mDisableAutoStart)
-				}
-			case <-mQuit.ClickedCh:
-				if watcher != nil {
-					watcher.StopMonitoring()
-				}
-				// Quit Wails app
-				if wailsCtx != nil {
-					runtime.Quit(wailsCtx)
-				}
-				systray.Quit()
-				return
-			}
+	if stopHotkeys, err := StartHotkeys(app); err == nil {
+		defer stopHotkeys()
+	} else {
+		fmt.Printf("⚠️  Hotkeys unavailable: %v\n", err)
+	}
+
+	if hs, err := GetHistoryStore(); err == nil {
+		defer StartHistoryRecorder(bus, hs).Unsubscribe()
+	} else {
+		fmt.Printf("⚠️  Failed to open history: %v\n", err)
+	}
+
+	// Editor plugins pointed at the WakaTime endpoint name the project of editor windows
+	if settings.WakaTime.Enabled {
+		coding := NewCodingActivity()
+		watcher.SetCodingActivity(coding)
+		wakaTime := NewWakaTimeServer(settings.WakaTime, coding)
+		if err := wakaTime.Start(); err != nil {
+			fmt.Printf("⚠️  Failed to start the WakaTime endpoint: %v\n", err)
+		} else {
+			defer wakaTime.Stop()
 		}
-	}()
-}
+	}
 
-// updateAutoStartMenu updates the menu items based on auto-start status
-func updateAutoStartMenu(mEnable, mDisable *systray.MenuItem) {
-	enabled, err := IsAutoStartEnabled()
-	if err != nil {
-		fmt.Printf("Error checking auto-start status: %v\n", err)
-		return
+	// ActivityWatch dashboards can show the same window changes
+	if settings.ActivityWatch.Enabled {
+		activityWatch := NewActivityWatch(bus, settings.ActivityWatch, watcher.Snapshot().Window)
+		activityWatch.Start()
+		defer activityWatch.Stop()
+	}
+
+	// Start the local control interface used by the `sybr` CLI
+	ipcServer := NewIPCServer(defaultIPCAddress())
+	registerIPCHandlers(ipcServer, app)
+	registerInstanceHandlers(ipcServer, app)
+	if err := ipcServer.Start(); err != nil {
+		fmt.Printf("⚠️  Failed to start IPC server: %v\n", err)
+	}
+
+	// The watchdog helper restarts sybr if it is killed during a session or lock
+	app.tamperLog, _ = getDataFilePath("tamper.jsonl")
+	var watchdog *WatchdogSupervisor
+	if settings.Watchdog && exePath != "" {
+		watchdog = StartWatchdogSupervisor(app, exePath, os.Args[1:])
 	}
 
-	if enabled {
-		mEnable.Hide()
-		mDisable.Show()
+	if headless {
+		err = runHeadless(app, watcher, launchArgs, exePath)
 	} else {
-		mEnable.Show()
-		mDisable.Hide()
+		err = runGUI(app, watcher, launchArgs, exePath)
 	}
-}
+	if watchdog != nil {
+		// Only a quit that passed the lock and admin checks releases the watchdog
+		watchdog.Stop(app.quitting.Load())
+	}
+	ipcServer.Stop()
 
-// getIcon returns a simple icon byte array
-// For a real application, you would load an actual .ico file
-// Returning nil means no custom icon will be set (systray will use default)
-func getIcon() []byte {
-	// Return nil - systray will handle the default icon
-	// To add a custom icon:
-	// 1. Create a .ico file (16x16 or 32x32 recommended)
-	// 2. Embed it: //go:embed icon.ico
-	// 3. var iconData []byte
-	// 4. Return iconData here
-	iconData, err := os.ReadFile("build/windows/icon.ico")
 	if err != nil {
-		return nil
+		fmt.Printf("Error: %v\n", err)
 	}
-	return iconData
 }
 
 // getExecutablePath returns the current executable path
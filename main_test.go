@@ -2,12 +2,18 @@ package main
 
 import (
 	"context"
+	"os"
+	"runtime"
 	"testing"
 	"time"
 )
 
 // TestGetActiveWindow tests the GetActiveWindow function
 func TestGetActiveWindow(t *testing.T) {
+	if runtime.GOOS == "linux" && os.Getenv("DISPLAY") == "" {
+		t.Skip("no X display available")
+	}
+
 	ctx := context.Background()
 	watcher := NewWindowWatcher(ctx)
 
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// ShowSystemWarning shows a desktop notification for the warning
+// zenity is preferred because it blocks like the Windows MessageBox; notify-send
+// is used as a fallback. Returns 1 (like IDOK) when the warning was shown
+func ShowSystemWarning(title, message string) (int, error) {
+	fmt.Printf("🔔 ShowSystemWarning: %s - %s\n", title, message)
+
+	if path, err := exec.LookPath("zenity"); err == nil {
+		// zenity exits with 1 when the dialog is closed instead of confirmed
+		err := exec.Command(path, "--warning", "--title", title, "--text", message, "--no-markup").Run()
+		if _, ok := err.(*exec.ExitError); err == nil || ok {
+			return 1, nil
+		}
+	}
+
+	if path, err := exec.LookPath("notify-send"); err == nil {
+		if err := exec.Command(path, "--urgency=critical", "--app-name=sybr", title, message).Run(); err != nil {
+			return 0, fmt.Errorf("notify-send failed: %w", err)
+		}
+		return 1, nil
+	}
+
+	return 0, fmt.Errorf("no notification tool found (install zenity or libnotify)")
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// nativeHostName is the name the extension connects to
+	nativeHostName = "sybr.native_host"
+	// nativeHostCommand starts the host by hand, e.g. to try it from a shell
+	nativeHostCommand = "native-host"
+	// maxNativeMessageSize caps messages from the extension; tab reports are tiny
+	maxNativeMessageSize = 1 << 20
+
+	// chromeExtensionID is the ID of the bundled extension, fixed by the key
+	// in browser-extension/manifest.json
+	chromeExtensionID = "gggpjbdejmgghefjbhpldbbjjdbbkhkk"
+	// firefoxExtensionID is the gecko ID in browser-extension/manifest.json
+	firefoxExtensionID = "browser@sybr"
+)
+
+// nativeMessage is a message from the extension
+type nativeMessage struct {
+	Type  string `json:"type"` // "tab" or "ping"
+	URL   string `json:"url,omitempty"`
+	Title string `json:"title,omitempty"`
+}
+
+// nativeReply is the answer to a nativeMessage
+type nativeReply struct {
+	Type        string `json:"type"` // "tab", "pong" or "error"
+	Domain      string `json:"domain,omitempty"`
+	Blocked     bool   `json:"blocked,omitempty"`
+	DisplayName string `json:"displayName,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// isNativeHostLaunch reports whether a browser started the binary as its
+// native messaging host: Chrome passes the extension's origin, Firefox the
+// path of the host manifest and the extension ID
+func isNativeHostLaunch(args []string) bool {
+	if len(args) == 0 {
+		return false
+	}
+	if args[0] == nativeHostCommand || strings.HasPrefix(args[0], "chrome-extension://") {
+		return true
+	}
+	return len(args) >= 2 && strings.HasSuffix(args[0], nativeHostName+".json")
+}
+
+// runNativeHost relays tab reports from the extension to the running
+// instance until the browser closes the connection, and returns the exit code
+func runNativeHost() int {
+	// stdout carries the protocol, anything else printed there would break it
+	protocol := os.Stdout
+	os.Stdout = os.Stderr
+
+	browser, pid := nativeHostBrowser()
+	host := &nativeHost{address: defaultIPCAddress(), browser: browser, pid: pid}
+	defer host.close()
+	if err := host.serve(os.Stdin, protocol); err != nil {
+		fmt.Fprintf(os.Stderr, "sybr native host: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// nativeHostBrowser returns the browser that started the host: the first
+// ancestor that isn't a shell, since Chrome on Windows goes through cmd.exe
+func nativeHostBrowser() (string, int) {
+	for _, p := range processAncestors(os.Getpid()) {
+		if wrapperProcesses[p.Name] {
+			continue
+		}
+		return normalizeExecutableName(p.Name), p.PID
+	}
+	return "", 0
+}
+
+// nativeHost forwards messages of one browser to the running instance,
+// reconnecting when sybr was restarted in between
+type nativeHost struct {
+	address string
+	browser string
+	pid     int
+	client  *IPCClient
+}
+
+// serve answers messages from r on w until r is closed
+func (h *nativeHost) serve(r io.Reader, w io.Writer) error {
+	for {
+		data, err := readNativeMessage(r)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := writeNativeMessage(w, h.handle(data)); err != nil {
+			return err
+		}
+	}
+}
+
+// handle answers one message
+func (h *nativeHost) handle(data []byte) nativeReply {
+	var msg nativeMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return nativeReply{Type: "error", Error: "malformed message"}
+	}
+	switch msg.Type {
+	case "ping":
+		return nativeReply{Type: "pong"}
+	case "tab":
+		var result browserTabResult
+		params := browserTabParams{Browser: h.browser, PID: h.pid, URL: msg.URL, Title: msg.Title}
+		if err := h.call("browser.tab", params, &result); err != nil {
+			return nativeReply{Type: "error", Error: err.Error()}
+		}
+		return nativeReply{Type: "tab", Domain: result.Domain, Blocked: result.Blocked, DisplayName: result.DisplayName}
+	}
+	return nativeReply{Type: "error", Error: fmt.Sprintf("unknown message type '%s'", msg.Type)}
+}
+
+// call calls the running instance, redialing once if the connection broke
+func (h *nativeHost) call(method string, params, result interface{}) error {
+	for attempt := 0; ; attempt++ {
+		if h.client == nil {
+			client, err := DialIPC(h.address)
+			if err != nil {
+				return fmt.Errorf("sybr is not running")
+			}
+			h.client = client
+		}
+		err := h.client.Call(method, params, result)
+		var ipcErr *IPCError
+		if err == nil || errors.As(err, &ipcErr) || attempt > 0 {
+			return err
+		}
+		h.close()
+	}
+}
+
+// close drops the connection to the running instance
+func (h *nativeHost) close() {
+	if h.client != nil {
+		h.client.Close()
+		h.client = nil
+	}
+}
+
+// readNativeMessage reads one message: a 32-bit length in native byte order
+// followed by that much JSON
+func readNativeMessage(r io.Reader) ([]byte, error) {
+	var length uint32
+	if err := binary.Read(r, binary.NativeEndian, &length); err != nil {
+		return nil, err
+	}
+	if length > maxNativeMessageSize {
+		return nil, fmt.Errorf("message of %d bytes is too large", length)
+	}
+	data := make([]byte, length)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, fmt.Errorf("truncated message: %w", err)
+	}
+	return data, nil
+}
+
+// writeNativeMessage writes v as one message
+func writeNativeMessage(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if err := binary.Write(w, binary.NativeEndian, uint32(len(data))); err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// nativeHostManifest tells a browser how to start the host; Chromium based
+// browsers use AllowedOrigins and Firefox AllowedExtensions
+type nativeHostManifest struct {
+	Name              string   `json:"name"`
+	Description       string   `json:"description"`
+	Path              string   `json:"path"`
+	Type              string   `json:"type"`
+	AllowedOrigins    []string `json:"allowed_origins,omitempty"`
+	AllowedExtensions []string `json:"allowed_extensions,omitempty"`
+}
+
+// newNativeHostManifest returns the manifest for the binary at exePath;
+// extensionIDs are extra Chrome extension IDs, e.g. of a self-packed build
+func newNativeHostManifest(exePath string, firefox bool, extensionIDs []string) nativeHostManifest {
+	manifest := nativeHostManifest{
+		Name:        nativeHostName,
+		Description: "sybr active tab reporting",
+		Path:        exePath,
+		Type:        "stdio",
+	}
+	if firefox {
+		manifest.AllowedExtensions = []string{firefoxExtensionID}
+		return manifest
+	}
+	for _, id := range append([]string{chromeExtensionID}, extensionIDs...) {
+		origin := "chrome-extension://" + id + "/"
+		if !containsString(manifest.AllowedOrigins, origin) {
+			manifest.AllowedOrigins = append(manifest.AllowedOrigins, origin)
+		}
+	}
+	return manifest
+}
+
+// writeNativeHostManifest writes the manifest as JSON to path
+func writeNativeHostManifest(path string, manifest nativeHostManifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// nativeHostBrowsers are where browsers look for host manifests, relative to
+// the config directory (~/.config) or, for Firefox, the home directory. The
+// manifest is only installed for browsers whose directory exists
+var nativeHostBrowsers = []struct {
+	name    string
+	dir     string
+	firefox bool
+}{
+	{"Google Chrome", "google-chrome", false},
+	{"Chromium", "chromium", false},
+	{"Brave", "BraveSoftware/Brave-Browser", false},
+	{"Microsoft Edge", "microsoft-edge", false},
+	{"Vivaldi", "vivaldi", false},
+	{"Firefox", ".mozilla", true},
+}
+
+// nativeHostLocation is where a browser keeps its profile and host manifests
+type nativeHostLocation struct {
+	name     string
+	dir      string // The browser's directory, it is installed if this exists
+	manifest string
+	firefox  bool
+}
+
+// nativeHostLocations resolves nativeHostBrowsers for the current user
+func nativeHostLocations() ([]nativeHostLocation, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return nil, err
+	}
+	locations := make([]nativeHostLocation, 0, len(nativeHostBrowsers))
+	for _, browser := range nativeHostBrowsers {
+		dir := filepath.Join(config, browser.dir)
+		hosts := "NativeMessagingHosts"
+		if browser.firefox {
+			dir = filepath.Join(home, browser.dir)
+			hosts = "native-messaging-hosts"
+		}
+		locations = append(locations, nativeHostLocation{
+			name:     browser.name,
+			dir:      dir,
+			manifest: filepath.Join(dir, hosts, nativeHostName+".json"),
+			firefox:  browser.firefox,
+		})
+	}
+	return locations, nil
+}
+
+// installNativeHost writes the host manifest for every installed browser and
+// returns their names
+func installNativeHost(exePath string, extensionIDs []string) ([]string, error) {
+	locations, err := nativeHostLocations()
+	if err != nil {
+		return nil, err
+	}
+	installed := []string{}
+	for _, location := range locations {
+		if _, err := os.Stat(location.dir); err != nil {
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(location.manifest), 0755); err != nil {
+			return installed, fmt.Errorf("failed to create %s: %w", filepath.Dir(location.manifest), err)
+		}
+		manifest := newNativeHostManifest(exePath, location.firefox, extensionIDs)
+		if err := writeNativeHostManifest(location.manifest, manifest); err != nil {
+			return installed, fmt.Errorf("failed to write %s: %w", location.manifest, err)
+		}
+		installed = append(installed, location.name)
+	}
+	if len(installed) == 0 {
+		return nil, fmt.Errorf("no supported browser found")
+	}
+	return installed, nil
+}
+
+// uninstallNativeHost removes the host manifests and returns the browsers
+// they were removed from
+func uninstallNativeHost() ([]string, error) {
+	locations, err := nativeHostLocations()
+	if err != nil {
+		return nil, err
+	}
+	removed := []string{}
+	for _, location := range locations {
+		err := os.Remove(location.manifest)
+		if err == nil {
+			removed = append(removed, location.name)
+		} else if !os.IsNotExist(err) {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+)
+
+// TestNativeMessageRoundTrip tests the length-prefixed framing and that
+// oversized messages are refused
+func TestNativeMessageRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeNativeMessage(&buf, nativeMessage{Type: "tab", URL: "https://example.com/"}); err != nil {
+		t.Fatalf("writeNativeMessage failed: %v", err)
+	}
+	data, err := readNativeMessage(&buf)
+	if err != nil {
+		t.Fatalf("readNativeMessage failed: %v", err)
+	}
+	var msg nativeMessage
+	if err := json.Unmarshal(data, &msg); err != nil || msg.URL != "https://example.com/" {
+		t.Fatalf("read back %s (%v)", data, err)
+	}
+
+	buf.Reset()
+	binary.Write(&buf, binary.NativeEndian, uint32(maxNativeMessageSize+1))
+	if _, err := readNativeMessage(&buf); err == nil {
+		t.Error("an oversized message should be refused")
+	}
+	buf.Reset()
+	binary.Write(&buf, binary.NativeEndian, uint32(10))
+	buf.WriteString("{}")
+	if _, err := readNativeMessage(&buf); err == nil {
+		t.Error("a truncated message should fail")
+	}
+}
+
+// TestIsNativeHostLaunch tests recognizing the arguments browsers start hosts with
+func TestIsNativeHostLaunch(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"chrome-extension://" + chromeExtensionID + "/"}, true},
+		{[]string{"chrome-extension://" + chromeExtensionID + "/", "--parent-window=0"}, true},
+		{[]string{"/home/me/.mozilla/native-messaging-hosts/" + nativeHostName + ".json", firefoxExtensionID}, true},
+		{[]string{nativeHostCommand}, true},
+		{[]string{"--headless"}, false},
+		{[]string{"settings.json", "x"}, false},
+		{nil, false},
+	}
+	for _, tt := range tests {
+		if got := isNativeHostLaunch(tt.args); got != tt.want {
+			t.Errorf("isNativeHostLaunch(%q) = %v, want %v", tt.args, got, tt.want)
+		}
+	}
+}
+
+// TestNativeHostRelaysTabs tests that tab reports reach the running instance
+// with the browser that started the host and that its answer is relayed
+func TestNativeHostRelaysTabs(t *testing.T) {
+	server, address := startTestIPCServer(t)
+	var got browserTabParams
+	server.Handle("browser.tab", func(params json.RawMessage) (interface{}, error) {
+		if err := decodeIPCParams(params, &got); err != nil {
+			return nil, err
+		}
+		return browserTabResult{Domain: "youtube.com", Blocked: true, DisplayName: "YouTube"}, nil
+	})
+
+	var in, out bytes.Buffer
+	writeNativeMessage(&in, nativeMessage{Type: "ping"})
+	writeNativeMessage(&in, nativeMessage{Type: "tab", URL: "https://www.youtube.com/watch", Title: "Video"})
+	writeNativeMessage(&in, nativeMessage{Type: "bogus"})
+
+	host := &nativeHost{address: address, browser: "firefox", pid: 4242}
+	defer host.close()
+	if err := host.serve(&in, &out); err != nil {
+		t.Fatalf("serve failed: %v", err)
+	}
+
+	want := []nativeReply{
+		{Type: "pong"},
+		{Type: "tab", Domain: "youtube.com", Blocked: true, DisplayName: "YouTube"},
+	}
+	for i, w := range want {
+		data, err := readNativeMessage(&out)
+		if err != nil {
+			t.Fatalf("reply %d: %v", i, err)
+		}
+		var reply nativeReply
+		json.Unmarshal(data, &reply)
+		if reply != w {
+			t.Errorf("reply %d = %+v, want %+v", i, reply, w)
+		}
+	}
+	data, _ := readNativeMessage(&out)
+	var reply nativeReply
+	if json.Unmarshal(data, &reply); reply.Type != "error" {
+		t.Errorf("unknown message reply = %s, want an error", data)
+	}
+
+	if got.Browser != "firefox" || got.PID != 4242 || got.URL != "https://www.youtube.com/watch" {
+		t.Errorf("instance got %+v", got)
+	}
+}
+
+// TestNativeHostWithoutInstance tests that the extension hears about sybr not
+// running instead of the host quitting
+func TestNativeHostWithoutInstance(t *testing.T) {
+	host := &nativeHost{address: testIPCAddress(t), browser: "chrome"}
+	reply := host.handle([]byte(`{"type":"tab","url":"https://example.com"}`))
+	if reply.Type != "error" || reply.Error == "" {
+		t.Errorf("reply = %+v, want an error", reply)
+	}
+}
+
+// TestNativeHostManifest tests the allowed extensions for both browser families
+func TestNativeHostManifest(t *testing.T) {
+	chrome := newNativeHostManifest("/usr/bin/sybr", false, []string{"abcdefghijklmnopabcdefghijklmnop", chromeExtensionID})
+	if len(chrome.AllowedOrigins) != 2 || chrome.AllowedOrigins[0] != "chrome-extension://"+chromeExtensionID+"/" {
+		t.Errorf("allowed origins = %q", chrome.AllowedOrigins)
+	}
+	if chrome.AllowedExtensions != nil || chrome.Type != "stdio" || chrome.Name != nativeHostName {
+		t.Errorf("chrome manifest = %+v", chrome)
+	}
+	firefox := newNativeHostManifest("/usr/bin/sybr", true, nil)
+	if len(firefox.AllowedExtensions) != 1 || firefox.AllowedExtensions[0] != firefoxExtensionID || firefox.AllowedOrigins != nil {
+		t.Errorf("firefox manifest = %+v", firefox)
+	}
+}
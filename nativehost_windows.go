@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// nativeHostBrowsers are the registry keys browsers look up host manifests
+// under, in HKEY_CURRENT_USER
+var nativeHostBrowsers = []struct {
+	name    string
+	key     string
+	firefox bool
+}{
+	{"Google Chrome", `Software\Google\Chrome\NativeMessagingHosts`, false},
+	{"Chromium", `Software\Chromium\NativeMessagingHosts`, false},
+	{"Brave", `Software\BraveSoftware\Brave-Browser\NativeMessagingHosts`, false},
+	{"Microsoft Edge", `Software\Microsoft\Edge\NativeMessagingHosts`, false},
+	{"Firefox", `Software\Mozilla\NativeMessagingHosts`, true},
+}
+
+// nativeHostManifestDir is where the manifests are written, %AppData%\sybr
+func nativeHostManifestDir() (string, error) {
+	config, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(config, "sybr"), nil
+}
+
+// installNativeHost writes the host manifests and registers them for every
+// supported browser, installed or not, and returns their names
+func installNativeHost(exePath string, extensionIDs []string) ([]string, error) {
+	dir, err := nativeHostManifestDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create %s: %w", dir, err)
+	}
+	chromium := filepath.Join(dir, nativeHostName+".json")
+	firefox := filepath.Join(dir, nativeHostName+".firefox.json")
+	if err := writeNativeHostManifest(chromium, newNativeHostManifest(exePath, false, extensionIDs)); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", chromium, err)
+	}
+	if err := writeNativeHostManifest(firefox, newNativeHostManifest(exePath, true, nil)); err != nil {
+		return nil, fmt.Errorf("failed to write %s: %w", firefox, err)
+	}
+
+	installed := []string{}
+	for _, browser := range nativeHostBrowsers {
+		manifestPath := chromium
+		if browser.firefox {
+			manifestPath = firefox
+		}
+		key, _, err := registry.CreateKey(registry.CURRENT_USER, browser.key+`\`+nativeHostName, registry.SET_VALUE)
+		if err != nil {
+			return installed, fmt.Errorf("failed to register with %s: %w", browser.name, err)
+		}
+		err = key.SetStringValue("", manifestPath)
+		key.Close()
+		if err != nil {
+			return installed, fmt.Errorf("failed to register with %s: %w", browser.name, err)
+		}
+		installed = append(installed, browser.name)
+	}
+	return installed, nil
+}
+
+// uninstallNativeHost removes the registrations and manifests and returns
+// the browsers the host was removed from
+func uninstallNativeHost() ([]string, error) {
+	removed := []string{}
+	for _, browser := range nativeHostBrowsers {
+		err := registry.DeleteKey(registry.CURRENT_USER, browser.key+`\`+nativeHostName)
+		if err == nil {
+			removed = append(removed, browser.name)
+		} else if err != registry.ErrNotExist {
+			return removed, err
+		}
+	}
+	if dir, err := nativeHostManifestDir(); err == nil {
+		os.Remove(filepath.Join(dir, nativeHostName+".json"))
+		os.Remove(filepath.Join(dir, nativeHostName+".firefox.json"))
+	}
+	return removed, nil
+}
@@ -0,0 +1,291 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// errPartnerCodeRequired is wrapped by every action refused for a missing
+// accountability partner code
+var errPartnerCodeRequired = errors.New("accountability partner code required")
+
+// TOTP parameters (RFC 6238 defaults, which every authenticator app supports)
+const (
+	totpPeriod = 30 * time.Second
+	totpDigits = 6
+	// totpSkew is how many periods before and after now are accepted, for
+	// clocks that are a little off
+	totpSkew = 1
+	// totpSecretLength is the secret size in bytes, the HMAC-SHA1 block size
+	// recommended by RFC 4226
+	totpSecretLength = 20
+)
+
+// PartnerConfig is the accountability partner's TOTP secret as stored in
+// settings.json; empty means no partner
+type PartnerConfig struct {
+	Secret string `json:"secret,omitempty"` // Base32, as in the otpauth URI
+}
+
+// PartnerSetup is shown once when a partner is set up, for the partner to scan
+type PartnerSetup struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"`    // otpauth://totp/...
+	QRCode string `json:"qrCode"` // PNG data URL of the URI
+}
+
+// PartnerStatus describes the accountability partner protection
+type PartnerStatus struct {
+	Enabled bool `json:"enabled"`
+	Pending bool `json:"pending"` // A setup is waiting for its first code
+}
+
+// PartnerAuth checks TOTP codes from the accountability partner's
+// authenticator app. A code is accepted once: the time step it belongs to is
+// stored in the state store and older steps are refused afterwards
+type PartnerAuth struct {
+	mu       sync.Mutex
+	clock    Clock
+	settings adminSettings
+	store    *StateStore
+	limiter  authLimiter
+	lastStep int64  // Used when there is no store
+	pending  string // Secret of a setup that hasn't been confirmed yet
+	broken   error  // Settings couldn't be loaded, refuse everything gated
+}
+
+// NewPartnerAuth creates the partner protection; store may be nil
+func NewPartnerAuth(clock Clock, settings adminSettings, store *StateStore) *PartnerAuth {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &PartnerAuth{
+		clock:    clock,
+		settings: settings,
+		store:    store,
+		limiter:  authLimiter{clock: clock, store: store},
+	}
+}
+
+// refuseAll makes every gated action fail with err; used when the settings
+// that may hold the partner's secret couldn't be loaded
+func (pa *PartnerAuth) refuseAll(err error) {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	pa.broken = err
+}
+
+// unavailable returns why the protection refuses everything, nil normally
+func (pa *PartnerAuth) unavailable() error {
+	if pa == nil {
+		return nil
+	}
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	return pa.broken
+}
+
+// Enabled reports whether a partner secret is set
+func (pa *PartnerAuth) Enabled() bool {
+	if pa.unavailable() != nil {
+		return true
+	}
+	if pa == nil || pa.settings == nil {
+		return false
+	}
+	return pa.settings.Get().Partner.Secret != ""
+}
+
+// Status reports whether a partner is set up
+func (pa *PartnerAuth) Status() PartnerStatus {
+	status := PartnerStatus{Enabled: pa.Enabled()}
+	if pa != nil {
+		pa.mu.Lock()
+		status.Pending = pa.pending != ""
+		pa.mu.Unlock()
+	}
+	return status
+}
+
+// Require checks a partner code for a gated action; anything passes without a partner
+func (pa *PartnerAuth) Require(code, action string) error {
+	if err := pa.unavailable(); err != nil {
+		fmt.Printf("🤝 Refused %s: settings couldn't be loaded\n", action)
+		return fmt.Errorf("%w for %s: settings couldn't be loaded: %v", errPartnerCodeRequired, action, err)
+	}
+	if !pa.Enabled() {
+		return nil
+	}
+	if strings.TrimSpace(code) == "" {
+		fmt.Printf("🤝 Refused %s: partner code required\n", action)
+		return fmt.Errorf("%w for %s", errPartnerCodeRequired, action)
+	}
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	if err := pa.verify(pa.settings.Get().Partner.Secret, code); err != nil {
+		return err
+	}
+	fmt.Printf("🤝 Partner code accepted for %s\n", action)
+	return nil
+}
+
+// Setup generates a new secret for the partner to scan. It only takes effect
+// once Confirm gets a code generated from it; replacing an existing partner
+// needs a current code from them
+func (pa *PartnerAuth) Setup(code string) (PartnerSetup, error) {
+	if err := pa.Require(code, "replacing the accountability partner"); err != nil {
+		return PartnerSetup{}, err
+	}
+
+	raw := make([]byte, totpSecretLength)
+	if _, err := rand.Read(raw); err != nil {
+		return PartnerSetup{}, fmt.Errorf("failed to generate secret: %w", err)
+	}
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+	uri := totpURI(secret)
+	png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+	if err != nil {
+		return PartnerSetup{}, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	pa.mu.Lock()
+	pa.pending = secret
+	pa.mu.Unlock()
+	fmt.Println("🤝 Accountability partner setup started")
+	return PartnerSetup{
+		Secret: secret,
+		URI:    uri,
+		QRCode: "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// Confirm saves the pending secret once the partner's app produced a valid
+// code for it, which proves they scanned it
+func (pa *PartnerAuth) Confirm(code string) error {
+	pa.mu.Lock()
+	defer pa.mu.Unlock()
+	if pa.pending == "" {
+		return fmt.Errorf("no partner setup in progress")
+	}
+	if err := pa.verify(pa.pending, code); err != nil {
+		return err
+	}
+	secret := pa.pending
+	err := pa.settings.Update(func(s *Settings) error {
+		s.Partner = PartnerConfig{Secret: secret}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	pa.pending = ""
+	fmt.Println("🤝 Accountability partner set up")
+	return nil
+}
+
+// Remove drops the partner; it needs a current code from them
+func (pa *PartnerAuth) Remove(code string) error {
+	if !pa.Enabled() {
+		return fmt.Errorf("no accountability partner is set up")
+	}
+	if err := pa.Require(code, "removing the accountability partner"); err != nil {
+		return err
+	}
+	return pa.settings.Update(func(s *Settings) error {
+		s.Partner = PartnerConfig{}
+		return nil
+	})
+}
+
+// verify checks code against secret within the skew window and records the
+// step it matched so the same code can't be used twice. Callers hold pa.mu
+func (pa *PartnerAuth) verify(secret, code string) error {
+	if err := pa.limiter.check(); err != nil {
+		return err
+	}
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return fmt.Errorf("invalid partner secret: %w", err)
+	}
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+
+	current := pa.clock.Now().Unix() / int64(totpPeriod/time.Second)
+	last := pa.lastUsedStep()
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) != 1 {
+			continue
+		}
+		if step <= last {
+			return fmt.Errorf("this code was already used, wait for the next one")
+		}
+		pa.limiter.reset()
+		return pa.recordStep(step)
+	}
+	fmt.Println("🤝 Wrong partner code")
+	return pa.limiter.fail("wrong partner code")
+}
+
+func (pa *PartnerAuth) lastUsedStep() int64 {
+	if pa.store == nil {
+		return pa.lastStep
+	}
+	return pa.store.Get().PartnerLastStep
+}
+
+func (pa *PartnerAuth) recordStep(step int64) error {
+	if pa.store == nil {
+		pa.lastStep = step
+		return nil
+	}
+	return pa.store.Update(func(state *EnforcementState) error {
+		state.PartnerLastStep = step
+		return nil
+	})
+}
+
+// totpCode computes the RFC 4226 HOTP value for a time step (RFC 6238)
+func totpCode(key []byte, step int64) string {
+	var counter [8]byte
+	binary.BigEndian.PutUint64(counter[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(counter[:])
+	sum := mac.Sum(nil)
+
+	// Dynamic truncation
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulus := uint32(1)
+	for i := 0; i < totpDigits; i++ {
+		modulus *= 10
+	}
+	return fmt.Sprintf("%0*d", totpDigits, value%modulus)
+}
+
+// totpURI builds the otpauth URI authenticator apps import
+func totpURI(secret string) string {
+	label := "sybr"
+	if host, err := os.Hostname(); err == nil && host != "" {
+		label = "sybr:" + host
+	}
+	params := url.Values{}
+	params.Set("secret", secret)
+	params.Set("issuer", "sybr")
+	params.Set("algorithm", "SHA1")
+	params.Set("digits", fmt.Sprintf("%d", totpDigits))
+	params.Set("period", fmt.Sprintf("%d", int(totpPeriod/time.Second)))
+	return "otpauth://totp/" + url.PathEscape(label) + "?" + params.Encode()
+}
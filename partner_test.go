@@ -0,0 +1,146 @@
+package main
+
+import (
+	"encoding/base32"
+	"errors"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestPartnerAuth returns a PartnerAuth backed by settings and state files in dir
+func newTestPartnerAuth(t *testing.T, dir string, clock Clock) *PartnerAuth {
+	t.Helper()
+	sm, err := newSettingsManager(filepath.Join(dir, "settings.json"))
+	if err != nil {
+		t.Fatalf("newSettingsManager failed: %v", err)
+	}
+	store, err := newStateStore(filepath.Join(dir, "state.json"))
+	if err != nil {
+		t.Fatalf("newStateStore failed: %v", err)
+	}
+	return NewPartnerAuth(clock, sm, store)
+}
+
+// codeAt returns the code an authenticator app shows for secret at now
+func codeAt(t *testing.T, secret string, now time.Time) string {
+	t.Helper()
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		t.Fatalf("bad secret %q: %v", secret, err)
+	}
+	return totpCode(key, now.Unix()/int64(totpPeriod/time.Second))
+}
+
+// TestTOTPCode tests the code generation against the RFC 6238 SHA1 test vectors,
+// truncated to six digits
+func TestTOTPCode(t *testing.T) {
+	key := []byte("12345678901234567890")
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		if got := totpCode(key, tt.unix/30); got != tt.want {
+			t.Errorf("totpCode at %d = %s, want %s", tt.unix, got, tt.want)
+		}
+	}
+}
+
+// TestPartnerSetup tests that a secret only takes effect once confirmed and
+// that the URI carries it
+func TestPartnerSetup(t *testing.T) {
+	clock := newFakeClock()
+	partner := newTestPartnerAuth(t, t.TempDir(), clock)
+
+	setup, err := partner.Setup("")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	uri, err := url.Parse(setup.URI)
+	if err != nil || uri.Scheme != "otpauth" || uri.Host != "totp" {
+		t.Fatalf("URI = %q, want otpauth://totp/...", setup.URI)
+	}
+	if uri.Query().Get("secret") != setup.Secret {
+		t.Errorf("URI secret = %q, want %q", uri.Query().Get("secret"), setup.Secret)
+	}
+	if !strings.HasPrefix(setup.QRCode, "data:image/png;base64,") {
+		t.Errorf("QRCode doesn't look like a PNG data URL")
+	}
+	if partner.Enabled() {
+		t.Fatal("partner enabled before Confirm")
+	}
+
+	code := codeAt(t, setup.Secret, clock.Now())
+	wrong := "000000"
+	if code == wrong {
+		wrong = "111111"
+	}
+	if err := partner.Confirm(wrong); err == nil {
+		t.Fatal("Confirm accepted a wrong code")
+	}
+	if err := partner.Confirm(code); err != nil {
+		t.Fatalf("Confirm failed: %v", err)
+	}
+	if !partner.Enabled() {
+		t.Fatal("partner not enabled after Confirm")
+	}
+
+	// Replacing the partner needs their code
+	if _, err := partner.Setup(""); !errors.Is(err, errPartnerCodeRequired) {
+		t.Fatalf("Setup without a code = %v, want errPartnerCodeRequired", err)
+	}
+}
+
+// TestPartnerRequire tests skew tolerance and that codes can't be replayed,
+// not even after a restart
+func TestPartnerRequire(t *testing.T) {
+	dir := t.TempDir()
+	clock := newFakeClock()
+	partner := newTestPartnerAuth(t, dir, clock)
+	setup, err := partner.Setup("")
+	if err != nil {
+		t.Fatalf("Setup failed: %v", err)
+	}
+	if err := partner.Confirm(codeAt(t, setup.Secret, clock.Now())); err != nil {
+		t.Fatalf("Confirm failed: %v", err)
+	}
+
+	if err := partner.Require("", "removing apps"); !errors.Is(err, errPartnerCodeRequired) {
+		t.Fatalf("Require without a code = %v, want errPartnerCodeRequired", err)
+	}
+
+	// The partner's phone runs one period ahead
+	clock.Advance(totpPeriod)
+	ahead := codeAt(t, setup.Secret, clock.Now().Add(totpPeriod))
+	if err := partner.Require(ahead, "removing apps"); err != nil {
+		t.Fatalf("Require with a code one period ahead failed: %v", err)
+	}
+	if err := partner.Require(ahead, "removing apps"); err == nil {
+		t.Fatal("the same code was accepted twice")
+	}
+
+	// Two periods off is too much
+	clock.Advance(10 * totpPeriod)
+	if err := partner.Require(codeAt(t, setup.Secret, clock.Now().Add(-2*totpPeriod)), "removing apps"); err == nil {
+		t.Fatal("a code two periods old was accepted")
+	}
+
+	clock.Advance(totpPeriod)
+	current := codeAt(t, setup.Secret, clock.Now())
+	if err := partner.Require(current, "removing apps"); err != nil {
+		t.Fatalf("Require with the current code failed: %v", err)
+	}
+	partner = newTestPartnerAuth(t, dir, clock)
+	if err := partner.Require(current, "removing apps"); err == nil {
+		t.Fatal("a used code was accepted after a restart")
+	}
+}
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// getDataFilePath returns the path of a data file stored next to the blocklist
+// In dev mode this is the current working directory, in production the
+// executable directory is used as a fallback
+func getDataFilePath(name string) (string, error) {
+	wd, err := os.Getwd()
+	if err != nil {
+		// Fallback to executable directory
+		exePath, exeErr := os.Executable()
+		if exeErr != nil {
+			return "", fmt.Errorf("failed to get working directory or executable path: %w", err)
+		}
+		wd = filepath.Dir(exePath)
+	}
+	return filepath.Join(wd, name), nil
+}
@@ -0,0 +1,295 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Kinds of delayed changes
+const (
+	PendingRemoveApp      = "remove-app"
+	PendingNarrowProfiles = "narrow-profiles"
+	PendingNarrowScope    = "narrow-scope"
+	PendingNarrowPlatform = "narrow-platforms"
+)
+
+// pendingRetryInterval is how soon a due change that couldn't be applied
+// (e.g. because of the commitment lock) is retried
+const pendingRetryInterval = time.Minute
+
+// PendingChange is a weakening blocklist change waiting for its cooling-off period
+type PendingChange struct {
+	ID             string    `json:"id"`
+	Kind           string    `json:"kind"`
+	ExecutableName string    `json:"executableName"`
+	Profiles       []string  `json:"profiles,omitempty"`  // New profiles for PendingNarrowProfiles
+	Scope          string    `json:"scope,omitempty"`     // New scope for PendingNarrowScope
+	Platforms      []string  `json:"platforms,omitempty"` // New platforms for PendingNarrowPlatform
+	RequestedAt    time.Time `json:"requestedAt"`
+	EffectiveAt    time.Time `json:"effectiveAt"`
+}
+
+// PendingChangedEvent is the payload of EventPendingChanged
+type PendingChangedEvent struct {
+	Change PendingChange `json:"change"`
+	Reason string        `json:"reason"` // queued, cancelled, applied
+}
+
+// pendingActions describe each kind of change for the commitment lock
+var pendingActions = map[string]string{
+	PendingRemoveApp:      "removing apps from the blocklist",
+	PendingNarrowProfiles: "narrowing the profiles of a blocked app",
+	PendingNarrowScope:    "narrowing the scope of a blocked app",
+	PendingNarrowPlatform: "limiting the platforms of a blocked app",
+}
+
+// pendingTarget applies changes (BlocklistManager)
+type pendingTarget interface {
+	RemoveApp(executableName string) error
+	SetAppProfiles(executableName string, profiles []string) error
+	SetAppScope(executableName, scope string) error
+	SetAppPlatforms(executableName string, platforms []string) error
+	checkGuard(action string) error
+}
+
+// PendingQueue delays changes that weaken the blocklist. They are kept in the
+// state store so the delay keeps running across restarts, and applied by a
+// timer once they are due
+type PendingQueue struct {
+	mu     sync.Mutex
+	clock  Clock
+	store  *StateStore
+	target pendingTarget
+	bus    *EventBus
+	delay  time.Duration
+	timer  Timer
+}
+
+// NewPendingQueue creates a queue; bus may be nil
+func NewPendingQueue(clock Clock, store *StateStore, target pendingTarget, bus *EventBus, delay time.Duration) *PendingQueue {
+	if clock == nil {
+		clock = realClock{}
+	}
+	return &PendingQueue{
+		clock:  clock,
+		store:  store,
+		target: target,
+		bus:    bus,
+		delay:  delay,
+	}
+}
+
+// Start applies changes that became due while sybr was down and arms the timer
+func (pq *PendingQueue) Start() {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+	pq.applyDueLocked()
+}
+
+// Stop cancels the timer
+func (pq *PendingQueue) Stop() {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+	if pq.timer != nil {
+		pq.timer.Stop()
+		pq.timer = nil
+	}
+}
+
+// Request applies change after the configured delay, or right away if there
+// is no delay. It returns the queued change, nil if it was applied. Changes
+// the commitment lock refuses now are not queued, so they can't go through
+// the moment it ends
+func (pq *PendingQueue) Request(change PendingChange) (*PendingChange, error) {
+	if err := pq.target.checkGuard(pendingActions[change.Kind]); err != nil {
+		return nil, err
+	}
+	if pq.delay <= 0 || pq.store == nil {
+		return nil, pq.apply(change)
+	}
+
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	now := pq.clock.Now()
+	change.ID = newPendingID()
+	change.RequestedAt = now
+	change.EffectiveAt = now.Add(pq.delay)
+
+	err := pq.store.Update(func(state *EnforcementState) error {
+		for _, pending := range state.Pending {
+			if pending.Kind == change.Kind && pending.ExecutableName == change.ExecutableName {
+				return fmt.Errorf("a change for '%s' is already pending until %s",
+					change.ExecutableName, pending.EffectiveAt.Local().Format("Jan 2 15:04"))
+			}
+		}
+		state.Pending = append(state.Pending, change)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	fmt.Printf("⏳ Queued %s for %s until %s\n", change.Kind, change.ExecutableName, change.EffectiveAt.Local().Format("Jan 2 15:04"))
+	pq.publish(change, "queued")
+	pq.armLocked(false)
+	return &change, nil
+}
+
+// Cancel drops a pending change
+func (pq *PendingQueue) Cancel(id string) error {
+	if pq.store == nil {
+		return fmt.Errorf("no pending change '%s'", id)
+	}
+
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+
+	var cancelled *PendingChange
+	err := pq.store.Update(func(state *EnforcementState) error {
+		for i, pending := range state.Pending {
+			if pending.ID == id {
+				cancelled = &pending
+				state.Pending = append(state.Pending[:i], state.Pending[i+1:]...)
+				return nil
+			}
+		}
+		return fmt.Errorf("no pending change '%s'", id)
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("↩️  Cancelled %s for %s\n", cancelled.Kind, cancelled.ExecutableName)
+	pq.publish(*cancelled, "cancelled")
+	pq.armLocked(false)
+	return nil
+}
+
+// List returns the pending changes, soonest first
+func (pq *PendingQueue) List() []PendingChange {
+	if pq.store == nil {
+		return []PendingChange{}
+	}
+	pending := pq.store.Get().Pending
+	sort.Slice(pending, func(i, j int) bool { return pending[i].EffectiveAt.Before(pending[j].EffectiveAt) })
+	return pending
+}
+
+// apply performs a change on the blocklist
+func (pq *PendingQueue) apply(change PendingChange) error {
+	switch change.Kind {
+	case PendingRemoveApp:
+		return pq.target.RemoveApp(change.ExecutableName)
+	case PendingNarrowProfiles:
+		return pq.target.SetAppProfiles(change.ExecutableName, change.Profiles)
+	case PendingNarrowScope:
+		return pq.target.SetAppScope(change.ExecutableName, change.Scope)
+	case PendingNarrowPlatform:
+		return pq.target.SetAppPlatforms(change.ExecutableName, change.Platforms)
+	}
+	return fmt.Errorf("unknown change '%s'", change.Kind)
+}
+
+// applyDueLocked applies every due change and re-arms the timer
+// Caller must hold the lock
+func (pq *PendingQueue) applyDueLocked() {
+	if pq.store == nil {
+		return
+	}
+	now := pq.clock.Now()
+	retry := false
+	for _, change := range pq.store.Get().Pending {
+		if change.EffectiveAt.After(now) {
+			continue
+		}
+		if err := pq.apply(change); err != nil && !isPermanentPendingError(pq.target, change) {
+			// Most likely the commitment lock; try again once it may have ended
+			fmt.Printf("⏳ Can't apply %s for %s yet: %v\n", change.Kind, change.ExecutableName, err)
+			retry = true
+			continue
+		} else if err != nil {
+			fmt.Printf("⚠️  Dropping %s for %s: %v\n", change.Kind, change.ExecutableName, err)
+		}
+
+		id := change.ID
+		if err := pq.store.Update(func(state *EnforcementState) error {
+			for i, pending := range state.Pending {
+				if pending.ID == id {
+					state.Pending = append(state.Pending[:i], state.Pending[i+1:]...)
+					break
+				}
+			}
+			return nil
+		}); err != nil {
+			fmt.Printf("⚠️  Failed to save pending changes: %v\n", err)
+		}
+		fmt.Printf("✅ Applied %s for %s\n", change.Kind, change.ExecutableName)
+		pq.publish(change, "applied")
+	}
+
+	pq.armLocked(retry)
+}
+
+// armLocked sets the timer for the next due change, or for a retry of
+// changes that are due but couldn't be applied
+// Caller must hold the lock
+func (pq *PendingQueue) armLocked(retry bool) {
+	if pq.timer != nil {
+		pq.timer.Stop()
+		pq.timer = nil
+	}
+	if pq.store == nil {
+		return
+	}
+
+	now := pq.clock.Now()
+	var next time.Time
+	for _, change := range pq.store.Get().Pending {
+		if !change.EffectiveAt.After(now) {
+			// Due changes that couldn't be applied wait for the retry timer
+			continue
+		}
+		if next.IsZero() || change.EffectiveAt.Before(next) {
+			next = change.EffectiveAt
+		}
+	}
+	if retry && (next.IsZero() || next.Sub(now) > pendingRetryInterval) {
+		next = now.Add(pendingRetryInterval)
+	}
+	if !next.IsZero() {
+		pq.timer = pq.clock.AfterFunc(next.Sub(now), pq.onTimer)
+	}
+}
+
+func (pq *PendingQueue) onTimer() {
+	pq.mu.Lock()
+	defer pq.mu.Unlock()
+	pq.applyDueLocked()
+}
+
+func (pq *PendingQueue) publish(change PendingChange, reason string) {
+	if pq.bus != nil {
+		pq.bus.Publish(EventPendingChanged, PendingChangedEvent{Change: change, Reason: reason})
+	}
+}
+
+// isPermanentPendingError reports whether a failed change can never succeed,
+// e.g. because the app was already removed by hand
+func isPermanentPendingError(target pendingTarget, change PendingChange) bool {
+	bm, ok := target.(*BlocklistManager)
+	return ok && bm.GetBlockedAppAnyProfile(change.ExecutableName) == nil
+}
+
+// newPendingID returns a short random identifier
+func newPendingID() string {
+	b := make([]byte, 4)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%08x", time.Now().UnixNano()&0xffffffff)
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeTarget records applied changes and can refuse them like the commitment lock
+type fakeTarget struct {
+	mu      sync.Mutex
+	removed []string
+	refuse  bool
+}
+
+func (f *fakeTarget) RemoveApp(executableName string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.refuse {
+		return errCommitmentLocked
+	}
+	f.removed = append(f.removed, executableName)
+	return nil
+}
+
+func (f *fakeTarget) SetAppProfiles(executableName string, profiles []string) error {
+	return nil
+}
+
+func (f *fakeTarget) SetAppScope(executableName, scope string) error {
+	return nil
+}
+
+func (f *fakeTarget) SetAppPlatforms(executableName string, platforms []string) error {
+	return nil
+}
+
+func (f *fakeTarget) checkGuard(action string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.refuse {
+		return errCommitmentLocked
+	}
+	return nil
+}
+
+func (f *fakeTarget) removedCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.removed)
+}
+
+func newTestPendingQueue(t *testing.T, clock *fakeClock, target *fakeTarget) (*PendingQueue, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "state.json")
+	store, err := newStateStore(path)
+	if err != nil {
+		t.Fatalf("newStateStore failed: %v", err)
+	}
+	return NewPendingQueue(clock, store, target, nil, 24*time.Hour), path
+}
+
+// TestPendingQueueDelay tests that a removal is only applied after the delay,
+// survives a restart and can't be queued twice
+func TestPendingQueueDelay(t *testing.T) {
+	clock := newFakeClock()
+	target := &fakeTarget{}
+	queue, path := newTestPendingQueue(t, clock, target)
+
+	change, err := queue.Request(PendingChange{Kind: PendingRemoveApp, ExecutableName: "discord.exe"})
+	if err != nil || change == nil {
+		t.Fatalf("Request = %v, %v; want a queued change", change, err)
+	}
+	if _, err := queue.Request(PendingChange{Kind: PendingRemoveApp, ExecutableName: "discord.exe"}); err == nil {
+		t.Error("queueing the same removal twice should fail")
+	}
+
+	clock.Advance(23 * time.Hour)
+	if target.removedCount() != 0 {
+		t.Fatal("removal applied before the delay")
+	}
+
+	// Restart: the new queue picks the change up from the state file
+	queue.Stop()
+	store, err := newStateStore(path)
+	if err != nil {
+		t.Fatalf("reopening failed: %v", err)
+	}
+	queue = NewPendingQueue(clock, store, target, nil, 24*time.Hour)
+	queue.Start()
+	if got := len(queue.List()); got != 1 {
+		t.Fatalf("pending after restart = %d, want 1", got)
+	}
+
+	clock.Advance(time.Hour)
+	if target.removedCount() != 1 {
+		t.Fatalf("removed %d apps after the delay, want 1", target.removedCount())
+	}
+	if got := len(queue.List()); got != 0 {
+		t.Errorf("pending after apply = %d, want 0", got)
+	}
+}
+
+// TestPendingQueueCancel tests that a cancelled change is never applied
+func TestPendingQueueCancel(t *testing.T) {
+	clock := newFakeClock()
+	target := &fakeTarget{}
+	queue, _ := newTestPendingQueue(t, clock, target)
+
+	change, err := queue.Request(PendingChange{Kind: PendingRemoveApp, ExecutableName: "steam.exe"})
+	if err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	if err := queue.Cancel(change.ID); err != nil {
+		t.Fatalf("Cancel failed: %v", err)
+	}
+	if err := queue.Cancel(change.ID); err == nil {
+		t.Error("cancelling twice should fail")
+	}
+	clock.Advance(48 * time.Hour)
+	if target.removedCount() != 0 {
+		t.Error("cancelled removal was applied")
+	}
+}
+
+// TestPendingQueueRetry tests that a due change refused by a lock that started
+// after it was queued is retried
+func TestPendingQueueRetry(t *testing.T) {
+	clock := newFakeClock()
+	target := &fakeTarget{}
+	queue, _ := newTestPendingQueue(t, clock, target)
+
+	if _, err := queue.Request(PendingChange{Kind: PendingRemoveApp, ExecutableName: "steam.exe"}); err != nil {
+		t.Fatalf("Request failed: %v", err)
+	}
+	target.mu.Lock()
+	target.refuse = true
+	target.mu.Unlock()
+	clock.Advance(24 * time.Hour)
+	if got := len(queue.List()); got != 1 {
+		t.Fatalf("refused change should stay pending, have %d", got)
+	}
+
+	target.mu.Lock()
+	target.refuse = false
+	target.mu.Unlock()
+	clock.Advance(pendingRetryInterval)
+	if target.removedCount() != 1 {
+		t.Error("change was not retried after the lock ended")
+	}
+}
+
+// TestPendingQueueRefusesWhileLocked tests that a removal requested during a
+// lock is refused rather than queued for when the lock ends
+func TestPendingQueueRefusesWhileLocked(t *testing.T) {
+	clock := newFakeClock()
+	target := &fakeTarget{refuse: true}
+	queue, _ := newTestPendingQueue(t, clock, target)
+
+	change, err := queue.Request(PendingChange{Kind: PendingRemoveApp, ExecutableName: "steam.exe"})
+	if !errors.Is(err, errCommitmentLocked) || change != nil {
+		t.Fatalf("Request under lock = %v, %v; want errCommitmentLocked", change, err)
+	}
+	if got := len(queue.List()); got != 0 {
+		t.Fatalf("refused removal was queued, have %d pending", got)
+	}
+
+	target.mu.Lock()
+	target.refuse = false
+	target.mu.Unlock()
+	clock.Advance(48 * time.Hour)
+	if target.removedCount() != 0 {
+		t.Error("removal requested during the lock was applied after it ended")
+	}
+}
+
+// TestPendingQueueNoDelay tests that without a delay changes apply at once
+func TestPendingQueueNoDelay(t *testing.T) {
+	target := &fakeTarget{}
+	queue := NewPendingQueue(newFakeClock(), nil, target, nil, 0)
+	change, err := queue.Request(PendingChange{Kind: PendingRemoveApp, ExecutableName: "steam.exe"})
+	if err != nil || change != nil {
+		t.Fatalf("Request = %v, %v; want applied", change, err)
+	}
+	if target.removedCount() != 1 {
+		t.Error("change was not applied")
+	}
+
+	target.refuse = true
+	if _, err := queue.Request(PendingChange{Kind: PendingRemoveApp, ExecutableName: "discord.exe"}); !errors.Is(err, errCommitmentLocked) {
+		t.Errorf("Request under lock = %v, want errCommitmentLocked", err)
+	}
+}
@@ -0,0 +1,373 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultProcessScanInterval is used when processScan.intervalSeconds is 0
+const defaultProcessScanInterval = 2 * time.Second
+
+// Process scanner actions
+const (
+	ScanActionWarn      = "warn"      // Warn once when a blocked app starts running
+	ScanActionTerminate = "terminate" // Terminate blocked processes as soon as they are seen
+)
+
+// ProcessScanConfig configures the background process scanner in settings.json
+type ProcessScanConfig struct {
+	Enabled         bool   `json:"enabled"`
+	IntervalSeconds int    `json:"intervalSeconds,omitempty"` // 0 = every 2 seconds
+	Action          string `json:"action,omitempty"`          // "warn" (default) or "terminate"
+}
+
+// interval returns the scan interval, falling back to the default
+func (c ProcessScanConfig) interval() time.Duration {
+	if c.IntervalSeconds <= 0 {
+		return defaultProcessScanInterval
+	}
+	return time.Duration(c.IntervalSeconds) * time.Second
+}
+
+// action returns the configured action, falling back to warn
+func (c ProcessScanConfig) action() string {
+	if c.Action == ScanActionTerminate {
+		return ScanActionTerminate
+	}
+	return ScanActionWarn
+}
+
+// ProcessInfo is a running process as seen by the scanner
+type ProcessInfo struct {
+	PID   int    `json:"pid"`
+	PPID  int    `json:"ppid"`
+	Name  string `json:"name"`            // Lowercase executable name, as in WindowInfo.Exe
+	Path  string `json:"path,omitempty"`  // Full executable path when it could be read
+	AppID string `json:"appId,omitempty"` // Flatpak app ID, Snap or AppImage name, or the app an interpreter runs
+}
+
+// ProcessScanner periodically lists running processes and applies the
+// configured action to blocked ones, whether they have a window in the
+// foreground or not. Warnings go through the Enforcer so a focused blocked
+// app isn't warned about twice
+type ProcessScanner struct {
+	bus       *EventBus
+	config    ProcessScanConfig
+	list      func() ([]ProcessInfo, error)
+	match     func(p ProcessInfo, ancestors []ProcessInfo) *BlockedApp
+	snoozed   func(exe string) bool
+	warn      func(ProcessInfo)
+	terminate func(pid int, force bool) error
+
+	mu       sync.Mutex
+	running  map[string]map[int]bool // Blocklist entry -> PIDs seen in the last scan
+	attempts map[int]int             // PID -> termination attempts so far
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// NewProcessScanner creates a scanner using the blocklist, the enforcer for
+// warnings and the state store for snoozes; enforcer and store may be nil
+func NewProcessScanner(bus *EventBus, config ProcessScanConfig, bm *BlocklistManager, enforcer *Enforcer, store *StateStore) *ProcessScanner {
+	ps := &ProcessScanner{
+		bus:       bus,
+		config:    config,
+		list:      listProcesses,
+		match:     bm.MatchProcess,
+		snoozed:   func(string) bool { return false },
+		warn:      func(ProcessInfo) {},
+		terminate: terminateProcess,
+		running:   map[string]map[int]bool{},
+		attempts:  map[int]int{},
+	}
+	if store != nil {
+		ps.snoozed = func(exe string) bool { return !store.SnoozedUntil(exe, time.Now()).IsZero() }
+	}
+	if enforcer != nil {
+		// The warning blocks until dismissed, so the enforcer's goroutine shows it
+		ps.warn = enforcer.QueueProcess
+	}
+	return ps
+}
+
+// Start scans right away and then every interval
+func (ps *ProcessScanner) Start() {
+	ps.mu.Lock()
+	defer ps.mu.Unlock()
+	if ps.stop != nil {
+		return
+	}
+	ps.stop = make(chan struct{})
+	ps.done = make(chan struct{})
+	fmt.Printf("🔎 Process scanner started (every %s, action: %s)\n", ps.config.interval(), ps.config.action())
+	go ps.run(ps.stop, ps.done)
+}
+
+// Stop stops scanning and waits for the current scan to finish
+func (ps *ProcessScanner) Stop() {
+	ps.mu.Lock()
+	stop, done := ps.stop, ps.done
+	ps.stop = nil
+	ps.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (ps *ProcessScanner) run(stop, done chan struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(ps.config.interval())
+	defer ticker.Stop()
+	for {
+		ps.scan()
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// scan lists the processes once and applies the action to blocked ones.
+// An app is warned about once when it starts running, no matter how many
+// processes it (or, for a launcher, what it started) has; terminating goes
+// after every process each scan
+func (ps *ProcessScanner) scan() {
+	processes, err := ps.list()
+	if err != nil {
+		fmt.Printf("⚠️  Failed to list processes: %v\n", err)
+		return
+	}
+	processIdentities.retain(processes)
+
+	self := os.Getpid()
+	lookup := processLookup(processes)
+	current := map[string][]ProcessInfo{}
+	apps := map[string]*BlockedApp{}
+	for _, p := range processes {
+		if p.PID == self || p.Name == "" {
+			continue
+		}
+		app := ps.match(p, ancestorChain(p.PID, lookup))
+		if app == nil {
+			continue
+		}
+		current[app.ExecutableName] = append(current[app.ExecutableName], p)
+		apps[app.ExecutableName] = app
+	}
+
+	ps.mu.Lock()
+	previous := ps.running
+	ps.running = make(map[string]map[int]bool, len(current))
+	for exe, procs := range current {
+		pids := make(map[int]bool, len(procs))
+		for _, p := range procs {
+			pids[p.PID] = true
+		}
+		ps.running[exe] = pids
+	}
+	ps.mu.Unlock()
+
+	exes := make([]string, 0, len(current))
+	for exe := range current {
+		exes = append(exes, exe)
+	}
+	sort.Strings(exes)
+
+	for _, exe := range exes {
+		procs := current[exe]
+		if ps.snoozed(exe) {
+			continue
+		}
+		newApp := len(previous[exe]) == 0
+		if newApp {
+			ps.publishDetected(procs[0], apps[exe])
+		}
+
+		switch ps.config.action() {
+		case ScanActionTerminate:
+			for _, p := range procs {
+				ps.terminateProcess(p)
+			}
+		default:
+			if newApp {
+				ps.warn(procs[0])
+			}
+		}
+	}
+
+	// Forget PIDs that are gone so a reused PID is handled again
+	ps.mu.Lock()
+	for pid := range ps.attempts {
+		if !ps.isRunning(pid) {
+			delete(ps.attempts, pid)
+		}
+	}
+	ps.mu.Unlock()
+}
+
+// isRunning reports whether pid was seen in the last scan; callers hold ps.mu
+func (ps *ProcessScanner) isRunning(pid int) bool {
+	for _, pids := range ps.running {
+		if pids[pid] {
+			return true
+		}
+	}
+	return false
+}
+
+func (ps *ProcessScanner) publishDetected(p ProcessInfo, app *BlockedApp) {
+	displayName := p.Name
+	if app.DisplayName != "" {
+		displayName = app.DisplayName
+	}
+	fmt.Printf("🔎 Blocked process running: [%s] pid %d\n", p.Name, p.PID)
+	if ps.bus != nil {
+		ps.bus.Publish(EventProcessDetected, ProcessDetectedEvent{
+			PID:            p.PID,
+			ExecutableName: p.Name,
+			DisplayName:    displayName,
+			Action:         ps.config.action(),
+		})
+	}
+}
+
+// terminateProcess asks a blocked process to exit, forces it if it is still
+// there on the next scan and then gives up on it
+func (ps *ProcessScanner) terminateProcess(p ProcessInfo) {
+	ps.mu.Lock()
+	attempt := ps.attempts[p.PID]
+	ps.attempts[p.PID] = attempt + 1
+	ps.mu.Unlock()
+	if attempt >= 2 {
+		return
+	}
+
+	force := attempt == 1
+	if err := ps.terminate(p.PID, force); err != nil {
+		fmt.Printf("❌ Failed to terminate %s (pid %d): %v\n", p.Name, p.PID, err)
+		ps.mu.Lock()
+		ps.attempts[p.PID] = 2
+		ps.mu.Unlock()
+		return
+	}
+	if force {
+		fmt.Printf("🛑 Killed blocked process %s (pid %d)\n", p.Name, p.PID)
+		return
+	}
+	fmt.Printf("🛑 Terminated blocked process %s (pid %d)\n", p.Name, p.PID)
+	if ps.bus != nil {
+		ps.bus.Publish(EventProcessTerminated, ProcessTerminatedEvent{PID: p.PID, ExecutableName: p.Name})
+	}
+}
+
+// trimProcessName normalizes a name read from the OS the way the watcher does
+func trimProcessName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// processIdentity is what is cached about a running process, valid while the
+// PID runs the same, unmodified executable
+type processIdentity struct {
+	path     string
+	modTime  time.Time
+	appID    string
+	resolved bool   // appID was looked up
+	sum      string // SHA-256 of the binary, "" until it was needed
+}
+
+// identityCache remembers the app ID and hash per (pid, executable path,
+// mtime), so scanning every couple of seconds doesn't read /proc or hash the
+// binaries of processes it has seen before
+type identityCache struct {
+	mu       sync.Mutex
+	entries  map[int]processIdentity
+	resolve  func(pid int, path string) string
+	hashFile func(path string) (string, error)
+}
+
+var processIdentities = newIdentityCache()
+
+func newIdentityCache() *identityCache {
+	return &identityCache{
+		entries:  map[int]processIdentity{},
+		resolve:  resolveAppID,
+		hashFile: hashExecutable,
+	}
+}
+
+// lookup returns the cached identity of pid, a fresh one if pid no longer runs
+// path as it was when cached
+func (ic *identityCache) lookup(pid int, path string) processIdentity {
+	var modTime time.Time
+	if info, err := os.Stat(path); err == nil {
+		modTime = info.ModTime()
+	}
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	if cached, ok := ic.entries[pid]; ok && cached.path == path && cached.modTime.Equal(modTime) {
+		return cached
+	}
+	return processIdentity{path: path, modTime: modTime}
+}
+
+func (ic *identityCache) store(pid int, identity processIdentity) {
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	if len(ic.entries) >= maxHashCacheEntries {
+		ic.entries = map[int]processIdentity{}
+	}
+	ic.entries[pid] = identity
+}
+
+// appID returns resolveAppID(pid, path), cached
+func (ic *identityCache) appID(pid int, path string) string {
+	if pid <= 0 {
+		return ic.resolve(pid, path)
+	}
+	identity := ic.lookup(pid, path)
+	if !identity.resolved {
+		identity.appID = ic.resolve(pid, path)
+		identity.resolved = true
+		ic.store(pid, identity)
+	}
+	return identity.appID
+}
+
+// hash returns the SHA-256 of the binary pid runs from path, cached
+func (ic *identityCache) hash(pid int, path string) (string, error) {
+	if pid <= 0 {
+		return ic.hashFile(path)
+	}
+	identity := ic.lookup(pid, path)
+	if identity.sum == "" {
+		sum, err := ic.hashFile(path)
+		if err != nil {
+			return "", err
+		}
+		identity.sum = sum
+		ic.store(pid, identity)
+	}
+	return identity.sum, nil
+}
+
+// retain forgets the processes that are no longer running
+func (ic *identityCache) retain(processes []ProcessInfo) {
+	running := make(map[int]bool, len(processes))
+	for _, p := range processes {
+		running[p.PID] = true
+	}
+	ic.mu.Lock()
+	defer ic.mu.Unlock()
+	for pid := range ic.entries {
+		if !running[pid] {
+			delete(ic.entries, pid)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+// listProcesses enumerates running processes from /proc
+func listProcesses() ([]ProcessInfo, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc: %w", err)
+	}
+
+	processes := make([]ProcessInfo, 0, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		p, err := readProcess(pid)
+		if err != nil {
+			// The process exited while we were looking
+			continue
+		}
+		processes = append(processes, p)
+	}
+	return processes, nil
+}
+
+// readProcess reads one process's name and parent from /proc/<pid>
+func readProcess(pid int) (ProcessInfo, error) {
+	stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return ProcessInfo{}, err
+	}
+	comm, ppid, err := parseProcStat(string(stat))
+	if err != nil {
+		return ProcessInfo{}, err
+	}
+
+	p := ProcessInfo{PID: pid, PPID: ppid, Name: trimProcessName(comm)}
+	// The exe link has the full name (comm is cut at 15 characters) but is
+	// unreadable for other users' processes
+	if target := processPath(pid); target != "" {
+		p.Path = target
+		p.Name = trimProcessName(filepath.Base(target))
+	}
+	p.AppID = processIdentities.appID(pid, p.Path)
+	return p, nil
+}
+
+// parseProcStat extracts comm and the parent PID from /proc/<pid>/stat
+func parseProcStat(stat string) (string, int, error) {
+	comm, fields, err := procStatFields(stat)
+	if err != nil {
+		return "", 0, err
+	}
+	// fields[0] is the state, fields[1] the parent PID
+	ppid, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return "", 0, fmt.Errorf("malformed stat: %w", err)
+	}
+	return comm, ppid, nil
+}
+
+// procStatFields splits /proc/<pid>/stat into comm and the fields after it.
+// comm is in parentheses and may itself contain spaces and parentheses
+func procStatFields(stat string) (string, []string, error) {
+	open := strings.IndexByte(stat, '(')
+	close := strings.LastIndexByte(stat, ')')
+	if open < 0 || close < open {
+		return "", nil, fmt.Errorf("malformed stat")
+	}
+	fields := strings.Fields(stat[close+1:])
+	if len(fields) < 2 {
+		return "", nil, fmt.Errorf("malformed stat")
+	}
+	return stat[open+1 : close], fields, nil
+}
+
+// processPath returns the executable path of pid, "" if it can't be read
+func processPath(pid int) string {
+	if pid <= 0 {
+		return ""
+	}
+	target, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(target, " (deleted)")
+}
+
+// terminateProcess sends SIGTERM, or SIGKILL with force
+func terminateProcess(pid int, force bool) error {
+	signal := unix.SIGTERM
+	if force {
+		signal = unix.SIGKILL
+	}
+	return unix.Kill(pid, signal)
+}
+
+// processAncestors returns the parent chain of pid, parent first
+func processAncestors(pid int) []ProcessInfo {
+	if pid <= 0 {
+		return nil
+	}
+	return ancestorChain(pid, func(pid int) (ProcessInfo, bool) {
+		p, err := readProcess(pid)
+		return p, err == nil
+	})
+}
+
+// processCommandLine returns the arguments pid was started with, nil if they
+// can't be read
+func processCommandLine(pid int) []string {
+	if pid <= 0 {
+		return nil
+	}
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", pid))
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00")
+}
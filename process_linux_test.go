@@ -0,0 +1,67 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+// TestParseProcStat tests reading comm and the parent PID, including a comm
+// with spaces and parentheses
+func TestParseProcStat(t *testing.T) {
+	comm, ppid, err := parseProcStat("4242 (my (odd) app) S 17 4242 4242 0 -1")
+	if err != nil {
+		t.Fatalf("parseProcStat failed: %v", err)
+	}
+	if comm != "my (odd) app" || ppid != 17 {
+		t.Errorf("got comm %q ppid %d, want \"my (odd) app\" 17", comm, ppid)
+	}
+	if _, _, err := parseProcStat("garbage"); err == nil {
+		t.Error("parseProcStat accepted a malformed line")
+	}
+}
+
+// TestListProcessesFindsSelf tests that the test process is listed with its
+// parent
+func TestListProcessesFindsSelf(t *testing.T) {
+	processes, err := listProcesses()
+	if err != nil {
+		t.Fatalf("listProcesses failed: %v", err)
+	}
+	for _, p := range processes {
+		if p.PID == os.Getpid() {
+			if p.PPID != os.Getppid() {
+				t.Errorf("ppid = %d, want %d", p.PPID, os.Getppid())
+			}
+			if p.Name == "" || p.Path == "" {
+				t.Errorf("self = %+v, want a name and path", p)
+			}
+			return
+		}
+	}
+	t.Fatalf("pid %d not listed", os.Getpid())
+}
+
+// TestProcessAncestors tests that the parent chain starts with the test
+// process's parent
+func TestProcessAncestors(t *testing.T) {
+	chain := processAncestors(os.Getpid())
+	if len(chain) == 0 || chain[0].PID != os.Getppid() {
+		t.Fatalf("ancestors = %+v, want the parent pid %d first", chain, os.Getppid())
+	}
+	if processAncestors(0) != nil {
+		t.Error("ancestors of pid 0 should be nil")
+	}
+}
+
+// TestProcessCommandLine tests reading the test process's own arguments
+func TestProcessCommandLine(t *testing.T) {
+	args := processCommandLine(os.Getpid())
+	if len(args) != len(os.Args) {
+		t.Fatalf("command line = %q, want %q", args, os.Args)
+	}
+	for i := range args {
+		if args[i] != os.Args[i] {
+			t.Errorf("argument %d = %q, want %q", i, args[i], os.Args[i])
+		}
+	}
+}
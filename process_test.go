@@ -0,0 +1,227 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// fakeProcessScanner returns a scanner over a fixed process list that records
+// warnings and termination attempts
+type fakeProcessScanner struct {
+	*ProcessScanner
+	apps       []BlockedApp
+	processes  []ProcessInfo
+	snoozedExe string
+	warned     []ProcessInfo
+	terminated []int
+	forced     []int
+	failPID    int
+}
+
+func newFakeProcessScanner(action string, blocked ...string) *fakeProcessScanner {
+	f := &fakeProcessScanner{}
+	for _, exe := range blocked {
+		f.apps = append(f.apps, BlockedApp{ExecutableName: normalizeExecutableName(exe)})
+	}
+	f.ProcessScanner = &ProcessScanner{
+		config: ProcessScanConfig{Enabled: true, Action: action},
+		list:   func() ([]ProcessInfo, error) { return f.processes, nil },
+		match: func(p ProcessInfo, ancestors []ProcessInfo) *BlockedApp {
+			for _, app := range f.apps {
+				if app.matches(p, ancestors) {
+					return &app
+				}
+			}
+			return nil
+		},
+		snoozed:  func(exe string) bool { return exe == normalizeExecutableName(f.snoozedExe) },
+		warn:     func(p ProcessInfo) { f.warned = append(f.warned, p) },
+		running:  map[string]map[int]bool{},
+		attempts: map[int]int{},
+	}
+	f.terminate = func(pid int, force bool) error {
+		if pid == f.failPID {
+			return errors.New("access denied")
+		}
+		if force {
+			f.forced = append(f.forced, pid)
+		} else {
+			f.terminated = append(f.terminated, pid)
+		}
+		return nil
+	}
+	return f
+}
+
+// TestProcessScannerWarnsOncePerApp tests that an app is warned about once
+// while it runs, however many processes it has, and again after a restart
+func TestProcessScannerWarnsOncePerApp(t *testing.T) {
+	f := newFakeProcessScanner(ScanActionWarn, "game.exe")
+	f.processes = []ProcessInfo{
+		{PID: 10, Name: "game.exe"},
+		{PID: 11, Name: "game.exe"},
+		{PID: 12, Name: "editor.exe"},
+	}
+
+	f.scan()
+	f.scan()
+	if len(f.warned) != 1 || f.warned[0].Name != "game.exe" {
+		t.Fatalf("warned = %+v, want one warning for game.exe", f.warned)
+	}
+
+	f.processes = nil
+	f.scan()
+	f.processes = []ProcessInfo{{PID: 20, Name: "game.exe"}}
+	f.scan()
+	if len(f.warned) != 2 || f.warned[1].PID != 20 {
+		t.Fatalf("warned = %+v, want a second warning after the restart", f.warned)
+	}
+	if len(f.terminated) != 0 {
+		t.Errorf("terminated = %v, want nothing terminated when warning", f.terminated)
+	}
+}
+
+// TestProcessScannerSkipsSnoozedAndSelf tests that snoozed apps and sybr's own
+// process are left alone
+func TestProcessScannerSkipsSnoozedAndSelf(t *testing.T) {
+	f := newFakeProcessScanner(ScanActionTerminate, "game.exe", "sybr.exe")
+	f.snoozedExe = "game.exe"
+	f.processes = []ProcessInfo{
+		{PID: 10, Name: "game.exe"},
+		{PID: os.Getpid(), Name: "sybr.exe"},
+	}
+
+	f.scan()
+	if len(f.terminated) != 0 || len(f.warned) != 0 {
+		t.Fatalf("terminated = %v, warned = %+v, want nothing", f.terminated, f.warned)
+	}
+}
+
+// TestProcessScannerTerminates tests that every blocked process is asked to
+// exit, forced on the next scan and then given up on
+func TestProcessScannerTerminates(t *testing.T) {
+	f := newFakeProcessScanner(ScanActionTerminate, "game.exe")
+	f.processes = []ProcessInfo{
+		{PID: 10, Name: "game.exe"},
+		{PID: 11, Name: "game.exe"},
+	}
+
+	f.scan()
+	if len(f.terminated) != 2 || len(f.forced) != 0 {
+		t.Fatalf("terminated = %v, forced = %v, want both asked to exit", f.terminated, f.forced)
+	}
+
+	// PID 11 ignored the request
+	f.processes = []ProcessInfo{{PID: 11, Name: "game.exe"}}
+	f.scan()
+	f.scan()
+	if len(f.forced) != 1 || f.forced[0] != 11 {
+		t.Fatalf("forced = %v, want pid 11 killed once", f.forced)
+	}
+	if len(f.terminated) != 2 {
+		t.Errorf("terminated = %v, want no new requests", f.terminated)
+	}
+	if len(f.warned) != 0 {
+		t.Errorf("warned = %+v, want no warnings when terminating", f.warned)
+	}
+}
+
+// TestProcessScannerTerminateFailure tests that a process that can't be
+// terminated isn't retried every scan
+func TestProcessScannerTerminateFailure(t *testing.T) {
+	f := newFakeProcessScanner(ScanActionTerminate, "game.exe")
+	f.failPID = 10
+	f.processes = []ProcessInfo{{PID: 10, Name: "game.exe"}}
+
+	f.scan()
+	if got := f.attempts[10]; got != 2 {
+		t.Fatalf("attempts = %d after a failure, want it given up", got)
+	}
+	f.scan()
+	if got := f.attempts[10]; got != 3 {
+		t.Fatalf("attempts = %d, want the failed pid skipped", got)
+	}
+	if len(f.forced) != 0 {
+		t.Errorf("forced = %v, want no kill after the failure", f.forced)
+	}
+}
+
+// TestProcessScannerLauncherScope tests that a launcher entry catches the
+// processes the launcher started and warns once for all of them
+func TestProcessScannerLauncherScope(t *testing.T) {
+	f := newFakeProcessScanner(ScanActionWarn)
+	f.apps = []BlockedApp{{ExecutableName: "steam", Scope: ScopeLaunched}}
+	f.processes = []ProcessInfo{
+		{PID: 1, Name: "systemd"},
+		{PID: 10, PPID: 1, Name: "steam"},
+		{PID: 11, PPID: 10, Name: "reaper"},
+		{PID: 12, PPID: 11, Name: "game"},
+		{PID: 20, PPID: 1, Name: "editor"},
+	}
+
+	f.scan()
+	if len(f.warned) != 1 || f.warned[0].PID != 11 {
+		t.Fatalf("warned = %+v, want one warning for steam's children", f.warned)
+	}
+	if pids := f.running["steam"]; len(pids) != 2 || !pids[11] || !pids[12] || pids[10] {
+		t.Errorf("running = %v, want steam's children but not steam itself", f.running)
+	}
+}
+
+// TestIdentityCache tests that app IDs and hashes are looked up once per
+// process and again when its executable changes or it exits
+func TestIdentityCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "discord")
+	if err := os.WriteFile(path, []byte("binary"), 0755); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	resolves, hashes := 0, 0
+	ic := newIdentityCache()
+	ic.resolve = func(pid int, path string) string { resolves++; return "com.discordapp.Discord" }
+	ic.hashFile = func(path string) (string, error) { hashes++; return "sum", nil }
+
+	for i := 0; i < 3; i++ {
+		ic.appID(42, path)
+		if sum, err := ic.hash(42, path); err != nil || sum != "sum" {
+			t.Fatalf("hash = %q, %v", sum, err)
+		}
+	}
+	if resolves != 1 || hashes != 1 {
+		t.Fatalf("resolved %d and hashed %d times, want once each", resolves, hashes)
+	}
+
+	changed := time.Now().Add(time.Hour)
+	if err := os.Chtimes(path, changed, changed); err != nil {
+		t.Fatalf("Chtimes failed: %v", err)
+	}
+	ic.appID(42, path)
+	ic.hash(42, path)
+	if resolves != 2 || hashes != 2 {
+		t.Errorf("after the executable changed resolved %d and hashed %d times, want twice each", resolves, hashes)
+	}
+
+	ic.retain([]ProcessInfo{{PID: 7}})
+	ic.appID(42, path)
+	if resolves != 3 {
+		t.Errorf("resolved %d times after the process exited, want 3", resolves)
+	}
+}
+
+// TestEnforcerQueuesScannerWarnings tests that scanner warnings wait for the
+// enforcer's goroutine instead of opening at once, and never block the scan
+func TestEnforcerQueuesScannerWarnings(t *testing.T) {
+	enforcer := NewEnforcer(nil)
+	scanner := NewProcessScanner(nil, ProcessScanConfig{}, &BlocklistManager{}, enforcer, nil)
+	for pid := 1; pid <= enforcerProcessQueue+5; pid++ {
+		scanner.warn(ProcessInfo{PID: pid, Name: "discord"})
+	}
+	if queued := len(enforcer.processes); queued != enforcerProcessQueue {
+		t.Errorf("%d warnings queued, want %d", queued, enforcerProcessQueue)
+	}
+	if p := <-enforcer.processes; p.PID != 1 {
+		t.Errorf("first queued warning is for pid %d, want 1", p.PID)
+	}
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// listProcesses enumerates running processes with a Toolhelp32 snapshot
+func listProcesses() ([]ProcessInfo, error) {
+	snapshot, err := windows.CreateToolhelp32Snapshot(windows.TH32CS_SNAPPROCESS, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create process snapshot: %w", err)
+	}
+	defer windows.CloseHandle(snapshot)
+
+	var entry windows.ProcessEntry32
+	entry.Size = uint32(unsafe.Sizeof(entry))
+	if err := windows.Process32First(snapshot, &entry); err != nil {
+		return nil, fmt.Errorf("failed to read process snapshot: %w", err)
+	}
+
+	var processes []ProcessInfo
+	for {
+		name := trimProcessName(windows.UTF16ToString(entry.ExeFile[:]))
+		processes = append(processes, ProcessInfo{
+			PID:   int(entry.ProcessID),
+			PPID:  int(entry.ParentProcessID),
+			Name:  name,
+			AppID: processIdentities.appID(int(entry.ProcessID), name),
+		})
+		if err := windows.Process32Next(snapshot, &entry); err != nil {
+			if err == windows.ERROR_NO_MORE_FILES {
+				break
+			}
+			return nil, fmt.Errorf("failed to read process snapshot: %w", err)
+		}
+	}
+	return processes, nil
+}
+
+// processPath returns the executable path of pid, "" if it can't be read
+func processPath(pid int) string {
+	if pid <= 0 {
+		return ""
+	}
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return ""
+	}
+	defer windows.CloseHandle(handle)
+
+	buf := make([]uint16, windows.MAX_LONG_PATH)
+	size := uint32(len(buf))
+	if err := windows.QueryFullProcessImageName(handle, 0, &buf[0], &size); err != nil {
+		return ""
+	}
+	return windows.UTF16ToString(buf[:size])
+}
+
+// terminateProcess ends the process; TerminateProcess is always forceful
+func terminateProcess(pid int, force bool) error {
+	handle, err := windows.OpenProcess(windows.PROCESS_TERMINATE, false, uint32(pid))
+	if err != nil {
+		return fmt.Errorf("failed to open process: %w", err)
+	}
+	defer windows.CloseHandle(handle)
+	return windows.TerminateProcess(handle, 1)
+}
+
+// processAncestors returns the parent chain of pid, parent first
+func processAncestors(pid int) []ProcessInfo {
+	if pid <= 0 {
+		return nil
+	}
+	processes, err := listProcesses()
+	if err != nil {
+		return nil
+	}
+	return ancestorChain(pid, processLookup(processes))
+}
+
+// processCommandLine returns the arguments pid was started with, nil if they
+// can't be read
+func processCommandLine(pid int) []string {
+	if pid <= 0 {
+		return nil
+	}
+	handle, err := windows.OpenProcess(windows.PROCESS_QUERY_LIMITED_INFORMATION, false, uint32(pid))
+	if err != nil {
+		return nil
+	}
+	defer windows.CloseHandle(handle)
+
+	// The result is a UNICODE_STRING pointing into the buffer right after it
+	buf := make([]byte, 4096)
+	for {
+		var size uint32
+		err := windows.NtQueryInformationProcess(handle, windows.ProcessCommandLineInformation, unsafe.Pointer(&buf[0]), uint32(len(buf)), &size)
+		if err == nil {
+			break
+		}
+		if err != windows.STATUS_INFO_LENGTH_MISMATCH || size <= uint32(len(buf)) {
+			return nil
+		}
+		buf = make([]byte, size)
+	}
+	commandLine := (*windows.NTUnicodeString)(unsafe.Pointer(&buf[0]))
+	args, err := windows.DecomposeCommandLine(commandLine.String())
+	if err != nil {
+		return nil
+	}
+	return args
+}
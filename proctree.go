@@ -0,0 +1,104 @@
+package main
+
+// maxProcessDepth bounds walks up the process tree in case parent PIDs form a
+// loop, which can happen on Windows when a parent's PID is reused
+const maxProcessDepth = 64
+
+// sessionProcesses end the walk up to a window's launcher: whatever they start
+// was started by the user from the desktop, not by a launcher
+var sessionProcesses = map[string]bool{
+	"init":                 true,
+	"systemd":              true,
+	"launchd":              true,
+	"login":                true,
+	"sshd":                 true,
+	"xinit":                true,
+	"gnome-shell":          true,
+	"gnome-session-binary": true,
+	"plasmashell":          true,
+	"kwin_x11":             true,
+	"kwin_wayland":         true,
+	"ksmserver":            true,
+	"xfce4-session":        true,
+	"xfce4-panel":          true,
+	"cinnamon":             true,
+	"mate-session":         true,
+	"lxsession":            true,
+	"sway":                 true,
+	"i3":                   true,
+	"explorer.exe":         true,
+	"services.exe":         true,
+	"svchost.exe":          true,
+	"wininit.exe":          true,
+	"winlogon.exe":         true,
+	"userinit.exe":         true,
+}
+
+// wrapperProcesses are skipped on the way to a launcher: launchers commonly
+// start games through a shell, and sandboxed apps run inside bwrap
+var wrapperProcesses = map[string]bool{
+	"bwrap":          true,
+	"snap-confine":   true,
+	"apprun":         true,
+	"sh":             true,
+	"bash":           true,
+	"dash":           true,
+	"zsh":            true,
+	"fish":           true,
+	"cmd.exe":        true,
+	"powershell.exe": true,
+	"pwsh.exe":       true,
+	"conhost.exe":    true,
+}
+
+// ancestorChain walks from pid's parent up to the root using lookup, parent first
+func ancestorChain(pid int, lookup func(pid int) (ProcessInfo, bool)) []ProcessInfo {
+	process, ok := lookup(pid)
+	if !ok {
+		return nil
+	}
+	chain := []ProcessInfo{}
+	seen := map[int]bool{pid: true}
+	for len(chain) < maxProcessDepth && process.PPID > 0 && !seen[process.PPID] {
+		seen[process.PPID] = true
+		parent, ok := lookup(process.PPID)
+		if !ok {
+			break
+		}
+		chain = append(chain, parent)
+		process = parent
+	}
+	return chain
+}
+
+// launcherOf returns the process that launched exe: the outermost ancestor
+// below the desktop session, skipping shells and exe's own helper processes.
+// "" means it was started from the desktop directly
+func launcherOf(exe string, ancestors []ProcessInfo) string {
+	launcher := ""
+	for _, p := range ancestors {
+		if sessionProcesses[p.Name] {
+			break
+		}
+		if p.Name == exe || wrapperProcesses[p.Name] {
+			continue
+		}
+		launcher = p.Name
+		if p.AppID != "" {
+			launcher = p.AppID
+		}
+	}
+	return launcher
+}
+
+// processLookup indexes a process list by PID for ancestorChain
+func processLookup(processes []ProcessInfo) func(pid int) (ProcessInfo, bool) {
+	byPID := make(map[int]ProcessInfo, len(processes))
+	for _, p := range processes {
+		byPID[p.PID] = p
+	}
+	return func(pid int) (ProcessInfo, bool) {
+		p, ok := byPID[pid]
+		return p, ok
+	}
+}
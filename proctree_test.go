@@ -0,0 +1,123 @@
+package main
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+// TestAncestorChain tests walking up the parents, stopping at the root and at
+// loops of reused PIDs
+func TestAncestorChain(t *testing.T) {
+	lookup := processLookup([]ProcessInfo{
+		{PID: 1, Name: "systemd"},
+		{PID: 10, PPID: 1, Name: "steam"},
+		{PID: 11, PPID: 10, Name: "game"},
+		{PID: 20, PPID: 21, Name: "a"},
+		{PID: 21, PPID: 20, Name: "b"},
+	})
+
+	chain := ancestorChain(11, lookup)
+	if len(chain) != 2 || chain[0].Name != "steam" || chain[1].Name != "systemd" {
+		t.Errorf("chain = %+v, want steam then systemd", chain)
+	}
+	if chain := ancestorChain(20, lookup); len(chain) != 1 || chain[0].PID != 21 {
+		t.Errorf("looping chain = %+v, want just pid 21", chain)
+	}
+	if chain := ancestorChain(99, lookup); chain != nil {
+		t.Errorf("chain of unknown pid = %+v, want nil", chain)
+	}
+}
+
+// TestLauncherOf tests picking the launcher out of the ancestor chain
+func TestLauncherOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		exe       string
+		ancestors []string
+		want      string
+	}{
+		{"steam game", "game", []string{"sh", "reaper", "steam", "systemd"}, "steam"},
+		{"windows launcher", "game.exe", []string{"epicgameslauncher.exe", "explorer.exe"}, "epicgameslauncher.exe"},
+		{"from the desktop", "firefox", []string{"systemd"}, ""},
+		{"own helper process", "chrome.exe", []string{"chrome.exe", "explorer.exe"}, ""},
+		{"from a terminal", "vim", []string{"bash", "gnome-terminal-server", "systemd"}, "gnome-terminal-server"},
+		{"no parents", "game", nil, ""},
+	}
+	for _, tt := range tests {
+		ancestors := make([]ProcessInfo, len(tt.ancestors))
+		for i, name := range tt.ancestors {
+			ancestors[i] = ProcessInfo{Name: name}
+		}
+		if got := launcherOf(tt.exe, ancestors); got != tt.want {
+			t.Errorf("%s: launcherOf = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestMatchProcessScopes tests which processes process, tree and launched
+// entries apply to
+func TestMatchProcessScopes(t *testing.T) {
+	bm := &BlocklistManager{filePath: filepath.Join(t.TempDir(), "blocking_list.json")}
+	for _, exe := range []string{"steam.exe", "launcher.exe", "game.exe"} {
+		if err := bm.AddApp(exe, ""); err != nil {
+			t.Fatalf("AddApp failed: %v", err)
+		}
+	}
+	if err := bm.SetAppScope("steam.exe", "tree"); err != nil {
+		t.Fatalf("SetAppScope failed: %v", err)
+	}
+	if err := bm.SetAppScope("launcher.exe", "launched"); err != nil {
+		t.Fatalf("SetAppScope failed: %v", err)
+	}
+	if err := bm.SetAppScope("game.exe", "sideways"); err == nil {
+		t.Error("SetAppScope accepted an unknown scope")
+	}
+
+	tests := []struct {
+		exe       string
+		ancestors []string
+		want      string
+	}{
+		{"steam.exe", nil, "steam"},
+		{"hl2.exe", []string{"steam.exe", "explorer.exe"}, "steam"},
+		{"launcher.exe", nil, ""},
+		{"other.exe", []string{"launcher.exe"}, "launcher"},
+		{"game.exe", []string{"steam.exe"}, "game"},
+		{"helper.exe", []string{"game.exe"}, ""},
+		{"notepad.exe", []string{"explorer.exe"}, ""},
+	}
+	for _, tt := range tests {
+		ancestors := make([]ProcessInfo, len(tt.ancestors))
+		for i, name := range tt.ancestors {
+			ancestors[i] = ProcessInfo{Name: name}
+		}
+		got := ""
+		if app := bm.MatchProcess(ProcessInfo{Name: tt.exe}, ancestors); app != nil {
+			got = app.ExecutableName
+		}
+		if got != tt.want {
+			t.Errorf("MatchProcess(%s, %v) = %q, want %q", tt.exe, tt.ancestors, got, tt.want)
+		}
+	}
+}
+
+// TestScopeGuard tests that narrowing an entry's scope goes through the guard
+// while widening it to the whole tree doesn't
+func TestScopeGuard(t *testing.T) {
+	bm := &BlocklistManager{filePath: filepath.Join(t.TempDir(), "blocking_list.json")}
+	if err := bm.AddApp("steam.exe", "Steam"); err != nil {
+		t.Fatalf("AddApp failed: %v", err)
+	}
+	bm.SetGuard(func(action string) error { return errCommitmentLocked })
+
+	if err := bm.SetAppScope("steam.exe", "tree"); err != nil {
+		t.Errorf("widening the scope under lock failed: %v", err)
+	}
+	if err := bm.SetAppScope("steam.exe", "launched"); !errors.Is(err, errCommitmentLocked) {
+		t.Errorf("narrowing tree to launched = %v, want errCommitmentLocked", err)
+	}
+	if err := bm.SetAppScope("steam.exe", "process"); !errors.Is(err, errCommitmentLocked) {
+		t.Errorf("narrowing tree to process = %v, want errCommitmentLocked", err)
+	}
+}
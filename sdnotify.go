@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+// sdNotify sends a state update to systemd (see sd_notify(3)).
+// It returns false without error when not running under a notify-aware service
+func sdNotify(state string) (bool, error) {
+	socketPath := os.Getenv("NOTIFY_SOCKET")
+	if socketPath == "" {
+		return false, nil
+	}
+	// Abstract namespace sockets are announced with a leading '@'
+	if socketPath[0] == '@' {
+		socketPath = "\x00" + socketPath[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socketPath, Net: "unixgram"})
+	if err != nil {
+		return false, fmt.Errorf("failed to connect to notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.Write([]byte(state)); err != nil {
+		return false, fmt.Errorf("failed to send notification: %w", err)
+	}
+	return true, nil
+}
+
+// sdWatchdogInterval returns how often the watchdog must be pinged, or 0 if the
+// service has no WatchdogSec= configured (or it is meant for another process)
+func sdWatchdogInterval() time.Duration {
+	usec, err := strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	if err != nil || usec <= 0 {
+		return 0
+	}
+	if pidStr := os.Getenv("WATCHDOG_PID"); pidStr != "" {
+		if pid, err := strconv.Atoi(pidStr); err != nil || pid != os.Getpid() {
+			return 0
+		}
+	}
+	// Ping at half the timeout, as recommended by systemd
+	return time.Duration(usec) * time.Microsecond / 2
+}
+
+// runSDWatchdog pings the systemd watchdog while healthy() reports true,
+// so a hung monitoring loop gets the service restarted
+func runSDWatchdog(stop <-chan struct{}, healthy func() bool) {
+	interval := sdWatchdogInterval()
+	if interval == 0 {
+		return
+	}
+	fmt.Printf("🐕 systemd watchdog enabled, pinging every %s\n", interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if !healthy() {
+				fmt.Println("⚠️  Skipping watchdog ping: monitoring is not running")
+				continue
+			}
+			if _, err := sdNotify("WATCHDOG=1"); err != nil {
+				fmt.Printf("⚠️  Watchdog ping failed: %v\n", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,440 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SessionState is a state of the focus session state machine
+type SessionState string
+
+const (
+	SessionIdle       SessionState = "idle"
+	SessionFocusing   SessionState = "focusing"
+	SessionShortBreak SessionState = "short_break"
+	SessionLongBreak  SessionState = "long_break"
+)
+
+// SessionConfig configures the lengths and cycles of focus sessions and which
+// blocklist profile is enforced in each state
+type SessionConfig struct {
+	FocusMinutes          int    `json:"focusMinutes"`
+	ShortBreakMinutes     int    `json:"shortBreakMinutes"`
+	LongBreakMinutes      int    `json:"longBreakMinutes"`
+	CyclesBeforeLongBreak int    `json:"cyclesBeforeLongBreak"`
+	TotalCycles           int    `json:"totalCycles"` // 0 = run until stopped
+	FocusProfile          string `json:"focusProfile"`
+	ShortBreakProfile     string `json:"shortBreakProfile"`
+	LongBreakProfile      string `json:"longBreakProfile"`
+	IdleProfile           string `json:"idleProfile"`
+
+	// DowntimePolicy decides what happens to a session when sybr was not
+	// running for part of it: DowntimeCount or DowntimeInterrupt
+	DowntimePolicy string `json:"downtimePolicy"`
+
+	// LockDuringSession turns on the commitment lock while a session runs
+	LockDuringSession bool `json:"lockDuringSession"`
+}
+
+const (
+	// DowntimeCount keeps the session clock running while sybr is down
+	DowntimeCount = "count"
+	// DowntimeInterrupt ends the session if sybr was down longer than downtimeGrace
+	DowntimeInterrupt = "interrupt"
+)
+
+// downtimeGrace is how long sybr may be down (e.g. for an update) before
+// DowntimeInterrupt ends the session
+const downtimeGrace = 2 * time.Minute
+
+// DefaultSessionConfig returns the classic pomodoro setup
+func DefaultSessionConfig() SessionConfig {
+	return SessionConfig{
+		FocusMinutes:          25,
+		ShortBreakMinutes:     5,
+		LongBreakMinutes:      15,
+		CyclesBeforeLongBreak: 4,
+		TotalCycles:           4,
+		FocusProfile:          "focus",
+		DowntimePolicy:        DowntimeCount,
+	}
+}
+
+// Validate checks the config for values the state machine can't run with
+func (c SessionConfig) Validate() error {
+	if c.FocusMinutes <= 0 || c.ShortBreakMinutes <= 0 || c.LongBreakMinutes <= 0 {
+		return fmt.Errorf("session lengths must be positive")
+	}
+	if c.CyclesBeforeLongBreak <= 0 {
+		return fmt.Errorf("cycles before long break must be positive")
+	}
+	if c.TotalCycles < 0 {
+		return fmt.Errorf("total cycles can't be negative")
+	}
+	switch c.DowntimePolicy {
+	case "", DowntimeCount, DowntimeInterrupt:
+	default:
+		return fmt.Errorf("unknown downtime policy '%s'", c.DowntimePolicy)
+	}
+	return nil
+}
+
+// length returns how long a state lasts
+func (c SessionConfig) length(state SessionState) time.Duration {
+	switch state {
+	case SessionFocusing:
+		return time.Duration(c.FocusMinutes) * time.Minute
+	case SessionShortBreak:
+		return time.Duration(c.ShortBreakMinutes) * time.Minute
+	case SessionLongBreak:
+		return time.Duration(c.LongBreakMinutes) * time.Minute
+	}
+	return 0
+}
+
+// profile returns the blocklist profile enforced in a state
+func (c SessionConfig) profile(state SessionState) string {
+	switch state {
+	case SessionFocusing:
+		return c.FocusProfile
+	case SessionShortBreak:
+		return c.ShortBreakProfile
+	case SessionLongBreak:
+		return c.LongBreakProfile
+	}
+	return c.IdleProfile
+}
+
+// SessionStatus describes the current session
+type SessionStatus struct {
+	State            SessionState `json:"state"`
+	Cycle            int          `json:"cycle"` // 1-based focus cycle, 0 when idle
+	TotalCycles      int          `json:"totalCycles"`
+	Profile          string       `json:"profile"`
+	StartedAt        time.Time    `json:"startedAt"` // Start of the current state
+	EndsAt           time.Time    `json:"endsAt"`
+	RemainingSeconds int          `json:"remainingSeconds"`
+}
+
+// SessionChangedEvent is the payload of EventSessionChanged
+type SessionChangedEvent struct {
+	Status   SessionStatus `json:"status"`
+	Previous SessionState  `json:"previous"`
+	Reason   string        `json:"reason"` // started, completed, skipped, stopped, restored, interrupted
+}
+
+// ProfileSwitcher changes the enforced blocklist profile (BlocklistManager)
+type ProfileSwitcher interface {
+	SetActiveProfile(profile string) error
+}
+
+// SessionStore persists the running session (StateStore)
+type SessionStore interface {
+	SaveSession(snapshot *SessionSnapshot) error
+}
+
+// SessionEngine runs the idle -> focusing -> short break -> long break state machine
+type SessionEngine struct {
+	mu          sync.Mutex
+	clock       Clock
+	bus         *EventBus
+	profiles    ProfileSwitcher
+	store       SessionStore
+	config      SessionConfig
+	state       SessionState
+	cycle       int
+	focusLength time.Duration // Override for this session (e.g. `sybr session start 50m`)
+	startedAt   time.Time
+	endsAt      time.Time
+	timer       Timer
+	generation  uint64 // Invalidates timers that fired after a transition
+}
+
+// NewSessionEngine creates an idle engine
+func NewSessionEngine(bus *EventBus, clock Clock, profiles ProfileSwitcher, config SessionConfig) *SessionEngine {
+	if clock == nil {
+		clock = realClock{}
+	}
+	if err := config.Validate(); err != nil {
+		// A zero length or cycle count would hang or crash the state machine.
+		// Keep the lock setting so a bad config can't turn it off
+		fmt.Printf("⚠️  Invalid session settings, using the defaults: %v\n", err)
+		lock := config.LockDuringSession
+		config = DefaultSessionConfig()
+		config.LockDuringSession = lock
+	}
+	return &SessionEngine{
+		clock:    clock,
+		bus:      bus,
+		profiles: profiles,
+		config:   config,
+		state:    SessionIdle,
+	}
+}
+
+// SetStore sets where the session is saved on every transition
+func (se *SessionEngine) SetStore(store SessionStore) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	se.store = store
+}
+
+// Restore resumes a saved session. lastSeen is the last time sybr was known to
+// be running; the time since then is handled by the downtime policy
+func (se *SessionEngine) Restore(snapshot *SessionSnapshot, lastSeen time.Time) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	if snapshot == nil || snapshot.State == SessionIdle || se.state != SessionIdle {
+		return
+	}
+
+	se.state = snapshot.State
+	se.cycle = snapshot.Cycle
+	se.focusLength = snapshot.FocusLength
+	se.startedAt = snapshot.StartedAt
+	se.endsAt = snapshot.EndsAt
+
+	now := se.clock.Now()
+	downtime := now.Sub(lastSeen)
+	if lastSeen.IsZero() || downtime < 0 {
+		downtime = 0
+	}
+
+	if se.config.DowntimePolicy == DowntimeInterrupt && downtime > downtimeGrace {
+		fmt.Printf("⏱️  Session interrupted: sybr was not running for %s\n", downtime.Round(time.Second))
+		se.cycle = 0
+		se.enter(SessionIdle, "interrupted", now)
+		return
+	}
+
+	// Count the downtime: play the phases that ended while sybr was down
+	for se.state != SessionIdle && !now.Before(se.endsAt) {
+		state, cycle := se.next()
+		start := se.endsAt
+		se.state, se.cycle = state, cycle
+		se.startedAt = start
+		se.endsAt = start.Add(se.stateLength(state))
+	}
+	if se.state == SessionIdle {
+		se.state = snapshot.State // Report the saved state as the previous one
+		se.enter(SessionIdle, "completed", now)
+		return
+	}
+
+	state := se.state
+	se.state = snapshot.State
+	se.enter(state, "restored", se.startedAt)
+}
+
+// SetConfig replaces the configuration; it applies from the next state
+func (se *SessionEngine) SetConfig(config SessionConfig) error {
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	se.config = config
+	return nil
+}
+
+// Config returns the current configuration
+func (se *SessionEngine) Config() SessionConfig {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	return se.config
+}
+
+// Start begins a focus session. focus overrides the configured focus length if > 0
+func (se *SessionEngine) Start(focus time.Duration) error {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	if se.state != SessionIdle {
+		return fmt.Errorf("a session is already running (%s)", se.state)
+	}
+	if focus < 0 {
+		return fmt.Errorf("focus length can't be negative")
+	}
+	se.focusLength = focus
+	se.cycle = 1
+	se.enter(SessionFocusing, "started", se.clock.Now())
+	return nil
+}
+
+// Stop ends the session and returns to idle
+func (se *SessionEngine) Stop() error {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	if se.state == SessionIdle {
+		return fmt.Errorf("no session is running")
+	}
+	se.cycle = 0
+	se.enter(SessionIdle, "stopped", se.clock.Now())
+	return nil
+}
+
+// Skip ends the current state early and moves to the next one
+func (se *SessionEngine) Skip() error {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	if se.state == SessionIdle {
+		return fmt.Errorf("no session is running")
+	}
+	se.advance("skipped")
+	return nil
+}
+
+// Status returns the current state and remaining time
+func (se *SessionEngine) Status() SessionStatus {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	return se.statusLocked()
+}
+
+func (se *SessionEngine) statusLocked() SessionStatus {
+	status := SessionStatus{
+		State:       se.state,
+		Cycle:       se.cycle,
+		TotalCycles: se.config.TotalCycles,
+		Profile:     se.config.profile(se.state),
+	}
+	if se.state != SessionIdle {
+		status.StartedAt = se.startedAt
+		status.EndsAt = se.endsAt
+		if remaining := se.endsAt.Sub(se.clock.Now()); remaining > 0 {
+			status.RemainingSeconds = int((remaining + time.Second - 1) / time.Second)
+		}
+	}
+	return status
+}
+
+// stateLength returns how long state lasts in the current session
+func (se *SessionEngine) stateLength(state SessionState) time.Duration {
+	if state == SessionFocusing && se.focusLength > 0 {
+		return se.focusLength
+	}
+	return se.config.length(state)
+}
+
+// next returns the state following the current one and its cycle
+// Caller must hold the lock
+func (se *SessionEngine) next() (SessionState, int) {
+	switch se.state {
+	case SessionFocusing:
+		if se.cycle%se.config.CyclesBeforeLongBreak == 0 {
+			return SessionLongBreak, se.cycle
+		}
+		return SessionShortBreak, se.cycle
+	case SessionShortBreak, SessionLongBreak:
+		if se.config.TotalCycles > 0 && se.cycle >= se.config.TotalCycles {
+			return SessionIdle, 0
+		}
+		return SessionFocusing, se.cycle + 1
+	}
+	return SessionIdle, 0
+}
+
+// advance moves to the state following the current one
+// Caller must hold the lock
+func (se *SessionEngine) advance(reason string) {
+	state, cycle := se.next()
+	if state == SessionIdle {
+		reason = "completed"
+	}
+	se.cycle = cycle
+	se.enter(state, reason, se.clock.Now())
+}
+
+// enter switches to state starting at start, arms its timer, applies its
+// profile, saves the session and publishes the change
+// Caller must hold the lock
+func (se *SessionEngine) enter(state SessionState, reason string, start time.Time) {
+	previous := se.state
+	se.state = state
+	se.generation++
+	if se.timer != nil {
+		se.timer.Stop()
+		se.timer = nil
+	}
+
+	se.startedAt = start
+	se.endsAt = time.Time{}
+	if state != SessionIdle {
+		se.endsAt = start.Add(se.stateLength(state))
+		remaining := se.endsAt.Sub(se.clock.Now())
+		if remaining < 0 {
+			remaining = 0
+		}
+		generation := se.generation
+		se.timer = se.clock.AfterFunc(remaining, func() {
+			se.onTimer(generation)
+		})
+	}
+
+	if se.profiles != nil {
+		if err := se.profiles.SetActiveProfile(se.config.profile(state)); err != nil {
+			fmt.Printf("⚠️  Failed to switch blocklist profile: %v\n", err)
+		}
+	}
+
+	if se.store != nil {
+		var snapshot *SessionSnapshot
+		if state != SessionIdle {
+			snapshot = &SessionSnapshot{
+				State:       state,
+				Cycle:       se.cycle,
+				FocusLength: se.focusLength,
+				StartedAt:   se.startedAt,
+				EndsAt:      se.endsAt,
+			}
+		}
+		if err := se.store.SaveSession(snapshot); err != nil {
+			fmt.Printf("⚠️  Failed to save session: %v\n", err)
+		}
+	}
+
+	status := se.statusLocked()
+	fmt.Printf("⏱️  Session %s -> %s (%s, cycle %d)\n", previous, state, reason, se.cycle)
+	if se.bus != nil {
+		se.bus.Publish(EventSessionChanged, SessionChangedEvent{
+			Status:   status,
+			Previous: previous,
+			Reason:   reason,
+		})
+	}
+}
+
+// onTimer is called when the current state's time is up
+func (se *SessionEngine) onTimer(generation uint64) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+	if generation != se.generation || se.state == SessionIdle {
+		return
+	}
+	se.advance("completed")
+}
+
+// parseSessionLength parses "50m", "1h30m" or a plain number of minutes
+func parseSessionLength(value string) (time.Duration, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(value)
+	if minutes, atoiErr := strconv.Atoi(value); atoiErr == nil {
+		d, err = time.Duration(minutes)*time.Minute, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("invalid session length '%s' (use e.g. 50m)", value)
+	}
+	if d < time.Minute {
+		return 0, fmt.Errorf("session length must be at least one minute")
+	}
+	return d, nil
+}
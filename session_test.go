@@ -0,0 +1,353 @@
+package main
+
+import (
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock is a Clock whose time only moves when Advance is called
+type fakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock   *fakeClock
+	at      time.Time
+	f       func()
+	stopped bool
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{now: time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) AfterFunc(d time.Duration, f func()) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	timer := &fakeTimer{clock: c, at: c.now.Add(d), f: f}
+	c.timers = append(c.timers, timer)
+	return timer
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+	wasActive := !t.stopped
+	t.stopped = true
+	return wasActive
+}
+
+// Advance moves time forward, firing due timers in order without holding the lock
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	target := c.now.Add(d)
+	c.mu.Unlock()
+
+	for {
+		c.mu.Lock()
+		sort.SliceStable(c.timers, func(i, j int) bool { return c.timers[i].at.Before(c.timers[j].at) })
+		var due *fakeTimer
+		for i, timer := range c.timers {
+			if timer.stopped {
+				continue
+			}
+			if !timer.at.After(target) {
+				due = timer
+				due.stopped = true
+				c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			}
+			break
+		}
+		if due == nil {
+			c.now = target
+			c.mu.Unlock()
+			return
+		}
+		c.now = due.at
+		c.mu.Unlock()
+		due.f()
+	}
+}
+
+// fakeProfiles records the profiles the engine switches to
+type fakeProfiles struct {
+	mu      sync.Mutex
+	applied []string
+}
+
+func (p *fakeProfiles) SetActiveProfile(profile string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.applied = append(p.applied, profile)
+	return nil
+}
+
+func (p *fakeProfiles) last() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.applied) == 0 {
+		return ""
+	}
+	return p.applied[len(p.applied)-1]
+}
+
+func testSessionConfig() SessionConfig {
+	return SessionConfig{
+		FocusMinutes:          25,
+		ShortBreakMinutes:     5,
+		LongBreakMinutes:      15,
+		CyclesBeforeLongBreak: 2,
+		TotalCycles:           3,
+		FocusProfile:          "focus",
+		ShortBreakProfile:     "break",
+		LongBreakProfile:      "break",
+	}
+}
+
+// TestSessionCycle tests the full idle -> focus -> break -> ... -> idle cycle,
+// including the long break and the profile switched in every state
+func TestSessionCycle(t *testing.T) {
+	clock := newFakeClock()
+	profiles := &fakeProfiles{}
+	engine := NewSessionEngine(nil, clock, profiles, testSessionConfig())
+
+	if err := engine.Start(0); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+
+	steps := []struct {
+		advance time.Duration
+		state   SessionState
+		cycle   int
+		profile string
+	}{
+		{0, SessionFocusing, 1, "focus"},
+		{25 * time.Minute, SessionShortBreak, 1, "break"},
+		{5 * time.Minute, SessionFocusing, 2, "focus"},
+		{25 * time.Minute, SessionLongBreak, 2, "break"},
+		{15 * time.Minute, SessionFocusing, 3, "focus"},
+		{25 * time.Minute, SessionShortBreak, 3, "break"},
+		{5 * time.Minute, SessionIdle, 0, ""},
+	}
+	for i, step := range steps {
+		clock.Advance(step.advance)
+		status := engine.Status()
+		if status.State != step.state || status.Cycle != step.cycle {
+			t.Fatalf("step %d: got %s cycle %d, want %s cycle %d", i, status.State, status.Cycle, step.state, step.cycle)
+		}
+		if got := profiles.last(); got != step.profile {
+			t.Errorf("step %d: active profile %q, want %q", i, got, step.profile)
+		}
+	}
+}
+
+// TestSessionStatusRemaining tests the remaining time and the focus length override
+func TestSessionStatusRemaining(t *testing.T) {
+	clock := newFakeClock()
+	engine := NewSessionEngine(nil, clock, nil, testSessionConfig())
+
+	if err := engine.Start(50 * time.Minute); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	clock.Advance(20 * time.Minute)
+	if got := engine.Status().RemainingSeconds; got != 30*60 {
+		t.Errorf("RemainingSeconds = %d, want %d", got, 30*60)
+	}
+	clock.Advance(30 * time.Minute)
+	if state := engine.Status().State; state != SessionShortBreak {
+		t.Errorf("state after 50m = %s, want %s", state, SessionShortBreak)
+	}
+}
+
+// TestSessionStopAndSkip tests that Stop cancels the pending timer and Skip advances early
+func TestSessionStopAndSkip(t *testing.T) {
+	clock := newFakeClock()
+	bus := NewEventBus()
+	sub := bus.Subscribe(16, EventSessionChanged)
+	defer sub.Unsubscribe()
+	engine := NewSessionEngine(bus, clock, nil, testSessionConfig())
+
+	if err := engine.Stop(); err == nil {
+		t.Error("Stop without a session should fail")
+	}
+	if err := engine.Start(0); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	if err := engine.Start(0); err == nil {
+		t.Error("Start during a session should fail")
+	}
+	if err := engine.Skip(); err != nil {
+		t.Fatalf("Skip failed: %v", err)
+	}
+	if state := engine.Status().State; state != SessionShortBreak {
+		t.Fatalf("state after skip = %s, want %s", state, SessionShortBreak)
+	}
+	if err := engine.Stop(); err != nil {
+		t.Fatalf("Stop failed: %v", err)
+	}
+
+	// The break timer must not fire after the stop
+	clock.Advance(time.Hour)
+	if state := engine.Status().State; state != SessionIdle {
+		t.Fatalf("state after stop = %s, want %s", state, SessionIdle)
+	}
+
+	reasons := []string{}
+	for len(sub.C) > 0 {
+		event := <-sub.C
+		reasons = append(reasons, event.Payload.(SessionChangedEvent).Reason)
+	}
+	want := []string{"started", "skipped", "stopped"}
+	if len(reasons) != len(want) {
+		t.Fatalf("events = %v, want %v", reasons, want)
+	}
+	for i := range want {
+		if reasons[i] != want[i] {
+			t.Errorf("event %d reason = %q, want %q", i, reasons[i], want[i])
+		}
+	}
+}
+
+// TestParseSessionLength tests the accepted session length formats
+func TestParseSessionLength(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"", 0, false},
+		{"50", 50 * time.Minute, false},
+		{"50m", 50 * time.Minute, false},
+		{"1h30m", 90 * time.Minute, false},
+		{"30s", 0, true},
+		{"soon", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSessionLength(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("parseSessionLength(%q) = %v, %v; want %v, err %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+// memorySessionStore keeps the last saved snapshot
+type memorySessionStore struct {
+	mu       sync.Mutex
+	snapshot *SessionSnapshot
+}
+
+func (m *memorySessionStore) SaveSession(snapshot *SessionSnapshot) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshot = snapshot
+	return nil
+}
+
+// TestSessionInvalidConfig tests that settings the state machine can't run
+// with are replaced by the defaults instead of crashing or hanging it
+func TestSessionInvalidConfig(t *testing.T) {
+	config := testSessionConfig()
+	config.CyclesBeforeLongBreak = 0
+	config.ShortBreakMinutes = 0
+	config.LockDuringSession = true
+	clock := newFakeClock()
+	engine := NewSessionEngine(nil, clock, nil, config)
+
+	got := engine.Config()
+	if got.CyclesBeforeLongBreak != DefaultSessionConfig().CyclesBeforeLongBreak || got.ShortBreakMinutes <= 0 || !got.LockDuringSession {
+		t.Fatalf("config = %+v, want the defaults with the lock kept", got)
+	}
+	if err := engine.Start(0); err != nil {
+		t.Fatalf("Start failed: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		if err := engine.Skip(); err != nil {
+			break
+		}
+	}
+
+	// Restoring over a long downtime has to end instead of spinning
+	started := clock.Now().Add(-48 * time.Hour)
+	engine = NewSessionEngine(nil, clock, nil, config)
+	engine.Restore(&SessionSnapshot{State: SessionFocusing, Cycle: 1, StartedAt: started, EndsAt: started.Add(time.Minute)}, started)
+}
+
+// TestSessionRestore tests resuming a saved session under both downtime policies
+func TestSessionRestore(t *testing.T) {
+	tests := []struct {
+		name      string
+		policy    string
+		downtime  time.Duration
+		wantState SessionState
+		wantCycle int
+		remaining int
+	}{
+		{"short restart", DowntimeInterrupt, time.Minute, SessionFocusing, 1, 14 * 60},
+		{"interrupted", DowntimeInterrupt, 5 * time.Minute, SessionIdle, 0, 0},
+		{"counted within phase", DowntimeCount, 5 * time.Minute, SessionFocusing, 1, 10 * 60},
+		{"counted across phases", DowntimeCount, 18 * time.Minute, SessionShortBreak, 1, 2 * 60},
+		{"counted past the end", DowntimeCount, 3 * time.Hour, SessionIdle, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// A focus phase started 10 minutes ago; sybr was seen for the last time
+			// `downtime` ago and was restarted now
+			clock := newFakeClock()
+			now := clock.Now()
+			lastSeen := now.Add(-tt.downtime)
+			started := lastSeen.Add(-10 * time.Minute)
+			snapshot := &SessionSnapshot{
+				State:     SessionFocusing,
+				Cycle:     1,
+				StartedAt: started,
+				EndsAt:    started.Add(25 * time.Minute),
+			}
+
+			config := testSessionConfig()
+			config.DowntimePolicy = tt.policy
+			store := &memorySessionStore{}
+			engine := NewSessionEngine(nil, clock, nil, config)
+			engine.SetStore(store)
+			engine.Restore(snapshot, lastSeen)
+
+			status := engine.Status()
+			if status.State != tt.wantState || status.Cycle != tt.wantCycle {
+				t.Fatalf("restored %s cycle %d, want %s cycle %d", status.State, status.Cycle, tt.wantState, tt.wantCycle)
+			}
+			if tt.remaining > 0 && status.RemainingSeconds != tt.remaining {
+				t.Errorf("RemainingSeconds = %d, want %d", status.RemainingSeconds, tt.remaining)
+			}
+			if (store.snapshot == nil) != (tt.wantState == SessionIdle) {
+				t.Errorf("saved snapshot = %+v for state %s", store.snapshot, tt.wantState)
+			}
+		})
+	}
+}
+
+// TestSessionRestoreTimer tests that the restored phase still ends on time
+func TestSessionRestoreTimer(t *testing.T) {
+	clock := newFakeClock()
+	now := clock.Now()
+	engine := NewSessionEngine(nil, clock, nil, testSessionConfig())
+	engine.Restore(&SessionSnapshot{
+		State:     SessionShortBreak,
+		Cycle:     1,
+		StartedAt: now.Add(-2 * time.Minute),
+		EndsAt:    now.Add(3 * time.Minute),
+	}, now)
+
+	clock.Advance(3 * time.Minute)
+	if status := engine.Status(); status.State != SessionFocusing || status.Cycle != 2 {
+		t.Errorf("after break: %s cycle %d, want %s cycle 2", status.State, status.Cycle, SessionFocusing)
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Settings holds the user configuration stored in settings.json
+type Settings struct {
+	Session SessionConfig `json:"session"`
+
+	// UnlockDelayMinutes delays removing blocks and narrowing profiles; 0 applies them at once
+	UnlockDelayMinutes int `json:"unlockDelayMinutes"`
+
+	// EmergencyPassageWords is the length of the emergency unlock passage
+	EmergencyPassageWords int `json:"emergencyPassageWords"`
+
+	// Admin is the optional password protecting blocklist and settings changes
+	Admin AdminConfig `json:"admin"`
+
+	// Partner is the accountability partner's TOTP secret for lifting locks and removing blocks
+	Partner PartnerConfig `json:"partner"`
+
+	// Watchdog runs a helper process that restarts sybr if it is killed during a session or lock
+	Watchdog bool `json:"watchdog"`
+
+	// ProcessScan looks for blocked apps among all running processes, not just the focused window
+	ProcessScan ProcessScanConfig `json:"processScan"`
+
+	// DNS runs a local resolver that blocks the blocklist's websites in every browser
+	DNS DNSConfig `json:"dns"`
+
+	// HTTPProxy runs a local proxy that refuses the blocklist's websites, as an alternative to DNS
+	HTTPProxy HTTPProxyConfig `json:"httpProxy"`
+
+	// ActivityWatch sends the focused window to an aw-server like aw-watcher-window
+	ActivityWatch ActivityWatchConfig `json:"activityWatch"`
+
+	// WakaTime accepts heartbeats from WakaTime editor plugins to attribute editor time to projects
+	WakaTime WakaTimeConfig `json:"wakaTime"`
+}
+
+// DefaultSettings returns the settings used when no file exists yet
+func DefaultSettings() Settings {
+	return Settings{
+		Session:               DefaultSessionConfig(),
+		EmergencyPassageWords: defaultEmergencyPassageWords,
+	}
+}
+
+// SettingsManager manages the settings storage
+type SettingsManager struct {
+	filePath string
+	mu       sync.RWMutex
+	settings Settings
+}
+
+var (
+	globalSettings *SettingsManager
+	settingsErr    error // Why settings.json couldn't be loaded, returned on every call
+	settingsOnce   sync.Once
+)
+
+// GetSettingsManager returns the global settings manager instance. A file that
+// couldn't be loaded keeps failing so its defaults never overwrite it
+func GetSettingsManager() (*SettingsManager, error) {
+	settingsOnce.Do(func() {
+		filePath, pathErr := getDataFilePath("settings.json")
+		if pathErr != nil {
+			settingsErr = pathErr
+			return
+		}
+		globalSettings, settingsErr = newSettingsManager(filePath)
+	})
+	return globalSettings, settingsErr
+}
+
+// newSettingsManager loads settings from filePath, falling back to defaults
+func newSettingsManager(filePath string) (*SettingsManager, error) {
+	sm := &SettingsManager{
+		filePath: filePath,
+		settings: DefaultSettings(),
+	}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		// If file doesn't exist, that's okay - start with defaults
+		if os.IsNotExist(err) {
+			return sm, nil
+		}
+		return sm, err
+	}
+	if len(data) > 0 {
+		// Unmarshal over the defaults so new fields keep their default values
+		if err := json.Unmarshal(data, &sm.settings); err != nil {
+			return sm, fmt.Errorf("failed to parse settings: %w", err)
+		}
+	}
+	return sm, nil
+}
+
+// Get returns a copy of the current settings
+func (sm *SettingsManager) Get() Settings {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+	return sm.settings
+}
+
+// Update applies fn to the settings and saves them if fn succeeds
+func (sm *SettingsManager) Update(fn func(*Settings) error) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	updated := sm.settings
+	if err := fn(&updated); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(updated, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal settings: %w", err)
+	}
+	if err := os.WriteFile(sm.filePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	sm.settings = updated
+	return nil
+}
@@ -0,0 +1,288 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// stateHeartbeatInterval is how often LastSeen is refreshed while a session runs.
+// It bounds how much downtime goes unnoticed after a crash
+const stateHeartbeatInterval = 30 * time.Second
+
+// SessionSnapshot is the persisted form of a running session
+type SessionSnapshot struct {
+	State       SessionState  `json:"state"`
+	Cycle       int           `json:"cycle"`
+	FocusLength time.Duration `json:"focusLength,omitempty"` // Override passed to Start
+	StartedAt   time.Time     `json:"startedAt"`
+	EndsAt      time.Time     `json:"endsAt"`
+}
+
+// EscalationCounter counts the warnings shown for an app on one day
+type EscalationCounter struct {
+	Day   string `json:"day"` // 2006-01-02 in local time
+	Count int    `json:"count"`
+}
+
+// EnforcementState is the runtime state that has to survive restarts and
+// crashes. It is stored in state.json and written on every change
+type EnforcementState struct {
+	Session     *SessionSnapshot             `json:"session,omitempty"`
+	Snoozes     map[string]time.Time         `json:"snoozes,omitempty"`     // exe -> snoozed until
+	Escalations map[string]EscalationCounter `json:"escalations,omitempty"` // exe -> warnings today
+	LockedUntil time.Time                    `json:"lockedUntil"`           // End of the timed commitment lock
+	Pending     []PendingChange              `json:"pending,omitempty"`     // Unlocks waiting for their delay
+	LastSeen    time.Time                    `json:"lastSeen"`              // Last time the app was known to be running
+
+	AuthFailures     int       `json:"authFailures,omitempty"`    // Wrong admin passwords in a row
+	AuthBlockedUntil time.Time `json:"authBlockedUntil"`          // No attempts until then
+	PartnerLastStep  int64     `json:"partnerLastStep,omitempty"` // TOTP step of the last accepted partner code
+}
+
+// StateStore manages the enforcement state storage
+type StateStore struct {
+	filePath string
+	mu       sync.Mutex
+	state    EnforcementState
+	broken   error // Why state.json couldn't be read; it isn't written until Reset
+}
+
+var (
+	globalState *StateStore
+	stateErr    error // Why state.json couldn't be loaded, returned on every call
+	stateOnce   sync.Once
+)
+
+// GetStateStore returns the global state store instance. A file that couldn't
+// be read still gives a store, which keeps changes in memory only
+func GetStateStore() (*StateStore, error) {
+	stateOnce.Do(func() {
+		filePath, pathErr := getDataFilePath("state.json")
+		if pathErr != nil {
+			stateErr = pathErr
+			return
+		}
+		globalState, stateErr = newStateStore(filePath)
+	})
+	return globalState, stateErr
+}
+
+// newStateStore loads the state from filePath; a missing file is an empty state.
+// A file that can't be read may hold a lock, so the store is returned broken
+func newStateStore(filePath string) (*StateStore, error) {
+	ss := &StateStore{filePath: filePath}
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return ss, nil
+		}
+		ss.broken = err
+		return ss, err
+	}
+	if len(data) > 0 {
+		if err := json.Unmarshal(data, &ss.state); err != nil {
+			ss.broken = fmt.Errorf("failed to parse state: %w", err)
+			return ss, ss.broken
+		}
+	}
+	return ss, nil
+}
+
+// Err returns why state.json couldn't be read, nil if it could
+func (ss *StateStore) Err() error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.broken
+}
+
+// Reset replaces a state.json that couldn't be read with the state in memory.
+// Only lifting the commitment lock calls it, as the file may have held a lock
+func (ss *StateStore) Reset() error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	if ss.broken == nil {
+		return nil
+	}
+	data, err := json.MarshalIndent(ss.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+	if err := writeFileAtomic(ss.filePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	fmt.Println("🔓 Replaced the unreadable state.json")
+	ss.broken = nil
+	return nil
+}
+
+// Get returns a copy of the current state
+func (ss *StateStore) Get() EnforcementState {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	return ss.state.clone()
+}
+
+// Update applies fn to the state and saves it if fn succeeds
+func (ss *StateStore) Update(fn func(*EnforcementState) error) error {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+
+	updated := ss.state.clone()
+	if err := fn(&updated); err != nil {
+		return err
+	}
+	updated.LastSeen = time.Now()
+	if ss.broken != nil {
+		// Writing would drop whatever the unreadable file holds
+		ss.state = updated
+		return nil
+	}
+
+	data, err := json.MarshalIndent(updated, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+	if err := writeFileAtomic(ss.filePath, data, 0600); err != nil {
+		return fmt.Errorf("failed to save state: %w", err)
+	}
+	ss.state = updated
+	return nil
+}
+
+// SaveSession stores the running session, nil when the engine is idle
+func (ss *StateStore) SaveSession(snapshot *SessionSnapshot) error {
+	return ss.Update(func(state *EnforcementState) error {
+		state.Session = snapshot
+		return nil
+	})
+}
+
+// Snooze suppresses warnings for exe until the given time
+func (ss *StateStore) Snooze(exe string, until time.Time) error {
+	return ss.Update(func(state *EnforcementState) error {
+		if !until.After(time.Now()) {
+			delete(state.Snoozes, exe)
+			return nil
+		}
+		state.Snoozes[exe] = until
+		return nil
+	})
+}
+
+// SnoozedUntil returns when the snooze for exe ends, zero if it isn't snoozed
+func (ss *StateStore) SnoozedUntil(exe string, now time.Time) time.Time {
+	ss.mu.Lock()
+	defer ss.mu.Unlock()
+	until := ss.state.Snoozes[exe]
+	if !until.After(now) {
+		return time.Time{}
+	}
+	return until
+}
+
+// RecordWarning counts a warning for exe and returns how many were shown today
+// Expired snoozes and counters from previous days are dropped on the way
+func (ss *StateStore) RecordWarning(exe string, now time.Time) (int, error) {
+	day := now.Local().Format("2006-01-02")
+	count := 0
+	err := ss.Update(func(state *EnforcementState) error {
+		for name, until := range state.Snoozes {
+			if !until.After(now) {
+				delete(state.Snoozes, name)
+			}
+		}
+		for name, counter := range state.Escalations {
+			if counter.Day != day {
+				delete(state.Escalations, name)
+			}
+		}
+		counter := state.Escalations[exe]
+		counter.Day = day
+		counter.Count++
+		state.Escalations[exe] = counter
+		count = counter.Count
+		return nil
+	})
+	return count, err
+}
+
+// Touch refreshes LastSeen while a session is running so downtime after a
+// crash can be measured; there is nothing to protect otherwise
+func (ss *StateStore) Touch() error {
+	ss.mu.Lock()
+	active := ss.state.Session != nil
+	ss.mu.Unlock()
+	if !active {
+		return nil
+	}
+	return ss.Update(func(state *EnforcementState) error { return nil })
+}
+
+// StartStateHeartbeat touches the store periodically until stop is closed
+func StartStateHeartbeat(ss *StateStore, stop <-chan struct{}) {
+	go func() {
+		ticker := time.NewTicker(stateHeartbeatInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if err := ss.Touch(); err != nil {
+					fmt.Printf("⚠️  Failed to update state: %v\n", err)
+				}
+			}
+		}
+	}()
+}
+
+// clone copies the maps so callers can't modify the stored state
+func (s EnforcementState) clone() EnforcementState {
+	out := s
+	if s.Session != nil {
+		session := *s.Session
+		out.Session = &session
+	}
+	out.Snoozes = make(map[string]time.Time, len(s.Snoozes))
+	for exe, until := range s.Snoozes {
+		out.Snoozes[exe] = until
+	}
+	out.Escalations = make(map[string]EscalationCounter, len(s.Escalations))
+	for exe, counter := range s.Escalations {
+		out.Escalations[exe] = counter
+	}
+	out.Pending = make([]PendingChange, len(s.Pending))
+	copy(out.Pending, s.Pending)
+	return out
+}
+
+// writeFileAtomic writes data to a temporary file and renames it over path,
+// so a crash mid-write never leaves a truncated file behind
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath) // No-op after a successful rename
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
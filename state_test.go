@@ -0,0 +1,56 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestStateStorePersistence tests that sessions, snoozes and escalation
+// counters survive reopening the store
+func TestStateStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state.json")
+	store, err := newStateStore(path)
+	if err != nil {
+		t.Fatalf("newStateStore failed: %v", err)
+	}
+
+	now := time.Now()
+	session := &SessionSnapshot{State: SessionFocusing, Cycle: 2, StartedAt: now, EndsAt: now.Add(25 * time.Minute)}
+	if err := store.SaveSession(session); err != nil {
+		t.Fatalf("SaveSession failed: %v", err)
+	}
+	if err := store.Snooze("discord.exe", now.Add(time.Hour)); err != nil {
+		t.Fatalf("Snooze failed: %v", err)
+	}
+	for i := 1; i <= 2; i++ {
+		count, err := store.RecordWarning("steam.exe", now)
+		if err != nil || count != i {
+			t.Fatalf("RecordWarning = %d, %v; want %d", count, err, i)
+		}
+	}
+
+	reopened, err := newStateStore(path)
+	if err != nil {
+		t.Fatalf("reopening failed: %v", err)
+	}
+	state := reopened.Get()
+	if state.Session == nil || state.Session.Cycle != 2 || !state.Session.EndsAt.Equal(session.EndsAt) {
+		t.Errorf("session = %+v, want %+v", state.Session, session)
+	}
+	if reopened.SnoozedUntil("discord.exe", now).IsZero() {
+		t.Error("discord.exe should still be snoozed")
+	}
+	if !reopened.SnoozedUntil("discord.exe", now.Add(2*time.Hour)).IsZero() {
+		t.Error("snooze should have expired after an hour")
+	}
+	if count, _ := reopened.RecordWarning("steam.exe", now); count != 3 {
+		t.Errorf("escalation count = %d, want 3", count)
+	}
+	if count, _ := reopened.RecordWarning("steam.exe", now.Add(48*time.Hour)); count != 1 {
+		t.Errorf("escalation count on a new day = %d, want 1", count)
+	}
+	if state.LastSeen.IsZero() {
+		t.Error("LastSeen should be set on save")
+	}
+}
@@ -0,0 +1,254 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// maxCommandLength caps the command line recorded for a terminal job
+const maxCommandLength = 120
+
+// terminalEmulators are looked into for the job in their foreground
+var terminalEmulators = map[string]bool{
+	"gnome-terminal-server": true,
+	"gnome-terminal":        true,
+	"kgx":                   true,
+	"ptyxis":                true,
+	"ptyxis-agent":          true,
+	"konsole":               true,
+	"yakuake":               true,
+	"xfce4-terminal":        true,
+	"mate-terminal":         true,
+	"lxterminal":            true,
+	"qterminal":             true,
+	"terminator":            true,
+	"tilix":                 true,
+	"guake":                 true,
+	"tilda":                 true,
+	"terminology":           true,
+	"xterm":                 true,
+	"uxterm":                true,
+	"urxvt":                 true,
+	"rxvt":                  true,
+	"st":                    true,
+	"sakura":                true,
+	"alacritty":             true,
+	"kitty":                 true,
+	"foot":                  true,
+	"wezterm":               true,
+	"wezterm-gui":           true,
+	"ghostty":               true,
+	"contour":               true,
+	"rio":                   true,
+	"blackbox":              true,
+	"cool-retro-term":       true,
+}
+
+// shellProcesses at a prompt mean the terminal is idle
+var shellProcesses = map[string]bool{
+	"sh":    true,
+	"bash":  true,
+	"dash":  true,
+	"zsh":   true,
+	"fish":  true,
+	"ksh":   true,
+	"tcsh":  true,
+	"csh":   true,
+	"nu":    true,
+	"xonsh": true,
+}
+
+// ttyProcess is the part of /proc/<pid>/stat needed to find terminal jobs
+type ttyProcess struct {
+	PID     int
+	PPID    int
+	Comm    string
+	PGRP    int // Process group
+	Session int
+	TTY     int // Controlling tty device number, 0 for none
+	TPGID   int // Foreground process group of that tty
+}
+
+// terminalForeground returns the job in the foreground of the terminal
+// emulator pid: of the ttys its descendants run on, the one typed into last.
+// The zero job means the shell is at its prompt or pid isn't a terminal
+func terminalForeground(pid int) TerminalJob {
+	processes, err := listTTYProcesses()
+	if err != nil {
+		return TerminalJob{}
+	}
+	job := selectForeground(pid, processes, ttyActivity)
+	if job.PID == 0 {
+		return TerminalJob{}
+	}
+
+	foreground := TerminalJob{PID: job.PID, Exe: trimProcessName(job.Comm)}
+	// comm is cut at 15 characters, the exe link has the full name
+	path := processPath(job.PID)
+	if path != "" {
+		foreground.Exe = trimProcessName(pathBase(path))
+	}
+	foreground.AppID = resolveAppID(job.PID, path)
+	foreground.Command = foregroundCommand(processCommandLine(job.PID))
+	return foreground
+}
+
+// selectForeground picks the foreground process of the most recently used
+// tty among the descendants of terminal. An interactive shell waiting at its
+// prompt is the foreground too, then nothing is returned
+func selectForeground(terminal int, processes []ttyProcess, activity func(tty int) time.Time) ttyProcess {
+	children := map[int][]ttyProcess{}
+	for _, p := range processes {
+		children[p.PPID] = append(children[p.PPID], p)
+	}
+
+	// Every tty used below the terminal, with its foreground process group
+	var descendants []ttyProcess
+	foregroundGroups := map[int]int{}
+	queue := []int{terminal}
+	seen := map[int]bool{terminal: true}
+	for len(queue) > 0 {
+		pid := queue[0]
+		queue = queue[1:]
+		for _, child := range children[pid] {
+			if seen[child.PID] {
+				continue
+			}
+			seen[child.PID] = true
+			queue = append(queue, child.PID)
+			descendants = append(descendants, child)
+			if child.TTY != 0 && child.TPGID > 0 {
+				foregroundGroups[child.TTY] = child.TPGID
+			}
+		}
+	}
+
+	activeTTY := 0
+	var activeAt time.Time
+	for tty, group := range foregroundGroups {
+		at := activity(tty)
+		if activeTTY == 0 || at.After(activeAt) || (at.Equal(activeAt) && group > foregroundGroups[activeTTY]) {
+			activeTTY, activeAt = tty, at
+		}
+	}
+	if activeTTY == 0 {
+		return ttyProcess{}
+	}
+
+	// The group leader is the job; it may have exited and left the rest
+	group := foregroundGroups[activeTTY]
+	job := ttyProcess{}
+	for _, p := range descendants {
+		if p.PGRP != group {
+			continue
+		}
+		if p.PID == group {
+			job = p
+			break
+		}
+		if job.PID == 0 || p.PID < job.PID {
+			job = p
+		}
+	}
+	// The terminal starts each shell as a session leader
+	if shellProcesses[trimProcessName(job.Comm)] && job.PID == job.Session {
+		return ttyProcess{}
+	}
+	return job
+}
+
+// listTTYProcesses reads the process and tty fields of every process
+func listTTYProcesses() ([]ttyProcess, error) {
+	entries, err := os.ReadDir("/proc")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read /proc: %w", err)
+	}
+	processes := make([]ttyProcess, 0, len(entries))
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		stat, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+		if err != nil {
+			continue
+		}
+		p, err := parseTTYStat(pid, string(stat))
+		if err != nil {
+			continue
+		}
+		processes = append(processes, p)
+	}
+	return processes, nil
+}
+
+// parseTTYStat reads the parent, process group, session and tty fields of
+// /proc/<pid>/stat
+func parseTTYStat(pid int, stat string) (ttyProcess, error) {
+	comm, fields, err := procStatFields(stat)
+	if err != nil {
+		return ttyProcess{}, err
+	}
+	// state ppid pgrp session tty_nr tpgid
+	if len(fields) < 6 {
+		return ttyProcess{}, fmt.Errorf("malformed stat")
+	}
+	values := make([]int, 5)
+	for i := range values {
+		if values[i], err = strconv.Atoi(fields[i+1]); err != nil {
+			return ttyProcess{}, fmt.Errorf("malformed stat: %w", err)
+		}
+	}
+	return ttyProcess{
+		PID:     pid,
+		PPID:    values[0],
+		Comm:    comm,
+		PGRP:    values[1],
+		Session: values[2],
+		TTY:     values[3],
+		TPGID:   values[4],
+	}, nil
+}
+
+// ttyActivity returns when the tty was last read from, i.e. typed into
+func ttyActivity(tty int) time.Time {
+	path := ptsPath(tty)
+	if path == "" {
+		return time.Time{}
+	}
+	var st unix.Stat_t
+	if err := unix.Stat(path, &st); err != nil {
+		return time.Time{}
+	}
+	return time.Unix(st.Atim.Unix())
+}
+
+// ptsPath returns the /dev/pts device of a tty number from stat, "" if it
+// isn't a pseudo-terminal
+func ptsPath(tty int) string {
+	major := (tty >> 8) & 0xfff
+	minor := (tty & 0xff) | ((tty >> 12) & 0xfff00)
+	// Pseudo-terminal slaves use majors 136 to 143
+	if major < 136 || major > 143 {
+		return ""
+	}
+	return fmt.Sprintf("/dev/pts/%d", (major-136)*256+minor)
+}
+
+// foregroundCommand shortens a command line for display: vim notes.md
+func foregroundCommand(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	parts := append([]string{pathBase(args[0])}, args[1:]...)
+	command := strings.Join(parts, " ")
+	if runes := []rune(command); len(runes) > maxCommandLength {
+		command = string(runes[:maxCommandLength-3]) + "..."
+	}
+	return command
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestParseTTYStat tests reading the process group and tty fields
+func TestParseTTYStat(t *testing.T) {
+	p, err := parseTTYStat(4300, "4300 (vim) S 4242 4300 4242 34817 4300 4194304 1 0")
+	if err != nil {
+		t.Fatalf("parseTTYStat failed: %v", err)
+	}
+	want := ttyProcess{PID: 4300, PPID: 4242, Comm: "vim", PGRP: 4300, Session: 4242, TTY: 34817, TPGID: 4300}
+	if p != want {
+		t.Errorf("parseTTYStat = %+v, want %+v", p, want)
+	}
+	if _, err := parseTTYStat(1, "1 (init) S 0"); err == nil {
+		t.Error("a short stat should fail")
+	}
+}
+
+// TestPtsPath tests mapping tty numbers to /dev/pts devices
+func TestPtsPath(t *testing.T) {
+	tests := map[int]string{
+		34817:     "/dev/pts/1",   // major 136, minor 1
+		34816 + 5: "/dev/pts/5",   // major 136, minor 5
+		35072 + 2: "/dev/pts/258", // major 137, minor 2
+		1024 + 1:  "",             // /dev/tty1
+		0:         "",
+	}
+	for tty, want := range tests {
+		if got := ptsPath(tty); got != want {
+			t.Errorf("ptsPath(%d) = %q, want %q", tty, got, want)
+		}
+	}
+}
+
+// TestSelectForeground tests finding the job of the tab typed into last and
+// ignoring shells at their prompt
+func TestSelectForeground(t *testing.T) {
+	const pts1, pts2 = 34817, 34818
+	processes := []ttyProcess{
+		{PID: 100, PPID: 1, Comm: "kitty"},
+		// Tab 1: vim started from bash
+		{PID: 200, PPID: 100, Comm: "bash", PGRP: 200, Session: 200, TTY: pts1, TPGID: 210},
+		{PID: 210, PPID: 200, Comm: "vim", PGRP: 210, Session: 200, TTY: pts1, TPGID: 210},
+		// Tab 2: ssh started from zsh
+		{PID: 300, PPID: 100, Comm: "zsh", PGRP: 300, Session: 300, TTY: pts2, TPGID: 310},
+		{PID: 310, PPID: 300, Comm: "ssh", PGRP: 310, Session: 300, TTY: pts2, TPGID: 310},
+		// Another terminal's shell
+		{PID: 400, PPID: 1, Comm: "bash", PGRP: 400, Session: 400, TTY: 34819, TPGID: 400},
+	}
+	now := time.Now()
+	activity := map[int]time.Time{pts1: now.Add(-time.Minute), pts2: now}
+	byActivity := func(tty int) time.Time { return activity[tty] }
+
+	if job := selectForeground(100, processes, byActivity); job.PID != 310 {
+		t.Errorf("foreground = %+v, want ssh in the tab typed into last", job)
+	}
+	activity[pts1] = now.Add(time.Second)
+	if job := selectForeground(100, processes, byActivity); job.PID != 210 {
+		t.Errorf("foreground = %+v, want vim after typing in its tab", job)
+	}
+
+	// Quitting vim leaves bash at its prompt
+	processes = append(processes[:2], processes[3:]...)
+	processes[1].TPGID = 200
+	activity[pts2] = now.Add(-time.Hour)
+	if job := selectForeground(100, processes, byActivity); job.PID != 0 {
+		t.Errorf("foreground = %+v, want none for a shell at its prompt", job)
+	}
+	if job := selectForeground(999, processes, byActivity); job.PID != 0 {
+		t.Errorf("foreground of an unknown terminal = %+v, want none", job)
+	}
+}
+
+// TestSelectForegroundGroupWithoutLeader tests that a pipeline whose first
+// command exited is still found through the rest of its process group
+func TestSelectForegroundGroupWithoutLeader(t *testing.T) {
+	processes := []ttyProcess{
+		{PID: 200, PPID: 100, Comm: "bash", PGRP: 200, Session: 200, TTY: 34817, TPGID: 210},
+		{PID: 212, PPID: 200, Comm: "less", PGRP: 210, Session: 200, TTY: 34817, TPGID: 210},
+	}
+	job := selectForeground(100, processes, func(int) time.Time { return time.Time{} })
+	if job.PID != 212 {
+		t.Errorf("foreground = %+v, want less", job)
+	}
+}
+
+// TestForegroundCommand tests shortening command lines for display
+func TestForegroundCommand(t *testing.T) {
+	if got := foregroundCommand([]string{"/usr/bin/ssh", "host"}); got != "ssh host" {
+		t.Errorf("foregroundCommand = %q, want ssh host", got)
+	}
+	if got := foregroundCommand(nil); got != "" {
+		t.Errorf("foregroundCommand(nil) = %q, want empty", got)
+	}
+	long := []string{"cat", string(make([]byte, 300))}
+	if got := foregroundCommand(long); len([]rune(got)) != maxCommandLength {
+		t.Errorf("long command has %d characters, want %d", len([]rune(got)), maxCommandLength)
+	}
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/wailsapp/wails/v2/pkg/runtime"
+)
+
+// WailsBridge forwards bus events to the frontend as Wails events
+// It is just another subscriber; nothing else depends on the Wails runtime
+type WailsBridge struct {
+	sub  *Subscription
+	done chan struct{}
+}
+
+// StartWailsBridge subscribes to all events and emits them with the live Wails context
+func StartWailsBridge(ctx context.Context, bus *EventBus) *WailsBridge {
+	bridge := &WailsBridge{
+		sub:  bus.Subscribe(0),
+		done: make(chan struct{}),
+	}
+	go bridge.run(ctx)
+	return bridge
+}
+
+// Stop unsubscribes from the bus
+func (b *WailsBridge) Stop() {
+	b.sub.Unsubscribe()
+	<-b.done
+}
+
+func (b *WailsBridge) run(ctx context.Context) {
+	defer close(b.done)
+	for event := range b.sub.C {
+		switch payload := event.Payload.(type) {
+		case WindowChangedEvent:
+			// The history log expects the plain WindowInfo
+			fmt.Printf("📤 Emitting event 'window-changed' with data: [%s] %s\n", payload.Window.Exe, payload.Window.Title)
+			runtime.EventsEmit(ctx, "window-changed", payload.Window)
+		case WarningEvent:
+			// The WarningModal component listens for 'warning-detected'
+			fmt.Printf("📤 Emitting 'warning-detected' event to frontend\n")
+			runtime.EventsEmit(ctx, "warning-detected", map[string]interface{}{
+				"executableName": payload.ExecutableName,
+				"displayName":    payload.DisplayName,
+				"title":          payload.Title,
+				"launchedBy":     payload.LaunchedBy,
+			})
+		default:
+			runtime.EventsEmit(ctx, string(event.Type), event)
+		}
+	}
+}
@@ -0,0 +1,347 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultWakaTimeListen is where the endpoint listens unless configured
+	defaultWakaTimeListen = "127.0.0.1:8090"
+	// codingTimeout is how long an editor window keeps the project of its last
+	// heartbeat, and the longest span counted without a window change; the
+	// same timeout WakaTime uses
+	codingTimeout = 15 * time.Minute
+	// maxWakaTimeBody caps a request; plugins send at most a few dozen heartbeats
+	maxWakaTimeBody = 1 << 20
+)
+
+// WakaTimeConfig configures the local WakaTime-compatible endpoint in settings.json
+type WakaTimeConfig struct {
+	Enabled bool   `json:"enabled"`
+	Listen  string `json:"listen,omitempty"` // "" = 127.0.0.1:8090
+}
+
+// listen returns the listen address, falling back to the default
+func (c WakaTimeConfig) listen() string {
+	if c.Listen == "" {
+		return defaultWakaTimeListen
+	}
+	return c.Listen
+}
+
+// editorKeywords identify the editor in a plugin's user agent, e.g.
+// "vscode/1.85.1 vscode-wakatime/24.4.0" or "PyCharm/2023.2 intellij-wakatime/14".
+// All JetBrains IDEs share a plugin, so their names come before "intellij"
+// and neovim before vim
+var editorKeywords = []string{
+	"vscode", "pycharm", "goland", "webstorm", "clion", "rider", "rubymine", "phpstorm", "datagrip",
+	"android studio", "intellij", "sublime", "neovim", "vim", "emacs", "zed",
+}
+
+// editorWindows maps editors' executables, Flatpak IDs and Snap names to
+// their keyword in editorKeywords
+var editorWindows = map[string]string{
+	"code":                                  "vscode",
+	"code-insiders":                         "vscode",
+	"code-oss":                              "vscode",
+	"codium":                                "vscode",
+	"cursor":                                "vscode",
+	"com.visualstudio.code":                 "vscode",
+	"com.vscodium.codium":                   "vscode",
+	"idea":                                  "intellij",
+	"idea64":                                "intellij",
+	"intellij-idea-community":               "intellij",
+	"intellij-idea-ultimate":                "intellij",
+	"com.jetbrains.intellij-idea-community": "intellij",
+	"com.jetbrains.intellij-idea-ultimate":  "intellij",
+	"pycharm":                               "pycharm",
+	"pycharm64":                             "pycharm",
+	"pycharm-community":                     "pycharm",
+	"pycharm-professional":                  "pycharm",
+	"com.jetbrains.pycharm-community":       "pycharm",
+	"goland":                                "goland",
+	"goland64":                              "goland",
+	"webstorm":                              "webstorm",
+	"webstorm64":                            "webstorm",
+	"clion":                                 "clion",
+	"clion64":                               "clion",
+	"rider":                                 "rider",
+	"rider64":                               "rider",
+	"rubymine":                              "rubymine",
+	"rubymine64":                            "rubymine",
+	"phpstorm":                              "phpstorm",
+	"phpstorm64":                            "phpstorm",
+	"datagrip":                              "datagrip",
+	"datagrip64":                            "datagrip",
+	"studio":                                "android studio",
+	"studio64":                              "android studio",
+	"sublime_text":                          "sublime",
+	"nvim":                                  "neovim",
+	"vim":                                   "vim",
+	"gvim":                                  "vim",
+	"emacs":                                 "emacs",
+	"zed":                                   "zed",
+}
+
+// editorOf returns the editor keyword in a plugin's user agent, "" if it
+// isn't a known editor. Keywords only count at the start of a word, so
+// "provider" isn't Rider
+func editorOf(userAgent string) string {
+	userAgent = strings.ToLower(userAgent)
+	for _, keyword := range editorKeywords {
+		for i := 0; i < len(userAgent); {
+			j := strings.Index(userAgent[i:], keyword)
+			if j < 0 {
+				break
+			}
+			if i+j == 0 || strings.ContainsRune(" (", rune(userAgent[i+j-1])) {
+				return keyword
+			}
+			i += j + 1
+		}
+	}
+	return ""
+}
+
+// editorOfWindow returns the editor keyword of a window: the job in a
+// focused terminal, else the app, "" if it isn't an editor
+func editorOfWindow(info *WindowInfo) string {
+	if info.Foreground.PID != 0 {
+		return editorWindows[normalizeExecutableName(info.Foreground.Exe)]
+	}
+	if editor := editorWindows[strings.ToLower(info.AppID)]; editor != "" {
+		return editor
+	}
+	return editorWindows[normalizeExecutableName(info.Exe)]
+}
+
+// CodingHeartbeat is a heartbeat an editor plugin sent
+type CodingHeartbeat struct {
+	Time     time.Time `json:"time"`
+	Editor   string    `json:"editor"` // Keyword from editorKeywords, "" for unknown editors
+	Entity   string    `json:"entity"` // File, app or domain
+	Type     string    `json:"type"`
+	Project  string    `json:"project,omitempty"`
+	Branch   string    `json:"branch,omitempty"`
+	Language string    `json:"language,omitempty"`
+	IsWrite  bool      `json:"isWrite,omitempty"`
+}
+
+// wakaHeartbeat is a heartbeat as plugins and wakatime-cli send it
+type wakaHeartbeat struct {
+	Entity    string  `json:"entity"`
+	Type      string  `json:"type"`
+	Category  string  `json:"category,omitempty"`
+	Time      float64 `json:"time"` // Unix time in seconds
+	Project   string  `json:"project,omitempty"`
+	Branch    string  `json:"branch,omitempty"`
+	Language  string  `json:"language,omitempty"`
+	IsWrite   bool    `json:"is_write,omitempty"`
+	UserAgent string  `json:"user_agent,omitempty"`
+}
+
+// codingHeartbeat converts a plugin's heartbeat; userAgent is the request's,
+// used when the heartbeat doesn't carry one
+func (h wakaHeartbeat) codingHeartbeat(userAgent string) CodingHeartbeat {
+	if h.UserAgent != "" {
+		userAgent = h.UserAgent
+	}
+	sec, frac := math.Modf(h.Time)
+	return CodingHeartbeat{
+		Time:     time.Unix(int64(sec), int64(frac*1e9)),
+		Editor:   editorOf(userAgent),
+		Entity:   h.Entity,
+		Type:     h.Type,
+		Project:  h.Project,
+		Branch:   h.Branch,
+		Language: h.Language,
+		IsWrite:  h.IsWrite,
+	}
+}
+
+// CodingActivity keeps the last heartbeat of every editor, which the watcher
+// uses to add the project to the window info of that editor's windows
+type CodingActivity struct {
+	mu     sync.Mutex
+	latest map[string]CodingHeartbeat // Editor keyword -> its newest heartbeat
+	now    func() time.Time
+}
+
+// NewCodingActivity creates an empty tracker
+func NewCodingActivity() *CodingActivity {
+	return &CodingActivity{latest: map[string]CodingHeartbeat{}, now: time.Now}
+}
+
+// Record keeps a heartbeat if it is the newest of its editor
+func (ca *CodingActivity) Record(heartbeat CodingHeartbeat) {
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	previous, ok := ca.latest[heartbeat.Editor]
+	if ok && previous.Time.After(heartbeat.Time) {
+		return
+	}
+	if !ok || previous.Project != heartbeat.Project {
+		fmt.Printf("⌨️  Coding in %s: %s\n", heartbeat.Editor, heartbeat.Project)
+	}
+	ca.latest[heartbeat.Editor] = heartbeat
+}
+
+// annotate adds the project of the editor's last heartbeat to the window info
+// of an editor window, unless the editor has been quiet for codingTimeout
+func (ca *CodingActivity) annotate(info *WindowInfo) {
+	editor := editorOfWindow(info)
+	if editor == "" {
+		return
+	}
+	ca.mu.Lock()
+	defer ca.mu.Unlock()
+	heartbeat, ok := ca.latest[editor]
+	if !ok || ca.now().Sub(heartbeat.Time) > codingTimeout {
+		return
+	}
+	info.Project = heartbeat.Project
+}
+
+// WakaTimeServer is a local stand-in for the WakaTime API, so editor plugins
+// pointed at it report which file and project is being worked on
+type WakaTimeServer struct {
+	config   WakaTimeConfig
+	activity *CodingActivity
+
+	mu       sync.Mutex
+	server   *http.Server
+	listener net.Listener
+}
+
+// NewWakaTimeServer creates an endpoint recording heartbeats in activity
+func NewWakaTimeServer(config WakaTimeConfig, activity *CodingActivity) *WakaTimeServer {
+	return &WakaTimeServer{config: config, activity: activity}
+}
+
+// Start listens for heartbeats
+func (ws *WakaTimeServer) Start() error {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.server != nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", ws.config.listen())
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", ws.config.listen(), err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/v1/users/current/heartbeats", ws.handleHeartbeats)
+	mux.HandleFunc("POST /api/v1/users/current/heartbeats.bulk", ws.handleHeartbeats)
+	ws.listener = listener
+	ws.server = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go ws.server.Serve(listener)
+	fmt.Printf("⌨️  WakaTime endpoint listening on http://%s/api/v1\n", listener.Addr())
+	return nil
+}
+
+// Stop closes the listener and open connections
+func (ws *WakaTimeServer) Stop() {
+	ws.mu.Lock()
+	server := ws.server
+	ws.server, ws.listener = nil, nil
+	ws.mu.Unlock()
+	if server != nil {
+		server.Close()
+	}
+}
+
+// Addr returns the address the endpoint listens on, "" when it isn't running
+func (ws *WakaTimeServer) Addr() string {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if ws.listener == nil {
+		return ""
+	}
+	return ws.listener.Addr().String()
+}
+
+// handleHeartbeats accepts one heartbeat or a list of them. Any API key is
+// accepted since only local plugins can reach the endpoint
+func (ws *WakaTimeServer) handleHeartbeats(w http.ResponseWriter, r *http.Request) {
+	data, err := io.ReadAll(io.LimitReader(r.Body, maxWakaTimeBody))
+	if err != nil {
+		http.Error(w, "failed to read heartbeats", http.StatusBadRequest)
+		return
+	}
+	bulk := bytes.HasPrefix(bytes.TrimSpace(data), []byte("["))
+	var heartbeats []wakaHeartbeat
+	if bulk {
+		err = json.Unmarshal(data, &heartbeats)
+	} else {
+		heartbeats = make([]wakaHeartbeat, 1)
+		err = json.Unmarshal(data, &heartbeats[0])
+	}
+	if err != nil {
+		http.Error(w, "malformed heartbeats", http.StatusBadRequest)
+		return
+	}
+
+	for _, heartbeat := range heartbeats {
+		ws.activity.Record(heartbeat.codingHeartbeat(r.UserAgent()))
+	}
+
+	// wakatime-cli reads a status per heartbeat from bulk responses
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	if !bulk {
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": heartbeats[0]})
+		return
+	}
+	responses := make([][]interface{}, len(heartbeats))
+	for i, heartbeat := range heartbeats {
+		responses[i] = []interface{}{map[string]interface{}{"data": heartbeat}, http.StatusCreated}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"responses": responses})
+}
+
+// ProjectTotal is the time spent in one project's editor windows
+type ProjectTotal struct {
+	Project  string        `json:"project"`
+	Duration time.Duration `json:"duration"`
+}
+
+// projectTotals adds up the time of history entries with a project, longest
+// first. An entry lasts until the next one; a quiet editor loses its project
+// after codingTimeout, which the watcher records as a new entry
+func projectTotals(entries []HistoryEntry, until time.Time) []ProjectTotal {
+	durations := map[string]time.Duration{}
+	for i, entry := range entries {
+		if entry.Project == "" {
+			continue
+		}
+		end := until
+		if i+1 < len(entries) {
+			end = entries[i+1].Time
+		}
+		if span := end.Sub(entry.Time); span > 0 {
+			durations[entry.Project] += span
+		}
+	}
+
+	totals := make([]ProjectTotal, 0, len(durations))
+	for project, duration := range durations {
+		totals = append(totals, ProjectTotal{Project: project, Duration: duration})
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].Duration != totals[j].Duration {
+			return totals[i].Duration > totals[j].Duration
+		}
+		return totals[i].Project < totals[j].Project
+	})
+	return totals
+}
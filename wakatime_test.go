@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestEditorOf tests that editors are recognized in plugin user agents
+func TestEditorOf(t *testing.T) {
+	tests := map[string]string{
+		"vscode/1.85.1 vscode-wakatime/24.4.0":                                                        "vscode",
+		"wakatime/v1.86.3 (linux-6.5.0-generic-x86_64) go1.21.4 vscode/1.85.1 vscode-wakatime/24.4.0": "vscode",
+		"PyCharm/2023.2 intellij-wakatime/14.2.2":                                                     "pycharm",
+		"IntelliJ IDEA/2023.3 intellij-wakatime/14.2.2":                                               "intellij",
+		"neovim/0.9.4 vim-wakatime/11.1.1":                                                            "neovim",
+		"vim/9.0 vim-wakatime/11.1.1":                                                                 "vim",
+		"Android Studio/2023.1 intellij-wakatime/14":                                                  "android studio",
+		"curl/8.0 provider-test":                                                                      "",
+		"":                                                                                            "",
+	}
+	for userAgent, want := range tests {
+		if got := editorOf(userAgent); got != want {
+			t.Errorf("editorOf(%q) = %q, want %q", userAgent, got, want)
+		}
+	}
+}
+
+// TestWakaTimeServerHeartbeats tests that single and bulk heartbeats are
+// accepted the way plugins and wakatime-cli send them
+func TestWakaTimeServerHeartbeats(t *testing.T) {
+	activity := NewCodingActivity()
+	server := NewWakaTimeServer(WakaTimeConfig{Listen: "127.0.0.1:0"}, activity)
+	if err := server.Start(); err != nil {
+		t.Fatalf("Start() failed: %v", err)
+	}
+	defer server.Stop()
+	base := "http://" + server.Addr() + "/api/v1/users/current/"
+	now := float64(time.Now().Unix())
+
+	post := func(path, body, userAgent string) map[string]json.RawMessage {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPost, base+path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Authorization", "Basic a2V5")
+		req.Header.Set("User-Agent", userAgent)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("POST %s failed: %v", path, err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusCreated {
+			t.Fatalf("POST %s = %d, want 201", path, resp.StatusCode)
+		}
+		var reply map[string]json.RawMessage
+		if err := json.NewDecoder(resp.Body).Decode(&reply); err != nil {
+			t.Fatalf("malformed reply to POST %s: %v", path, err)
+		}
+		return reply
+	}
+
+	single := `{"entity": "/src/sybr/main.go", "type": "file", "time": ` + jsonNumber(now-30) + `, "project": "sybr", "language": "Go"}`
+	if reply := post("heartbeats", single, "vscode/1.85.1 vscode-wakatime/24.4.0"); reply["data"] == nil {
+		t.Errorf("single reply = %v, want data", reply)
+	}
+	window := WindowInfo{Exe: "code.exe", Title: "main.go - sybr"}
+	activity.annotate(&window)
+	if window.Project != "sybr" {
+		t.Errorf("project after a single heartbeat = %q, want sybr", window.Project)
+	}
+
+	bulk := `[
+		{"entity": "/src/api/app.py", "type": "file", "time": ` + jsonNumber(now-10) + `, "project": "api", "user_agent": "PyCharm/2023.2 intellij-wakatime/14"},
+		{"entity": "/src/web/index.ts", "type": "file", "time": ` + jsonNumber(now) + `, "project": "web", "user_agent": "vscode/1.85.1 vscode-wakatime/24.4.0"}
+	]`
+	reply := post("heartbeats.bulk", bulk, "wakatime/v1.86.3")
+	var responses [][]json.RawMessage
+	if err := json.Unmarshal(reply["responses"], &responses); err != nil || len(responses) != 2 || string(responses[1][1]) != "201" {
+		t.Errorf("bulk responses = %s, want a 201 per heartbeat", reply["responses"])
+	}
+
+	for exe, want := range map[string]string{"code": "web", "pycharm64": "api", "idea64": "", "firefox": ""} {
+		window := WindowInfo{Exe: exe}
+		activity.annotate(&window)
+		if window.Project != want {
+			t.Errorf("project of %s = %q, want %q", exe, window.Project, want)
+		}
+	}
+}
+
+// jsonNumber formats a Unix time the way plugins send it
+func jsonNumber(f float64) string {
+	data, _ := json.Marshal(f + 0.5)
+	return string(data)
+}
+
+// TestCodingActivityAnnotate tests that editor windows only get the project
+// of recent heartbeats of the same editor, including editors in terminals
+func TestCodingActivityAnnotate(t *testing.T) {
+	now := time.Now()
+	activity := NewCodingActivity()
+	activity.now = func() time.Time { return now }
+	activity.Record(CodingHeartbeat{Time: now.Add(-time.Minute), Editor: "neovim", Project: "dotfiles"})
+	activity.Record(CodingHeartbeat{Time: now.Add(-2 * time.Minute), Editor: "neovim", Project: "older"})
+	activity.Record(CodingHeartbeat{Time: now.Add(-time.Hour), Editor: "vscode", Project: "stale"})
+
+	terminal := WindowInfo{Exe: "kitty", Foreground: TerminalJob{PID: 7, Exe: "nvim"}}
+	activity.annotate(&terminal)
+	if terminal.Project != "dotfiles" {
+		t.Errorf("terminal running nvim got project %q, want dotfiles", terminal.Project)
+	}
+	shell := WindowInfo{Exe: "kitty"}
+	activity.annotate(&shell)
+	if shell.Project != "" {
+		t.Errorf("terminal without an editor got project %q", shell.Project)
+	}
+	code := WindowInfo{Exe: "code"}
+	activity.annotate(&code)
+	if code.Project != "" {
+		t.Errorf("VS Code got project %q from a heartbeat an hour old", code.Project)
+	}
+}
+
+// TestProjectTotals tests that history spans are added up per project, however
+// long the editor stayed in one window
+func TestProjectTotals(t *testing.T) {
+	start := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	entries := []HistoryEntry{
+		{Time: start, Exe: "code", Project: "sybr"},
+		{Time: start.Add(10 * time.Minute), Exe: "firefox"},
+		{Time: start.Add(12 * time.Minute), Exe: "code", Project: "api"},
+		{Time: start.Add(14 * time.Minute), Exe: "code", Project: "sybr"},
+		{Time: start.Add(2 * time.Hour), Exe: "code", Project: "api"},
+	}
+	got := projectTotals(entries, start.Add(2*time.Hour+5*time.Minute))
+	want := []ProjectTotal{
+		{Project: "sybr", Duration: 116 * time.Minute}, // 10m, then an hour and 46 minutes of coding
+		{Project: "api", Duration: 7 * time.Minute},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("projectTotals() = %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("projectTotals()[%d] = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,433 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// watchdogCommand is the hidden subcommand that runs the watchdog helper
+const watchdogCommand = "watchdog"
+
+const (
+	// watchdogInterval is how often the main process and the watchdog ping each other
+	watchdogInterval = 2 * time.Second
+	// watchdogMinBackoff is the wait before the first restart; it doubles per restart
+	watchdogMinBackoff = time.Second
+	// watchdogMaxBackoff caps the wait between restarts
+	watchdogMaxBackoff = time.Minute
+	// watchdogStableAfter resets the back-off once a restarted process stayed up this long
+	watchdogStableAfter = 5 * time.Minute
+	// watchdogGiveUpAfter is how many pings may fail before the main process is ever reached
+	watchdogGiveUpAfter = 15
+)
+
+// WatchdogPing is what the main process answers to "watchdog.ping"
+type WatchdogPing struct {
+	PID       int    `json:"pid"`
+	Protected bool   `json:"protected"`        // A session or lock is running, restart if killed
+	Locked    bool   `json:"locked"`           // The commitment lock is active
+	Reason    string `json:"reason,omitempty"` // What is being protected
+}
+
+// TamperEvent is one line of the tamper log
+type TamperEvent struct {
+	Time     time.Time `json:"time"`
+	Kind     string    `json:"kind"` // TamperMainKilled or TamperWatchdogKilled
+	PID      int       `json:"pid"`
+	Reason   string    `json:"reason,omitempty"`
+	Restarts int       `json:"restarts"` // Restarts so far by this supervisor
+}
+
+// Tamper event kinds
+const (
+	TamperMainKilled     = "main-killed"
+	TamperWatchdogKilled = "watchdog-killed"
+)
+
+// appendTamperEvent appends an event to the tamper log at path
+func appendTamperEvent(path string, event TamperEvent) error {
+	if path == "" {
+		return nil
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal tamper event: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open tamper log: %w", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write tamper log: %w", err)
+	}
+	return nil
+}
+
+// readTamperEvents returns the events in the tamper log at path, oldest first
+func readTamperEvents(path string) ([]TamperEvent, error) {
+	events := []TamperEvent{}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return events, nil
+		}
+		return nil, err
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		var event TamperEvent
+		if line == "" || json.Unmarshal([]byte(line), &event) != nil {
+			continue
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+// restartBackoff spaces out restarts so a process that dies right away
+// doesn't turn into a restart storm
+type restartBackoff struct {
+	min, max, stableAfter time.Duration
+	next                  time.Duration
+	lastStart             time.Time
+}
+
+// wait returns how long to wait before the next restart
+func (b *restartBackoff) wait(now time.Time) time.Duration {
+	if b.next == 0 || (!b.lastStart.IsZero() && now.Sub(b.lastStart) >= b.stableAfter) {
+		b.next = b.min
+	}
+	wait := b.next
+	b.next *= 2
+	if b.next > b.max {
+		b.next = b.max
+	}
+	return wait
+}
+
+// started records that the process was (re)started
+func (b *restartBackoff) started(now time.Time) {
+	b.lastStart = now
+}
+
+// Watchdog runs in the helper process. It pings the main process and
+// restarts it if it disappears while a session or lock is running
+type Watchdog struct {
+	mainAddress string
+	start       func() (int, error) // Starts the main process and returns its PID
+	logPath     string
+	interval    time.Duration
+	backoff     restartBackoff
+
+	mu       sync.Mutex
+	last     WatchdogPing // Last answer from the main process
+	stopping bool         // The main process quit on purpose
+	restarts int
+	reported int // PID of the last kill recorded, so failed restarts aren't logged again
+}
+
+// NewWatchdog creates a watchdog for the main process listening on mainAddress
+func NewWatchdog(mainAddress string, start func() (int, error), logPath string) *Watchdog {
+	return &Watchdog{
+		mainAddress: mainAddress,
+		start:       start,
+		logPath:     logPath,
+		interval:    watchdogInterval,
+		backoff: restartBackoff{
+			min:         watchdogMinBackoff,
+			max:         watchdogMaxBackoff,
+			stableAfter: watchdogStableAfter,
+		},
+	}
+}
+
+// RegisterHandlers adds the methods the main process calls on the watchdog
+func (w *Watchdog) RegisterHandlers(server *IPCServer) {
+	server.Handle("watchdog.ping", func(params json.RawMessage) (interface{}, error) {
+		return WatchdogPing{PID: os.Getpid()}, nil
+	})
+	server.Handle("watchdog.stop", func(params json.RawMessage) (interface{}, error) {
+		// Ask the main process itself, a session may have started since the last ping
+		ping, err := pingIPC(w.mainAddress, "watchdog.ping")
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if err == nil {
+			w.last = ping
+		}
+		// The main process stops reporting itself protected once a quit passed
+		// its checks, so a stop while it still is would be followed by a kill
+		if w.last.Protected {
+			return nil, fmt.Errorf("%w: sybr is protecting %s", errCommitmentLocked, w.last.Reason)
+		}
+		w.stopping = true
+		return nil, nil
+	})
+}
+
+// Run supervises the main process until it exits while unprotected, it asks
+// the watchdog to stop, or stop is closed
+func (w *Watchdog) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	missed := 0
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		ping, err := pingIPC(w.mainAddress, "watchdog.ping")
+		if err == nil {
+			missed = 0
+			w.mu.Lock()
+			w.last = ping
+			w.mu.Unlock()
+			continue
+		}
+		missed++
+
+		w.mu.Lock()
+		last, stopping := w.last, w.stopping
+		w.mu.Unlock()
+		if last.PID == 0 {
+			// The main process was never reached; it may still be starting
+			if missed >= watchdogGiveUpAfter {
+				fmt.Println("🐕 Main process never answered, watchdog exiting")
+				return
+			}
+			continue
+		}
+		if processAlive(last.PID) {
+			// Busy or restarting its IPC server, not gone
+			continue
+		}
+		if stopping || !last.Protected {
+			fmt.Println("🐕 Main process exited, watchdog exiting")
+			return
+		}
+
+		w.restartMain(last, stop)
+		missed = 0
+	}
+}
+
+// restartMain records the kill and starts the main process again after the back-off
+func (w *Watchdog) restartMain(last WatchdogPing, stop <-chan struct{}) {
+	w.mu.Lock()
+	w.restarts++
+	event := TamperEvent{
+		Time:     time.Now(),
+		Kind:     TamperMainKilled,
+		PID:      last.PID,
+		Reason:   last.Reason,
+		Restarts: w.restarts,
+	}
+	report := w.reported != last.PID
+	w.reported = last.PID
+	w.mu.Unlock()
+	if report {
+		fmt.Printf("🐕 Main process %d was killed during %s, restarting\n", last.PID, last.Reason)
+		if err := appendTamperEvent(w.logPath, event); err != nil {
+			fmt.Printf("⚠️  Failed to record tamper event: %v\n", err)
+		}
+	}
+
+	wait := w.backoff.wait(time.Now())
+	select {
+	case <-stop:
+		return
+	case <-time.After(wait):
+	}
+
+	pid, err := w.start()
+	if err != nil {
+		fmt.Printf("❌ Failed to restart main process: %v\n", err)
+		return
+	}
+	w.backoff.started(time.Now())
+	// Keep treating the session as protected until the new process answers
+	w.mu.Lock()
+	w.last.PID = pid
+	w.mu.Unlock()
+}
+
+// pingIPC calls a ping method and returns the answer
+func pingIPC(address, method string) (WatchdogPing, error) {
+	var ping WatchdogPing
+	client, err := DialIPC(address)
+	if err != nil {
+		return ping, err
+	}
+	defer client.Close()
+	err = client.Call(method, nil, &ping)
+	return ping, err
+}
+
+// startDetached starts exe with args in the current directory, detached from
+// this process so it outlives it, and reaps it when it exits
+func startDetached(exe string, args []string) (int, error) {
+	cmd := exec.Command(exe, args...)
+	if wd, err := os.Getwd(); err == nil {
+		cmd.Dir = wd
+	}
+	detachCommand(cmd)
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	go cmd.Wait()
+	return cmd.Process.Pid, nil
+}
+
+// runWatchdog is the entry point of `sybr watchdog -- <main args>`
+func runWatchdog(args []string) int {
+	fs := flag.NewFlagSet(watchdogCommand, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return 2
+	}
+	mainArgs := fs.Args()
+
+	// Log next to sybr.log so the two processes don't interleave
+	if logFile, err := defaultLogFilePath(); err == nil {
+		if closeLog, err := redirectOutputToLogFile(filepath.Join(filepath.Dir(logFile), "watchdog.log")); err == nil {
+			defer closeLog()
+		}
+	}
+
+	exePath, err := getExecutablePath()
+	if err != nil {
+		fmt.Printf("❌ Watchdog can't find its executable: %v\n", err)
+		return 1
+	}
+	logPath, err := getDataFilePath("tamper.jsonl")
+	if err != nil {
+		fmt.Printf("⚠️  Tamper attempts won't be logged: %v\n", err)
+	}
+
+	watchdog := NewWatchdog(defaultIPCAddress(), func() (int, error) {
+		return startDetached(exePath, mainArgs)
+	}, logPath)
+
+	// Listening fails if another watchdog is already running
+	server := NewIPCServer(watchdogIPCAddress())
+	watchdog.RegisterHandlers(server)
+	if err := server.Start(); err != nil {
+		fmt.Printf("🐕 Watchdog not started: %v\n", err)
+		return 1
+	}
+	defer server.Stop()
+
+	fmt.Printf("🐕 Watchdog running (pid %d)\n", os.Getpid())
+	stop := make(chan struct{})
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		close(stop)
+	}()
+	watchdog.Run(stop)
+	return 0
+}
+
+// WatchdogSupervisor runs in the main process. It keeps the watchdog helper
+// running and records when it was killed during a session or lock
+type WatchdogSupervisor struct {
+	app     *App
+	start   func() (int, error) // Starts the watchdog and returns its PID
+	address string
+	logPath string
+	backoff restartBackoff
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+// StartWatchdogSupervisor starts the watchdog helper for this process and
+// restarts it when it goes away
+func StartWatchdogSupervisor(app *App, exePath string, mainArgs []string) *WatchdogSupervisor {
+	args := append([]string{watchdogCommand, "--"}, mainArgs...)
+	ws := &WatchdogSupervisor{
+		app:     app,
+		start:   func() (int, error) { return startDetached(exePath, args) },
+		address: watchdogIPCAddress(),
+		logPath: app.tamperLog,
+		backoff: restartBackoff{
+			min:         watchdogMinBackoff,
+			max:         watchdogMaxBackoff,
+			stableAfter: watchdogStableAfter,
+		},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	go ws.run()
+	return ws
+}
+
+func (ws *WatchdogSupervisor) run() {
+	defer close(ws.done)
+	ticker := time.NewTicker(watchdogInterval)
+	defer ticker.Stop()
+
+	lastPID := 0
+	for {
+		if ping, err := pingIPC(ws.address, "watchdog.ping"); err == nil {
+			lastPID = ping.PID
+		} else if lastPID == 0 || !processAlive(lastPID) {
+			if lastPID != 0 {
+				if status := ws.app.watchdogStatus(); status.Protected {
+					fmt.Printf("🐕 Watchdog %d was killed during %s, restarting it\n", lastPID, status.Reason)
+					event := TamperEvent{Time: time.Now(), Kind: TamperWatchdogKilled, PID: lastPID, Reason: status.Reason}
+					if err := appendTamperEvent(ws.logPath, event); err != nil {
+						fmt.Printf("⚠️  Failed to record tamper event: %v\n", err)
+					}
+				}
+				select {
+				case <-ws.stop:
+					return
+				case <-time.After(ws.backoff.wait(time.Now())):
+				}
+			}
+			if pid, err := ws.start(); err != nil {
+				fmt.Printf("⚠️  Failed to start watchdog: %v\n", err)
+			} else {
+				ws.backoff.started(time.Now())
+				lastPID = pid
+			}
+		}
+
+		select {
+		case <-ws.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Stop stops supervising; with release the watchdog is told sybr quit on
+// purpose so it doesn't restart it
+func (ws *WatchdogSupervisor) Stop(release bool) {
+	close(ws.stop)
+	<-ws.done
+	if !release {
+		return
+	}
+	client, err := DialIPC(ws.address)
+	if err != nil {
+		return
+	}
+	defer client.Close()
+	if err := client.Call("watchdog.stop", nil, nil); err != nil {
+		fmt.Printf("⚠️  Failed to stop watchdog: %v\n", err)
+	}
+}
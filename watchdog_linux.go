@@ -0,0 +1,29 @@
+package main
+
+import (
+	"os/exec"
+	"path/filepath"
+	"syscall"
+
+	"golang.org/x/sys/unix"
+)
+
+// watchdogIPCAddress returns the watchdog's Unix socket, next to the main one
+func watchdogIPCAddress() string {
+	return filepath.Join(filepath.Dir(defaultIPCAddress()), "watchdog.sock")
+}
+
+// detachCommand starts the process in its own session, so signals sent to
+// our process group (Ctrl+C, killing the terminal) don't reach it
+func detachCommand(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// processAlive reports whether a process with this PID exists
+func processAlive(pid int) bool {
+	if pid <= 0 {
+		return false
+	}
+	err := unix.Kill(pid, 0)
+	return err == nil || err == unix.EPERM
+}
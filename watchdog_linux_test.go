@@ -0,0 +1,219 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestWatchdogHelperProcess is not a real test: it is the fake main process
+// the watchdog tests start and kill
+func TestWatchdogHelperProcess(t *testing.T) {
+	address := os.Getenv("SYBR_WATCHDOG_HELPER_ADDR")
+	if address == "" {
+		return
+	}
+	protected := os.Getenv("SYBR_WATCHDOG_HELPER_PROTECTED") == "1"
+	server := NewIPCServer(address)
+	server.Handle("watchdog.ping", func(params json.RawMessage) (interface{}, error) {
+		return WatchdogPing{PID: os.Getpid(), Protected: protected, Reason: "a focus session"}, nil
+	})
+	if err := server.Start(); err != nil {
+		os.Exit(1)
+	}
+	select {}
+}
+
+// fakeMainStarter starts helper processes answering pings on address and
+// kills whatever is left when the test ends
+func fakeMainStarter(t *testing.T, address string, protected bool) func() (int, error) {
+	var mu sync.Mutex
+	var started []*os.Process
+	t.Cleanup(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, process := range started {
+			process.Kill()
+		}
+	})
+
+	return func() (int, error) {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestWatchdogHelperProcess$")
+		cmd.Env = append(os.Environ(), "SYBR_WATCHDOG_HELPER_ADDR="+address)
+		if protected {
+			cmd.Env = append(cmd.Env, "SYBR_WATCHDOG_HELPER_PROTECTED=1")
+		}
+		if err := cmd.Start(); err != nil {
+			return 0, err
+		}
+		go cmd.Wait() // Reap it so a killed helper doesn't linger as a zombie
+		mu.Lock()
+		started = append(started, cmd.Process)
+		mu.Unlock()
+		return cmd.Process.Pid, nil
+	}
+}
+
+// waitFor polls cond until it holds or the timeout passes
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// newTestWatchdog returns a watchdog with short intervals
+func newTestWatchdog(address string, start func() (int, error), logPath string) *Watchdog {
+	w := NewWatchdog(address, start, logPath)
+	w.interval = 50 * time.Millisecond
+	w.backoff.min = 50 * time.Millisecond
+	return w
+}
+
+// TestWatchdogRestartsKilledMain tests that killing the main process during a
+// session gets it restarted and logged
+func TestWatchdogRestartsKilledMain(t *testing.T) {
+	dir := t.TempDir()
+	address := filepath.Join(dir, "main.sock")
+	logPath := filepath.Join(dir, "tamper.jsonl")
+	start := fakeMainStarter(t, address, true)
+
+	first, err := start()
+	if err != nil {
+		t.Fatalf("starting the fake main process failed: %v", err)
+	}
+	w := newTestWatchdog(address, start, logPath)
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		w.Run(stop)
+		close(done)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	waitFor(t, "the watchdog to reach the main process", func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return w.last.PID == first
+	})
+	if err := syscall.Kill(first, syscall.SIGKILL); err != nil {
+		t.Fatalf("killing the main process failed: %v", err)
+	}
+
+	waitFor(t, "a restarted main process", func() bool {
+		ping, err := pingIPC(address, "watchdog.ping")
+		return err == nil && ping.PID != first
+	})
+	events, err := readTamperEvents(logPath)
+	if err != nil {
+		t.Fatalf("reading the tamper log failed: %v", err)
+	}
+	if len(events) != 1 || events[0].Kind != TamperMainKilled || events[0].PID != first {
+		t.Fatalf("tamper log = %+v, want one %s event for pid %d", events, TamperMainKilled, first)
+	}
+}
+
+// TestWatchdogLetsUnprotectedMainGo tests that the watchdog exits without a
+// restart when the main process ends outside of a session or lock
+func TestWatchdogLetsUnprotectedMainGo(t *testing.T) {
+	dir := t.TempDir()
+	address := filepath.Join(dir, "main.sock")
+	logPath := filepath.Join(dir, "tamper.jsonl")
+	starts := 0
+	startFake := fakeMainStarter(t, address, false)
+	start := func() (int, error) {
+		starts++
+		return startFake()
+	}
+
+	pid, err := start()
+	if err != nil {
+		t.Fatalf("starting the fake main process failed: %v", err)
+	}
+	w := newTestWatchdog(address, start, logPath)
+	done := make(chan struct{})
+	go func() {
+		w.Run(make(chan struct{}))
+		close(done)
+	}()
+
+	waitFor(t, "the watchdog to reach the main process", func() bool {
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		return w.last.PID == pid
+	})
+	syscall.Kill(pid, syscall.SIGKILL)
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("the watchdog didn't exit")
+	}
+	if starts != 1 {
+		t.Errorf("main process started %d times, want no restart", starts)
+	}
+	if events, _ := readTamperEvents(logPath); len(events) != 0 {
+		t.Errorf("tamper log = %+v, want it empty", events)
+	}
+}
+
+// TestWatchdogRefusesStopWhileProtected tests that a client can't release the
+// watchdog during an unlocked session and then kill the main process
+func TestWatchdogRefusesStopWhileProtected(t *testing.T) {
+	dir := t.TempDir()
+	address := filepath.Join(dir, "main.sock")
+	start := fakeMainStarter(t, address, true)
+
+	first, err := start()
+	if err != nil {
+		t.Fatalf("starting the fake main process failed: %v", err)
+	}
+	w := newTestWatchdog(address, start, filepath.Join(dir, "tamper.jsonl"))
+	server := NewIPCServer(filepath.Join(dir, "watchdog.sock"))
+	w.RegisterHandlers(server)
+	if err := server.Start(); err != nil {
+		t.Fatalf("starting the watchdog IPC server failed: %v", err)
+	}
+	defer server.Stop()
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		w.Run(stop)
+		close(done)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	waitFor(t, "the main process to answer", func() bool {
+		_, err := pingIPC(address, "watchdog.ping")
+		return err == nil
+	})
+	client, err := DialIPC(filepath.Join(dir, "watchdog.sock"))
+	if err != nil {
+		t.Fatalf("dialing the watchdog failed: %v", err)
+	}
+	defer client.Close()
+	if err := client.Call("watchdog.stop", nil, nil); err == nil {
+		t.Fatal("watchdog.stop was accepted during a session")
+	}
+
+	syscall.Kill(first, syscall.SIGKILL)
+	waitFor(t, "a restarted main process", func() bool {
+		ping, err := pingIPC(address, "watchdog.ping")
+		return err == nil && ping.PID != first
+	})
+}
@@ -3,152 +3,142 @@ package main
 import (
 	"context"
 	"fmt"
-	"strings"
 	"sync"
 	"time"
-	"unsafe"
-
-	"github.com/wailsapp/wails/v2/pkg/runtime"
-	"golang.org/x/sys/windows"
 )
 
-// WindowWatcher monitors the active window on Windows
+// idleThreshold is how long without keyboard/mouse input counts as idle
+const idleThreshold = 5 * time.Minute
+
+// defaultPollInterval is how often the active window is checked
+const defaultPollInterval = 1 * time.Second
+
+// windowEventBuffer bounds the channel returned by Subscribe
+const windowEventBuffer = 16
+
+// WindowWatcher monitors the active window
+// The platform specific lookup lives in watcher_windows.go and watcher_linux.go
+// Changes are published on the event bus; the watcher itself knows nothing
+// about the blocklist or the Wails runtime
 type WindowWatcher struct {
-	ctx           context.Context
-	currentTitle  string
-	currentExe    string
-	mu            sync.RWMutex
-	stopChan      chan struct{}
-	running       bool
-	lastWarnedExe string // Track last warned app to avoid spam
+	ctx          context.Context
+	bus          *EventBus
+	current      WindowInfo
+	currentSince time.Time
+	lastSeq      uint64
+	mu           sync.RWMutex
+	stopChan     chan struct{}
+	running      bool
+	idle         bool
+	idleWarned   bool            // Idle detection unsupported message already printed
+	tabs         *BrowserTabs    // Active tabs reported by browser extensions, may be nil
+	coding       *CodingActivity // Projects reported by editor plugins, may be nil
+
+	// Sources of truth, replaceable in tests
+	pollInterval time.Duration
+	activeWindow func() (*WindowInfo, error)
+	idleDuration func() (time.Duration, error)
 }
 
-// WindowInfo represents information about the active window
-type WindowInfo struct {
-	Title string `json:"title"`
-	Exe   string `json:"exe"`
+// WindowEvent is delivered by Subscribe for every real change of the active window
+type WindowEvent struct {
+	Seq      uint64      `json:"seq"`
+	Time     time.Time   `json:"time"`
+	Window   WindowInfo  `json:"window"`
+	Previous *WindowInfo `json:"previous,omitempty"`
 }
 
-// NewWindowWatcher creates a new WindowWatcher instance
-func NewWindowWatcher(ctx context.Context) *WindowWatcher {
-	return &WindowWatcher{
-		ctx:      ctx,
-		stopChan: make(chan struct{}),
-	}
+// WatcherSnapshot is the current state of the watcher
+type WatcherSnapshot struct {
+	Monitoring bool        `json:"monitoring"`
+	Window     *WindowInfo `json:"window,omitempty"`
+	Since      time.Time   `json:"since"` // When Window became active
+	Idle       bool        `json:"idle"`
+	Seq        uint64      `json:"seq"` // Sequence number of the last window change
 }
 
-// SetContext sets the Wails context for event emission
-// This must be called with the context from App.OnStartup for events to work
-func (ww *WindowWatcher) SetContext(ctx context.Context) {
-	ww.mu.Lock()
-	defer ww.mu.Unlock()
-	ww.ctx = ctx
-}
+// WindowInfo represents information about the active window
+type WindowInfo struct {
+	Title    string `json:"title"`
+	Exe      string `json:"exe"`
+	PID      int    `json:"pid,omitempty"`
+	AppID    string `json:"appId,omitempty"`    // Flatpak app ID, Snap or AppImage name, or the app an interpreter runs; Exe is then often just bwrap, AppRun or python
+	Launcher string `json:"launcher,omitempty"` // Executable that started the app, e.g. steam.exe
 
-// GetActiveWindow returns the current active window's title and process name
-func (ww *WindowWatcher) GetActiveWindow() (*WindowInfo, error) {
-	// Get the foreground window handle
-	hwnd := windows.GetForegroundWindow()
-	if hwnd == 0 {
-		return nil, fmt.Errorf("failed to get foreground window")
-	}
+	// Foreground is the job running in a focused terminal emulator, e.g. vim
+	Foreground TerminalJob `json:"foreground,omitzero"`
 
-	// Get window title
-	title, err := ww.getWindowTitle(uintptr(hwnd))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get window title: %w", err)
-	}
+	// Active tab of a browser with the sybr extension
+	URL    string `json:"url,omitempty"`
+	Domain string `json:"domain,omitempty"`
 
-	// Get process name
-	exe, err := ww.getProcessName(uintptr(hwnd))
-	if err != nil {
-		return nil, fmt.Errorf("failed to get process name: %w", err)
-	}
+	// Project an editor plugin reported for an editor window
+	Project string `json:"project,omitempty"`
+}
 
-	return &WindowInfo{
-		Title: title,
-		Exe:   exe,
-	}, nil
+// TerminalJob is the foreground process of a terminal's active tty
+type TerminalJob struct {
+	PID     int    `json:"pid"`
+	Exe     string `json:"exe"`
+	AppID   string `json:"appId,omitempty"`
+	Command string `json:"command,omitempty"` // Command line, e.g. ssh host
 }
 
-// getWindowTitle retrieves the title of a window using GetWindowTextW
-func (ww *WindowWatcher) getWindowTitle(hwnd uintptr) (string, error) {
-	user32 := windows.NewLazyDLL("user32.dll")
-	getWindowTextLengthW := user32.NewProc("GetWindowTextLengthW")
-	getWindowTextW := user32.NewProc("GetWindowTextW")
-
-	// Get the length of the window text
-	ret, _, _ := getWindowTextLengthW.Call(uintptr(hwnd))
-	length := int32(ret)
-	if length == 0 {
-		return "", nil // Empty title is valid
+// name returns the app ID of the job, or its executable
+func (j TerminalJob) name() string {
+	if j.AppID != "" {
+		return j.AppID
 	}
+	return j.Exe
+}
 
-	// Allocate buffer with the correct size + 1 for null terminator
-	buf := make([]uint16, length+1)
-	ret, _, err := getWindowTextW.Call(
-		uintptr(hwnd),
-		uintptr(unsafe.Pointer(&buf[0])),
-		uintptr(len(buf)),
-	)
-	if ret == 0 && err != nil {
-		return "", err
+// NewWindowWatcher creates a new WindowWatcher instance
+// If ctx is not nil, monitoring also stops when it is cancelled
+func NewWindowWatcher(ctx context.Context) *WindowWatcher {
+	ww := &WindowWatcher{
+		ctx:          ctx,
+		bus:          NewEventBus(),
+		stopChan:     make(chan struct{}),
+		pollInterval: defaultPollInterval,
+		idleDuration: getIdleDuration,
 	}
+	ww.activeWindow = ww.GetActiveWindow
+	return ww
+}
 
-	title := windows.UTF16ToString(buf)
-	return strings.TrimSpace(title), nil
+// SetEventBus sets the bus window and idle events are published on
+// Must be called before StartMonitoring
+func (ww *WindowWatcher) SetEventBus(bus *EventBus) {
+	ww.mu.Lock()
+	defer ww.mu.Unlock()
+	ww.bus = bus
 }
 
-// getProcessName retrieves the executable name of the process owning the window
-func (ww *WindowWatcher) getProcessName(hwnd uintptr) (string, error) {
-	var processID uint32
-	user32 := windows.NewLazyDLL("user32.dll")
-	getWindowThreadProcessId := user32.NewProc("GetWindowThreadProcessId")
-
-	// Get the process ID
-	ret, _, err := getWindowThreadProcessId.Call(
-		uintptr(hwnd),
-		uintptr(unsafe.Pointer(&processID)),
-	)
-	if ret == 0 && err != nil {
-		return "", fmt.Errorf("failed to get process ID: %w", err)
-	}
-	if processID == 0 {
-		return "", fmt.Errorf("invalid process ID")
-	}
+// SetBrowserTabs sets where the active tabs of browsers come from
+// Must be called before StartMonitoring
+func (ww *WindowWatcher) SetBrowserTabs(tabs *BrowserTabs) {
+	ww.mu.Lock()
+	defer ww.mu.Unlock()
+	ww.tabs = tabs
+}
 
-	// Open the process
-	processHandle, err := windows.OpenProcess(
-		windows.PROCESS_QUERY_INFORMATION|windows.PROCESS_VM_READ,
-		false,
-		processID,
-	)
-	if err != nil {
-		return "", fmt.Errorf("failed to open process: %w", err)
-	}
-	defer windows.CloseHandle(processHandle)
-
-	// Get the module base name (GetModuleBaseNameW is in psapi.dll, not kernel32.dll)
-	psapi := windows.NewLazyDLL("psapi.dll")
-	getModuleBaseNameW := psapi.NewProc("GetModuleBaseNameW")
-
-	buf := make([]uint16, windows.MAX_PATH)
-	ret, _, err = getModuleBaseNameW.Call(
-		uintptr(processHandle),
-		0,
-		uintptr(unsafe.Pointer(&buf[0])),
-		uintptr(len(buf)),
-	)
-	if ret == 0 && err != nil {
-		return "", fmt.Errorf("failed to get module base name: %w", err)
-	}
+// SetCodingActivity sets where the projects of editor windows come from
+// Must be called before StartMonitoring
+func (ww *WindowWatcher) SetCodingActivity(coding *CodingActivity) {
+	ww.mu.Lock()
+	defer ww.mu.Unlock()
+	ww.coding = coding
+}
 
-	exe := windows.UTF16ToString(buf)
-	return strings.ToLower(exe), nil
+// Events returns the bus the watcher publishes on
+func (ww *WindowWatcher) Events() *EventBus {
+	ww.mu.RLock()
+	defer ww.mu.RUnlock()
+	return ww.bus
 }
 
 // StartMonitoring starts polling the active window every second
-// and emits Wails events when the active window changes
+// and publishes events when the active window changes
 func (ww *WindowWatcher) StartMonitoring() error {
 	ww.mu.Lock()
 	if ww.running {
@@ -156,9 +146,10 @@ func (ww *WindowWatcher) StartMonitoring() error {
 		return fmt.Errorf("monitoring already running")
 	}
 	ww.running = true
+	stopChan := ww.stopChan
 	ww.mu.Unlock()
 
-	go ww.monitorLoop()
+	go ww.monitorLoop(stopChan)
 	return nil
 }
 
@@ -174,19 +165,97 @@ func (ww *WindowWatcher) StopMonitoring() {
 	ww.stopChan = make(chan struct{})
 }
 
+// IsMonitoring reports whether the monitoring loop is running
+func (ww *WindowWatcher) IsMonitoring() bool {
+	ww.mu.RLock()
+	defer ww.mu.RUnlock()
+	return ww.running
+}
+
+// Snapshot returns the current state without querying the OS
+func (ww *WindowWatcher) Snapshot() WatcherSnapshot {
+	ww.mu.RLock()
+	defer ww.mu.RUnlock()
+
+	snapshot := WatcherSnapshot{
+		Monitoring: ww.running,
+		Since:      ww.currentSince,
+		Idle:       ww.idle,
+		Seq:        ww.lastSeq,
+	}
+	if ww.current.Title != "" || ww.current.Exe != "" {
+		current := ww.current
+		snapshot.Window = &current
+	}
+	return snapshot
+}
+
+// Subscribe returns a channel that receives every change of the active window
+// until ctx is cancelled, after which the channel is closed. A consumer that
+// falls behind misses changes rather than stalling the watcher
+func (ww *WindowWatcher) Subscribe(ctx context.Context) <-chan WindowEvent {
+	sub := ww.Events().Subscribe(windowEventBuffer, EventWindowChanged)
+	out := make(chan WindowEvent, windowEventBuffer)
+
+	go func() {
+		defer close(out)
+		defer sub.Unsubscribe()
+
+		var last *WindowInfo
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-sub.C:
+				if !ok {
+					return
+				}
+				changed, ok := event.Payload.(WindowChangedEvent)
+				if !ok || (last != nil && *last == changed.Window) {
+					continue
+				}
+				window := changed.Window
+				last = &window
+
+				select {
+				case out <- WindowEvent{
+					Seq:      event.Seq,
+					Time:     event.Time,
+					Window:   changed.Window,
+					Previous: changed.Previous,
+				}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return out
+}
+
 // monitorLoop runs the monitoring ticker
-func (ww *WindowWatcher) monitorLoop() {
-	ticker := time.NewTicker(1 * time.Second)
+func (ww *WindowWatcher) monitorLoop(stopChan chan struct{}) {
+	ticker := time.NewTicker(ww.pollInterval)
 	defer ticker.Stop()
 
+	var ctxDone <-chan struct{}
+	if ww.ctx != nil {
+		ctxDone = ww.ctx.Done()
+	}
+
 	firstWindow := true
 
 	for {
 		select {
-		case <-ww.stopChan:
+		case <-stopChan:
+			return
+		case <-ctxDone:
+			ww.StopMonitoring()
 			return
 		case <-ticker.C:
-			info, err := ww.GetActiveWindow()
+			ww.checkIdle()
+
+			info, err := ww.activeWindow()
 			if err != nil {
 				// Log error but continue monitoring
 				fmt.Printf("Error getting active window: %v\n", err)
@@ -199,102 +268,88 @@ func (ww *WindowWatcher) monitorLoop() {
 			}
 
 			ww.mu.Lock()
-			titleChanged := ww.currentTitle != info.Title
-			exeChanged := ww.currentExe != info.Exe
-			isFirstWindow := firstWindow && (ww.currentTitle == "" && ww.currentExe == "")
-			ww.mu.Unlock()
+			if ww.tabs != nil {
+				ww.tabs.annotate(info)
+			}
+			if ww.coding != nil {
+				ww.coding.annotate(info)
+			}
+			titleChanged := ww.current.Title != info.Title
+			exeChanged := ww.current.Exe != info.Exe
+			foregroundChanged := ww.current.Foreground != info.Foreground
+			domainChanged := ww.current.Domain != info.Domain
+			projectChanged := ww.current.Project != info.Project
+			isFirstWindow := firstWindow && (ww.current.Title == "" && ww.current.Exe == "")
+			var previous *WindowInfo
+			if ww.current.Title != "" || ww.current.Exe != "" {
+				current := ww.current
+				previous = &current
+			}
+			changed := titleChanged || exeChanged || foregroundChanged || domainChanged || projectChanged || isFirstWindow
+			if changed {
+				if info.Launcher == "" && info.PID > 0 {
+					if exeChanged || info.PID != ww.current.PID {
+						info.Launcher = launcherOf(info.Exe, processAncestors(info.PID))
+					} else {
+						info.Launcher = ww.current.Launcher
+					}
+				}
 
-			if titleChanged || exeChanged || isFirstWindow {
-				ww.mu.Lock()
-				ww.currentTitle = info.Title
-				ww.currentExe = info.Exe
+				// Publish under the lock so Snapshot and the event stay consistent
+				ww.current = *info
 				firstWindow = false
-				ww.mu.Unlock()
 
 				// Print to console for debugging (terminal output)
-				fmt.Printf("Active Window Changed: [%s] %s\n", info.Exe, info.Title)
-
-				// Check if app is blocked
-				bm, err := GetBlocklistManager()
-				if err == nil && bm != nil {
-					// Normalize executable name for comparison
-					exeLower := strings.ToLower(strings.TrimSpace(info.Exe))
-
-					// Debug logging
-					fmt.Printf("🔍 Checking if blocked: exe=%s\n", exeLower)
-
-					isBlocked := bm.IsBlocked(exeLower)
-					fmt.Printf("🔍 IsBlocked result for '%s': %v\n", exeLower, isBlocked)
-
-					if isBlocked {
-						fmt.Printf("✅ BLOCKED APP DETECTED!\n")
-						// Only warn if this is a different app (avoid spam)
-						ww.mu.Lock()
-						shouldWarn := ww.lastWarnedExe != exeLower
-						if shouldWarn {
-							ww.lastWarnedExe = exeLower
-						}
-						ww.mu.Unlock()
-
-						if shouldWarn {
-							blockedApp := bm.GetBlockedApp(exeLower)
-							displayName := exeLower
-							if blockedApp != nil && blockedApp.DisplayName != "" {
-								displayName = blockedApp.DisplayName
-							}
-
-							fmt.Printf("⚠️  Blocked app detected: [%s] %s\n", exeLower, info.Title)
-
-							// Show native Windows MessageBox
-							message := fmt.Sprintf("You're trying to open a blocked application:\n\n%s\n\nWindow: %s", displayName, info.Title)
-							fmt.Printf("📢 Calling ShowSystemWarning...\n")
-							_, err := ShowSystemWarning("⚠️ Focus Warning", message)
-							if err != nil {
-								fmt.Printf("❌ Failed to show warning MessageBox: %v\n", err)
-							} else {
-								fmt.Printf("✅ MessageBox shown successfully\n")
-							}
-
-							// Also emit warning event for frontend (WarningModal component)
-							ww.mu.RLock()
-							ctx := ww.ctx
-							ww.mu.RUnlock()
-							if ctx != nil {
-								warningData := map[string]interface{}{
-									"executableName": exeLower,
-									"displayName":    displayName,
-									"title":          info.Title,
-								}
-								fmt.Printf("📤 Emitting 'warning-detected' event to frontend\n")
-								runtime.EventsEmit(ctx, "warning-detected", warningData)
-							}
-						} else {
-							fmt.Printf("⏭️  Same blocked app, skipping duplicate warning\n")
-						}
-					} else {
-						// Reset last warned if app is not blocked
-						ww.mu.Lock()
-						if ww.lastWarnedExe == exeLower {
-							ww.lastWarnedExe = ""
-						}
-						ww.mu.Unlock()
-					}
-				} else {
-					fmt.Printf("⚠️  Failed to get blocklist manager: %v\n", err)
+				label := info.Exe
+				if info.Foreground.PID != 0 {
+					label += " > " + info.Foreground.name()
 				}
-
-				// Emit Wails event if context is available
-				// This sends the data to the frontend history log
-				ww.mu.RLock()
-				ctx := ww.ctx
-				ww.mu.RUnlock()
-				if ctx != nil {
-					fmt.Printf("📤 Emitting event 'window-changed' with data: [%s] %s\n", info.Exe, info.Title)
-					runtime.EventsEmit(ctx, "window-changed", info)
-				} else {
-					fmt.Println("⚠️  Cannot emit event: context is nil")
+				if info.Domain != "" {
+					label += " @ " + info.Domain
 				}
+				if info.Project != "" {
+					label += " in " + info.Project
+				}
+				fmt.Printf("Active Window Changed: [%s] %s\n", label, info.Title)
+
+				event := ww.bus.Publish(EventWindowChanged, WindowChangedEvent{
+					Window:   *info,
+					Previous: previous,
+				})
+				ww.currentSince = event.Time
+				ww.lastSeq = event.Seq
 			}
+			ww.mu.Unlock()
 		}
 	}
 }
+
+// checkIdle publishes EventIdleChanged when the user goes idle or comes back
+func (ww *WindowWatcher) checkIdle() {
+	idleFor, err := ww.idleDuration()
+	if err != nil {
+		ww.mu.Lock()
+		warned := ww.idleWarned
+		ww.idleWarned = true
+		ww.mu.Unlock()
+		if !warned {
+			fmt.Printf("⚠️  Idle detection unavailable: %v\n", err)
+		}
+		return
+	}
+
+	idle := idleFor >= idleThreshold
+	ww.mu.Lock()
+	changed := idle != ww.idle
+	ww.idle = idle
+	bus := ww.bus
+	ww.mu.Unlock()
+
+	if changed {
+		fmt.Printf("💤 Idle changed: idle=%v (no input for %s)\n", idle, idleFor.Round(time.Second))
+		bus.Publish(EventIdleChanged, IdleChangedEvent{
+			Idle:      idle,
+			IdleSince: time.Now().Add(-idleFor),
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	xpropWindowIDPattern = regexp.MustCompile(`window id # (0x[0-9a-fA-F]+)`)
+	xpropPIDPattern      = regexp.MustCompile(`_NET_WM_PID(?:\(CARDINAL\))? = (\d+)`)
+	xpropNamePattern     = regexp.MustCompile(`(?m)^(?:_NET_WM_NAME|WM_NAME)(?:\([A-Z_0-9]+\))? = (".*")$`)
+)
+
+// GetActiveWindow returns the current active window's title and process name
+// It queries the X server through xprop (EWMH _NET_ACTIVE_WINDOW), which also
+// works for XWayland windows on most desktops
+func (ww *WindowWatcher) GetActiveWindow() (*WindowInfo, error) {
+	if os.Getenv("DISPLAY") == "" {
+		return nil, fmt.Errorf("no X display available (DISPLAY is not set)")
+	}
+
+	out, err := exec.Command("xprop", "-root", "_NET_ACTIVE_WINDOW").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active window: %w", err)
+	}
+	match := xpropWindowIDPattern.FindSubmatch(out)
+	if match == nil || string(match[1]) == "0x0" {
+		return nil, fmt.Errorf("failed to get foreground window")
+	}
+	windowID := string(match[1])
+
+	// Get window title
+	title, pid, err := ww.getWindowProperties(windowID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get window title: %w", err)
+	}
+
+	// Get process name
+	exe, err := ww.getProcessName(pid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get process name: %w", err)
+	}
+
+	info := &WindowInfo{
+		Title: title,
+		Exe:   exe,
+		PID:   pid,
+		AppID: resolveAppID(pid, processPath(pid)),
+	}
+	// A terminal is only as telling as what runs in it
+	if terminalEmulators[exe] {
+		info.Foreground = terminalForeground(pid)
+	}
+	return info, nil
+}
+
+// getWindowProperties reads the title and owning PID of an X window
+func (ww *WindowWatcher) getWindowProperties(windowID string) (string, int, error) {
+	out, err := exec.Command("xprop", "-id", windowID, "_NET_WM_PID", "_NET_WM_NAME", "WM_NAME").Output()
+	if err != nil {
+		return "", 0, err
+	}
+
+	title := ""
+	if match := xpropNamePattern.FindSubmatch(out); match != nil {
+		if unquoted, err := strconv.Unquote(string(match[1])); err == nil {
+			title = unquoted
+		} else {
+			title = strings.Trim(string(match[1]), `"`)
+		}
+	}
+
+	pid := 0
+	if match := xpropPIDPattern.FindSubmatch(out); match != nil {
+		pid, _ = strconv.Atoi(string(match[1]))
+	}
+	return strings.TrimSpace(title), pid, nil
+}
+
+// getProcessName retrieves the executable name of a process from /proc
+func (ww *WindowWatcher) getProcessName(pid int) (string, error) {
+	if pid <= 0 {
+		return "", fmt.Errorf("invalid process ID")
+	}
+
+	// The exe link is the most reliable, but is unreadable for other users' processes
+	if target, err := os.Readlink(fmt.Sprintf("/proc/%d/exe", pid)); err == nil {
+		// A replaced binary shows up as "/usr/bin/foo (deleted)"
+		target = strings.TrimSuffix(target, " (deleted)")
+		return strings.ToLower(filepath.Base(target)), nil
+	}
+
+	comm, err := os.ReadFile(fmt.Sprintf("/proc/%d/comm", pid))
+	if err != nil {
+		return "", fmt.Errorf("failed to read process name: %w", err)
+	}
+	return strings.ToLower(strings.TrimSpace(string(comm))), nil
+}
+
+// getIdleDuration returns the time since the last keyboard or mouse input
+// It relies on xprintidle (X11 screensaver extension)
+func getIdleDuration() (time.Duration, error) {
+	out, err := exec.Command("xprintidle").Output()
+	if err != nil {
+		return 0, fmt.Errorf("xprintidle failed: %w", err)
+	}
+	ms, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("unexpected xprintidle output: %w", err)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeWindowSource returns a scripted sequence of windows, repeating the last one
+type fakeWindowSource struct {
+	mu      sync.Mutex
+	windows []WindowInfo
+}
+
+func (f *fakeWindowSource) next() (*WindowInfo, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	info := f.windows[0]
+	if len(f.windows) > 1 {
+		f.windows = f.windows[1:]
+	}
+	return &info, nil
+}
+
+// newTestWatcher returns a watcher polling the fake source every few milliseconds
+func newTestWatcher(source *fakeWindowSource) *WindowWatcher {
+	watcher := NewWindowWatcher(nil)
+	watcher.pollInterval = 5 * time.Millisecond
+	watcher.activeWindow = source.next
+	watcher.idleDuration = func() (time.Duration, error) { return 0, nil }
+	return watcher
+}
+
+// TestWatcherSubscribe tests that subscribers get each real change exactly
+// once and that the channel closes when the context is cancelled
+func TestWatcherSubscribe(t *testing.T) {
+	source := &fakeWindowSource{windows: []WindowInfo{
+		{Exe: "code.exe", Title: "main.go"},
+		{Exe: "code.exe", Title: "main.go"},
+		{Exe: "firefox.exe", Title: "Docs"},
+		{Exe: "firefox.exe", Title: "Docs"},
+		{Exe: "code.exe", Title: "watcher.go"},
+	}}
+	watcher := newTestWatcher(source)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	events := watcher.Subscribe(ctx)
+
+	if err := watcher.StartMonitoring(); err != nil {
+		t.Fatalf("StartMonitoring() failed: %v", err)
+	}
+	defer watcher.StopMonitoring()
+
+	want := []string{"main.go", "Docs", "watcher.go"}
+	var lastSeq uint64
+	for i, title := range want {
+		select {
+		case event := <-events:
+			if event.Window.Title != title {
+				t.Fatalf("event %d title = %q, want %q", i, event.Window.Title, title)
+			}
+			if event.Seq <= lastSeq {
+				t.Errorf("event %d seq %d not after %d", i, event.Seq, lastSeq)
+			}
+			if i > 0 && (event.Previous == nil || event.Previous.Title != want[i-1]) {
+				t.Errorf("event %d previous = %+v, want %q", i, event.Previous, want[i-1])
+			}
+			lastSeq = event.Seq
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+
+	// The source now repeats the last window: no more events
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected duplicate event %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	snapshot := watcher.Snapshot()
+	if !snapshot.Monitoring || snapshot.Window == nil || snapshot.Window.Title != "watcher.go" || snapshot.Seq != lastSeq {
+		t.Errorf("unexpected snapshot %+v", snapshot)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-events:
+		if ok {
+			t.Error("expected channel to be closed after cancel")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel not closed after cancel")
+	}
+}
+
+// TestWatcherTerminalJobChange tests that starting a job in the focused
+// terminal counts as a window change even though the window stays the same
+func TestWatcherTerminalJobChange(t *testing.T) {
+	source := &fakeWindowSource{windows: []WindowInfo{
+		{Exe: "kitty", Title: "~"},
+		{Exe: "kitty", Title: "~", Foreground: TerminalJob{PID: 42, Exe: "vim", Command: "vim notes.md"}},
+	}}
+	watcher := newTestWatcher(source)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := watcher.Subscribe(ctx)
+	if err := watcher.StartMonitoring(); err != nil {
+		t.Fatalf("StartMonitoring() failed: %v", err)
+	}
+	defer watcher.StopMonitoring()
+
+	for i, want := range []string{"", "vim"} {
+		select {
+		case event := <-events:
+			if event.Window.Foreground.Exe != want {
+				t.Fatalf("event %d foreground = %+v, want %q", i, event.Window.Foreground, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+}
+
+// TestWatcherProjectChange tests that a heartbeat naming another project
+// counts as a window change for the focused editor
+func TestWatcherProjectChange(t *testing.T) {
+	source := &fakeWindowSource{windows: []WindowInfo{{Exe: "code", Title: "main.go"}}}
+	watcher := newTestWatcher(source)
+	coding := NewCodingActivity()
+	watcher.SetCodingActivity(coding)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events := watcher.Subscribe(ctx)
+	if err := watcher.StartMonitoring(); err != nil {
+		t.Fatalf("StartMonitoring() failed: %v", err)
+	}
+	defer watcher.StopMonitoring()
+
+	for i, want := range []string{"", "sybr"} {
+		select {
+		case event := <-events:
+			if event.Window.Project != want {
+				t.Fatalf("event %d project = %q, want %q", i, event.Window.Project, want)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+		coding.Record(CodingHeartbeat{Time: time.Now(), Editor: "vscode", Project: "sybr"})
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// GetActiveWindow returns the current active window's title and process name
+func (ww *WindowWatcher) GetActiveWindow() (*WindowInfo, error) {
+	// Get the foreground window handle
+	hwnd := windows.GetForegroundWindow()
+	if hwnd == 0 {
+		return nil, fmt.Errorf("failed to get foreground window")
+	}
+
+	// Get window title
+	title, err := ww.getWindowTitle(uintptr(hwnd))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get window title: %w", err)
+	}
+
+	// Get process name
+	exe, pid, err := ww.getProcessName(uintptr(hwnd))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get process name: %w", err)
+	}
+
+	return &WindowInfo{
+		Title: title,
+		Exe:   exe,
+		PID:   int(pid),
+		AppID: resolveAppID(int(pid), exe),
+	}, nil
+}
+
+// getWindowTitle retrieves the title of a window using GetWindowTextW
+func (ww *WindowWatcher) getWindowTitle(hwnd uintptr) (string, error) {
+	user32 := windows.NewLazyDLL("user32.dll")
+	getWindowTextLengthW := user32.NewProc("GetWindowTextLengthW")
+	getWindowTextW := user32.NewProc("GetWindowTextW")
+
+	// Get the length of the window text
+	ret, _, _ := getWindowTextLengthW.Call(uintptr(hwnd))
+	length := int32(ret)
+	if length == 0 {
+		return "", nil // Empty title is valid
+	}
+
+	// Allocate buffer with the correct size + 1 for null terminator
+	buf := make([]uint16, length+1)
+	ret, _, err := getWindowTextW.Call(
+		uintptr(hwnd),
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(len(buf)),
+	)
+	if ret == 0 && err != nil {
+		return "", err
+	}
+
+	title := windows.UTF16ToString(buf)
+	return strings.TrimSpace(title), nil
+}
+
+// getProcessName retrieves the executable name and ID of the process owning the window
+func (ww *WindowWatcher) getProcessName(hwnd uintptr) (string, uint32, error) {
+	var processID uint32
+	user32 := windows.NewLazyDLL("user32.dll")
+	getWindowThreadProcessId := user32.NewProc("GetWindowThreadProcessId")
+
+	// Get the process ID
+	ret, _, err := getWindowThreadProcessId.Call(
+		uintptr(hwnd),
+		uintptr(unsafe.Pointer(&processID)),
+	)
+	if ret == 0 && err != nil {
+		return "", 0, fmt.Errorf("failed to get process ID: %w", err)
+	}
+	if processID == 0 {
+		return "", 0, fmt.Errorf("invalid process ID")
+	}
+
+	// Open the process
+	processHandle, err := windows.OpenProcess(
+		windows.PROCESS_QUERY_INFORMATION|windows.PROCESS_VM_READ,
+		false,
+		processID,
+	)
+	if err != nil {
+		return "", 0, fmt.Errorf("failed to open process: %w", err)
+	}
+	defer windows.CloseHandle(processHandle)
+
+	// Get the module base name (GetModuleBaseNameW is in psapi.dll, not kernel32.dll)
+	psapi := windows.NewLazyDLL("psapi.dll")
+	getModuleBaseNameW := psapi.NewProc("GetModuleBaseNameW")
+
+	buf := make([]uint16, windows.MAX_PATH)
+	ret, _, err = getModuleBaseNameW.Call(
+		uintptr(processHandle),
+		0,
+		uintptr(unsafe.Pointer(&buf[0])),
+		uintptr(len(buf)),
+	)
+	if ret == 0 && err != nil {
+		return "", 0, fmt.Errorf("failed to get module base name: %w", err)
+	}
+
+	exe := windows.UTF16ToString(buf)
+	return strings.ToLower(exe), processID, nil
+}
+
+// lastInputInfo mirrors the Win32 LASTINPUTINFO struct
+type lastInputInfo struct {
+	cbSize uint32
+	dwTime uint32
+}
+
+// getIdleDuration returns the time since the last keyboard or mouse input
+func getIdleDuration() (time.Duration, error) {
+	user32 := windows.NewLazyDLL("user32.dll")
+	getLastInputInfo := user32.NewProc("GetLastInputInfo")
+	kernel32 := windows.NewLazyDLL("kernel32.dll")
+	getTickCount := kernel32.NewProc("GetTickCount")
+
+	info := lastInputInfo{}
+	info.cbSize = uint32(unsafe.Sizeof(info))
+	ret, _, err := getLastInputInfo.Call(uintptr(unsafe.Pointer(&info)))
+	if ret == 0 {
+		return 0, fmt.Errorf("GetLastInputInfo failed: %w", err)
+	}
+	now, _, _ := getTickCount.Call()
+
+	// Both are 32-bit millisecond tick counts, so subtracting handles wraparound
+	return time.Duration(uint32(now)-info.dwTime) * time.Millisecond, nil
+}